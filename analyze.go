@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// stringsFlag collects every use of a repeatable command-line flag
+type stringsFlag []string
+
+func (self *stringsFlag) String() string {
+	return strings.Join(*self, " ")
+}
+
+// Set implements flag.Value
+func (self *stringsFlag) Set(value string) error {
+	*self = append(*self, value)
+	return nil
+}
+
+// analyzeCommand implements the "analyze" subcommand, which re-solves variations of a scenario to
+// show how much margin the player really has
+func analyzeCommand(args []string) {
+	flags := flag.NewFlagSet("analyze", flag.ExitOnError)
+	scenarioFlags := newScenarioFlags(flags)
+	vary := stringsFlag{}
+	flags.Var(&vary, "vary", "re-solve across a range of offsets, e.g. start.power=-2..+2 (repeatable)")
+	criticality := flags.Bool("criticality", false, "re-solve without each command to find which are essential")
+	minStart := flags.Bool("min-start", false, "find the least of each starting resource with which the scenario is solvable")
+	timeout := flags.Duration("timeout", 0, "give up analyzing after this long (e.g. 5m), keeping what was shown by then")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("Usage: ", os.Args[0], " analyze [OPTIONS]")
+	}
+	if len(vary) == 0 && !*criticality && !*minStart {
+		log.Fatal("Nothing to analyze (try -vary, -criticality, or -min-start)")
+	}
+	scenario := scenarioFlags.load()
+
+	variations := []*variation{}
+	for _, spec := range vary {
+		v, err := parseVariation(spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		variations = append(variations, v)
+	}
+	ctx, stopInterrupting := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stopInterrupting()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	var err error
+	if len(variations) > 0 {
+		err = analyzeSensitivity(ctx, scenario, variations)
+	}
+	if *criticality && err == nil {
+		err = analyzeCriticality(ctx, scenario)
+	}
+	if *minStart && err == nil {
+		err = analyzeMinimumStart(ctx, scenario)
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Fatal("Analysis timed out after ", *timeout)
+	case errors.Is(err, context.Canceled):
+		log.Fatal("Analysis interrupted")
+	case err != nil:
+		log.Fatal(err)
+	}
+}
+
+// bestSolution re-solves the scenario (as Scenario.BestSolution), returning nil if it has no solution,
+// or the context's error once it is done.  Unlike the serial engine the parallel one stops as soon as
+// the context is done.
+func bestSolution(ctx context.Context, scenario *solver.Scenario) (*solver.Sequence, error) {
+	found, err := solver.Solve(scenario, solver.Options{Engine: "parallel", Limit: 1, Shallowest: true, Context: ctx})
+	if err == nil {
+		err = ctx.Err() // What was found may not be the best
+	}
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return found[0].Sequence, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// variation is a range of offsets to apply to one resource of the scenario's start or goal
+type variation struct {
+	section  string
+	resource solver.Resource
+	from     int
+	to       int
+}
+
+// parseVariation parses specifications such as "start.power=-2..+2"
+func parseVariation(spec string) (*variation, error) {
+	v := variation{}
+	target, offsets, ok := strings.Cut(spec, "=")
+	name := ""
+	if ok {
+		v.section, name, ok = strings.Cut(target, ".")
+	}
+	if ok {
+		v.resource, ok = solver.ResourceNamed(name)
+	}
+	if !ok || (v.section != "start" && v.section != "goal") {
+		return nil, fmt.Errorf("invalid variation %q (expected e.g. start.power=-2..+2)", spec)
+	}
+	from, to, ok := strings.Cut(offsets, "..")
+	var err error
+	if v.from, err = strconv.Atoi(from); err != nil || !ok {
+		return nil, fmt.Errorf("invalid range in variation %q", spec)
+	}
+	if v.to, err = strconv.Atoi(to); err != nil || v.to < v.from {
+		return nil, fmt.Errorf("invalid range in variation %q", spec)
+	}
+	return &v, nil
+}
+
+func (self *variation) apply(scenario *solver.Scenario, offset int) {
+	resources := &scenario.Start
+	if self.section == "goal" {
+		resources = &scenario.Goal
+	}
+	resources.Set(self.resource, resources.Get(self.resource)+offset)
+}
+
+// analyzeSensitivity re-solves the scenario for every combination of offsets in the variations,
+// reporting whether each is solvable and the length of its best solution
+func analyzeSensitivity(ctx context.Context, scenario *solver.Scenario, variations []*variation) error {
+	offsets := make([]int, len(variations))
+	for i, v := range variations {
+		offsets[i] = v.from
+	}
+	for {
+		labels := []string{}
+		variant, err := scenario.Variant(func(variant *solver.Scenario) {
+			for i, v := range variations {
+				v.apply(variant, offsets[i])
+				labels = append(labels, fmt.Sprintf("%s.%s%+d", v.section, v.resource, offsets[i]))
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(labels, " "), err)
+		}
+		best, err := bestSolution(ctx, variant)
+		if err != nil {
+			return err
+		}
+		if best != nil {
+			fmt.Println(strings.Join(labels, " "), "\t", solver.Colorize("green", "solvable in ", best.Size), "\t", best.CommandSequence())
+		} else {
+			fmt.Println(strings.Join(labels, " "), "\t", solver.Colorize("red", "unsolvable"))
+		}
+
+		// Advance to the next combination of offsets (like an odometer)
+		i := len(offsets) - 1
+		for ; i >= 0 && offsets[i] == variations[i].to; i-- {
+			offsets[i] = variations[i].from
+		}
+		if i < 0 {
+			return nil
+		}
+		offsets[i]++
+	}
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// analyzeCriticality re-solves the scenario with each command removed in turn, reporting which
+// commands are essential (no solution without them), which merely save actions, and which are
+// redundant
+func analyzeCriticality(ctx context.Context, scenario *solver.Scenario) error {
+	baseline, err := bestSolution(ctx, scenario)
+	if err != nil {
+		return err
+	}
+	if baseline == nil {
+		fmt.Println(solver.Colorize("red", "unsolvable"), "even with every command")
+		return nil
+	}
+	fmt.Println("with every command: solvable in", baseline.Size)
+	for i, command := range scenario.Commands {
+		name := strings.ToUpper(command.Name)
+		without, err := scenario.Variant(func(variant *solver.Scenario) {
+			variant.Commands = append(variant.Commands[:i], variant.Commands[i+1:]...)
+		})
+		if err != nil {
+			return fmt.Errorf("without %s: %w", name, err)
+		}
+		best, err := bestSolution(ctx, without)
+		if err != nil {
+			return err
+		}
+		switch {
+		case best == nil:
+			fmt.Println(name, "\t", solver.Colorize("red", "essential"), "(unsolvable without it)")
+		case best.Size > baseline.Size:
+			fmt.Println(name, "\t", solver.Colorize("yellow", "important"), fmt.Sprintf("(solvable in %d without it, %+d actions)", best.Size, best.Size-baseline.Size))
+		default:
+			fmt.Println(name, "\t", solver.Colorize("green", "redundant"), "(solvable in", best.Size, "without it)")
+		}
+	}
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// analyzeMinimumStart finds, for each resource the player has banked at the start, the least amount
+// with which the scenario is still solvable (holding the other resources fixed).  Ordinarily having
+// more of a resource never makes a scenario harder, so a binary search over re-solves suffices, but
+// once any rule bounds a resource from above (see hasUpperBounds) each amount is tried in turn.
+func analyzeMinimumStart(ctx context.Context, scenario *solver.Scenario) error {
+	if best, err := bestSolution(ctx, scenario); err != nil {
+		return err
+	} else if best == nil {
+		fmt.Println(solver.Colorize("red", "unsolvable"), "with the given start")
+		return nil
+	}
+	linear := hasUpperBounds(scenario)
+	for _, resource := range []solver.Resource{solver.Comm, solver.Data, solver.Nav, solver.Power, solver.Thrust, solver.Crew} {
+		start := scenario.Start.Get(resource)
+		if start <= 0 {
+			continue
+		}
+		solvable := func(amount int) (bool, error) {
+			variant, err := scenario.Variant(func(variant *solver.Scenario) {
+				variant.Start.Set(resource, amount)
+			})
+			if err != nil {
+				return false, fmt.Errorf("%s=%d: %w", resource, amount, err)
+			}
+			best, err := bestSolution(ctx, variant)
+			return best != nil, err
+		}
+		low, high := 0, start // Solvable at high, unknown below
+		for linear && low < high {
+			if ok, err := solvable(low); err != nil {
+				return err
+			} else if ok {
+				break
+			}
+			low++
+		}
+		for !linear && low < high {
+			mid := (low + high) / 2
+			if ok, err := solvable(mid); err != nil {
+				return err
+			} else if ok {
+				high = mid
+			} else {
+				low = mid + 1
+			}
+		}
+		fmt.Printf("%s\t%s (of %d)\n", resource, solver.Colorize("green", "needs at least ", low), start)
+	}
+	return nil
+}
+
+// hasUpperBounds is true if any rule of the scenario may be broken by having too much of a resource:
+// custom constraints, caps, fail_above, goal_max, goal conditions, or turn-end maxima
+func hasUpperBounds(scenario *solver.Scenario) bool {
+	bounded := func(resources *solver.Resources) bool {
+		for _, resource := range solver.AllResources {
+			if resources != nil && solver.IsBounded(resources.Get(resource)) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(scenario.Constraints) > 0 || len(scenario.GoalConditions) > 0 || bounded(scenario.Caps) || bounded(scenario.FailAbove) ||
+		bounded(scenario.GoalMax) || bounded(scenario.TurnEndMax) || bounded(&scenario.TurnMustEndBelow)
+}
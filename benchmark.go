@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// benchmarkCommand implements the "bench" subcommand, which solves a scenario with worker pools
+// of several sizes (doubling from 1 to twice the size chosen automatically) to show which suits this
+// machine, so that -workers (and -cpu) can be set accordingly
+func benchmarkCommand(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	engine := flags.String("engine", "parallel", "engine to time (any but serial, which has no workers)")
+	runs := flags.Int("runs", 3, "times to solve with each pool size (the fastest is reported)")
+	flags.Parse(args)
+	if flags.NArg() != 1 || *runs < 1 {
+		log.Fatal("Usage: ", os.Args[0], " bench [-engine ENGINE] [-runs N] SCENARIO")
+	}
+	if *engine == "serial" {
+		log.Fatal("The serial engine has no workers to size")
+	}
+	scenario := readScenario(flags.Arg(0))
+
+	automatic := solver.PoolSizeFor(runtime.GOMAXPROCS(0))
+	sizes := []int{}
+	for size := 1; size < 2*automatic; size *= 2 {
+		if size < automatic && 2*size > automatic {
+			sizes = append(sizes, size, automatic)
+		} else {
+			sizes = append(sizes, size)
+		}
+	}
+	sizes = append(sizes, 2*automatic)
+
+	fmt.Printf("Solving with the %s engine on %d CPUs (best of %d runs):\n", *engine, runtime.GOMAXPROCS(0), *runs)
+	fastest, fastestSize := time.Duration(0), 0
+	for _, size := range sizes {
+		best, searched := time.Duration(0), uint64(0)
+		for run := 0; run < *runs; run++ {
+			opts := solver.Options{Engine: *engine, PoolSize: size, Stats: &solver.Stats{}}
+			if _, err := solver.Solve(scenario, opts); err != nil {
+				log.Fatal(err)
+			}
+			if run == 0 || opts.Stats.Elapsed < best {
+				best, searched = opts.Stats.Elapsed, opts.Stats.Searched
+			}
+		}
+		if fastestSize == 0 || best < fastest {
+			fastest, fastestSize = best, size
+		}
+		note := ""
+		if size == automatic {
+			note = solver.Colorize("gray", " (chosen automatically)")
+		}
+		fmt.Printf("%5d workers  %10v  %8d nodes%s\n", size, best.Round(time.Microsecond), searched, note)
+	}
+	fmt.Printf("%s (try -workers %d)\n", solver.Colorize("green", "Fastest with ", fastestSize, " workers"), fastestSize)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/shorthand"
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// commandName is what the builder accepts as the name of a command (so that it needs no quoting in
+// YAML, and can be typed in a plan)
+var commandName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// prompter asks the player for one line of input at a time
+type prompter interface {
+	ask(prompt string) (string, error)
+	say(a ...interface{})
+}
+
+// buildCommand implements the "build" subcommand, which builds a scenario interactively (see
+// buildScenario) and, if wanted, solves it with any flags given after --
+func buildCommand(args []string) {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	output := flags.String("o", "scenario.yml", "where to write the scenario (any flags after -- are used to solve it)")
+	flags.Parse(args)
+	solve, err := buildScenario(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if solve {
+		solveCommand(append([]string{"-scenario", *output}, flags.Args()...))
+	}
+}
+
+// buildScenario interactively asks for each part of a scenario (resources in shorthand or written out
+// in full, see shorthand.Abbreviate), then writes it to path as YAML.  Returns true if the player
+// wants it solved right away.
+func buildScenario(path string) (bool, error) {
+	fd := int(os.Stdin.Fd())
+	var p prompter
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return false, err
+		}
+		defer term.Restore(fd, state)
+		p = newTerminalPrompter()
+	} else {
+		p = &scannerPrompter{bufio.NewScanner(os.Stdin), os.Stdout}
+	}
+
+	p.say(solver.Colorize("gray", "Resources may be written in shorthand (e.g. 4w2r) or in full (e.g. 4 power 2 comm), with Tab to complete their names."))
+	turns, err := askNumber(p, "Turns: ")
+	if err != nil {
+		return false, err
+	}
+	actions, err := askNumber(p, "Actions per turn: ")
+	if err != nil {
+		return false, err
+	}
+	start, err := askResources(p, "Start: ", false)
+	if err != nil {
+		return false, err
+	}
+	goal, err := askResources(p, "Goal: ", false)
+	if err != nil {
+		return false, err
+	}
+	turnCost, err := askResources(p, "Turn cost (blank for none): ", true)
+	if err != nil {
+		return false, err
+	}
+
+	yaml := fmt.Sprintf("---\nturns: %d\nactions_per_turn: %d\nstart: %s\ngoal: %s\ncommands:\n", turns, actions, start, goal)
+	commands := 0
+	for {
+		name, err := p.ask("Command name (blank when done): ")
+		if err != nil {
+			return false, err
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" && commands > 0 {
+			break
+		} else if name == "" {
+			p.say(solver.Colorize("red", "A scenario needs at least one command"))
+			continue
+		} else if !commandName.MatchString(name) {
+			p.say(solver.Colorize("red", "Command names must be a letter followed by letters, digits or _"))
+			continue
+		}
+		input, err := askResources(p, "  "+name+" input (blank for none): ", true)
+		if err != nil {
+			return false, err
+		}
+		output, err := askResources(p, "  "+name+" output (blank for none): ", true)
+		if err != nil {
+			return false, err
+		}
+		yaml += fmt.Sprintf("  %s: %s\n", name, strings.TrimSpace(input+" -> "+output))
+		commands++
+	}
+	yaml += fmt.Sprintf("turn_cost: %q\nturn_must_end_above: \"\"\nturn_must_end_below: \"\"\n", turnCost)
+
+	rawJSON, err := shorthand.ToJSON([]byte(yaml))
+	if err != nil {
+		return false, err
+	}
+	if _, err := solver.ParseScenario(rawJSON); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		return false, err
+	}
+	p.say(solver.Colorize("green", "Wrote ", path))
+	answer, err := p.ask("Solve it now? [Y/n] ")
+	if err != nil {
+		return false, err
+	}
+	return !strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "n"), nil
+}
+
+// askNumber asks for a whole number of at least 1 until one is given
+func askNumber(p prompter, prompt string) (int, error) {
+	for {
+		answer, err := p.ask(prompt)
+		if err != nil {
+			return 0, err
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(answer)); err == nil && n > 0 {
+			return n, nil
+		}
+		p.say(solver.Colorize("red", "Expected a whole number of at least 1"))
+	}
+}
+
+// askResources asks for resources until they can be read, returning them in shorthand
+func askResources(p prompter, prompt string, optional bool) (string, error) {
+	for {
+		answer, err := p.ask(prompt)
+		if err != nil {
+			return "", err
+		}
+		resources := shorthand.Abbreviate(answer)
+		if resources == "" && !optional {
+			p.say(solver.Colorize("red", "Expected some resources"))
+		} else if _, err := shorthand.ToResources(resources, nil); err != nil {
+			p.say(solver.Colorize("red", err))
+		} else {
+			return resources, nil
+		}
+	}
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// terminalPrompter reads lines from the terminal (which must be in raw mode), completing the names of
+// resources with Tab
+type terminalPrompter struct {
+	terminal *term.Terminal
+}
+
+func newTerminalPrompter() *terminalPrompter {
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
+	terminal.AutoCompleteCallback = completeResource
+	return &terminalPrompter{terminal}
+}
+
+func (self *terminalPrompter) ask(prompt string) (string, error) {
+	self.terminal.SetPrompt(prompt)
+	line, err := self.terminal.ReadLine()
+	if err == io.EOF {
+		return "", errors.New("cancelled")
+	}
+	return line, err
+}
+
+func (self *terminalPrompter) say(a ...interface{}) {
+	fmt.Fprintln(self.terminal, a...)
+}
+
+// completeResource completes the name of the resource being typed when Tab is pressed (if only one
+// name begins with what has been typed so far)
+func completeResource(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	start := strings.LastIndexAny(line[:pos], " 0123456789-") + 1
+	typed := strings.ToLower(line[start:pos])
+	if typed == "" {
+		return "", 0, false
+	}
+	completion := ""
+	for _, name := range shorthand.ResourceNames() {
+		if strings.HasPrefix(name, typed) {
+			if completion != "" {
+				return "", 0, false // Ambiguous
+			}
+			completion = name
+		}
+	}
+	if completion == "" {
+		return "", 0, false
+	}
+	completed := line[:start] + completion + " "
+	return completed + line[pos:], len(completed), true
+}
+
+// scannerPrompter reads lines from a pipe (e.g. a scripted build), echoing nothing
+type scannerPrompter struct {
+	input  *bufio.Scanner
+	output io.Writer
+}
+
+func (self *scannerPrompter) ask(prompt string) (string, error) {
+	fmt.Fprint(self.output, prompt)
+	if !self.input.Scan() {
+		if err := self.input.Err(); err != nil {
+			return "", err
+		}
+		return "", errors.New("input ended before the scenario was finished")
+	}
+	fmt.Fprintln(self.output)
+	return self.input.Text(), nil
+}
+
+func (self *scannerPrompter) say(a ...interface{}) {
+	fmt.Fprintln(self.output, a...)
+}
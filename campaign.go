@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// campaignSlack is how many actions beyond a stage's shortest solution are considered when planning a
+// campaign, since a longer plan can leave a surplus which shortens later stages by more
+const campaignSlack = 2
+
+// campaignCandidates limits how many distinct final states of each stage are tried
+const campaignCandidates = 32
+
+// Campaign is a series of stages, each played from wherever the previous one finished, e.g.:
+//
+//	{"stages": [
+//		{"scenario": "launch.yml"},
+//		{"scenario": "orbit.yml", "carry": ["data", "power"], "set": "heat=0"}
+//	]}
+type Campaign struct {
+	Stages []*Stage
+}
+
+// Stage is one scenario of a campaign.  Its start is the final state of the previous stage, or if
+// Carry lists any resources, its own start with just those resources carried over.  Set then applies
+// any adjustments (see ParseResourceAssignments).
+type Stage struct {
+	Scenario string // Path to the scenario, relative to the campaign file
+	Carry    []string
+	Set      string
+	scenario *solver.Scenario
+}
+
+func readCampaign(path string) *Campaign {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	campaign := Campaign{}
+	if err := json.Unmarshal(raw, &campaign); err != nil {
+		log.Fatal(path, ": ", err)
+	}
+	if len(campaign.Stages) == 0 {
+		log.Fatal(path, ": a campaign needs at least one stage")
+	}
+	for _, stage := range campaign.Stages {
+		stage.scenario = readScenario(filepath.Join(filepath.Dir(path), stage.Scenario))
+		for _, name := range stage.Carry {
+			if _, ok := solver.ResourceNamed(name); !ok {
+				log.Fatal(path, ": can not carry unknown resource ", name)
+			}
+		}
+		if _, err := solver.ParseResourceAssignments(solver.Resources{}, stage.Set); err != nil {
+			log.Fatal(path, ": ", err)
+		}
+	}
+	return &campaign
+}
+
+// startFrom is this stage's scenario, started from the previous stage's final state (or an error if
+// the stage's rules reject that start)
+func (self *Stage) startFrom(previous solver.Resources) (*solver.Scenario, error) {
+	return self.scenario.Variant(func(variant *solver.Scenario) {
+		if len(self.Carry) == 0 {
+			variant.Start = previous
+		}
+		for _, name := range self.Carry {
+			resource, _ := solver.ResourceNamed(name) // Checked by readCampaign
+			variant.Start.Set(resource, previous.Get(resource))
+		}
+		variant.Start, _ = solver.ParseResourceAssignments(variant.Start, self.Set)
+	})
+}
+
+// candidates lists solutions of the stage which finish in distinct states, considering plans up to
+// campaignSlack actions longer than the shortest
+func candidates(scenario *solver.Scenario) []*solver.Sequence {
+	best := scenario.BestSolution()
+	if best == nil {
+		return nil
+	}
+	found := []*solver.Sequence{}
+	seen := map[solver.Resources]bool{}
+	for length := best.Size; length <= best.Size+campaignSlack && length <= scenario.TotalActions(); length++ {
+		for _, solution := range solver.EnumerateSolutions(solver.StartSequence(scenario), length, campaignCandidates) {
+			if !seen[solution.Resources] && len(found) < campaignCandidates {
+				seen[solution.Resources] = true
+				found = append(found, solution)
+			}
+		}
+	}
+	return found
+}
+
+// plan finds the plans for the stages from the given one onward which take the fewest actions in
+// total (breaking ties by the score of the final stage), returning nil if there are none
+func (self *Campaign) plan(stage int, scenario *solver.Scenario) []*solver.Sequence {
+	var best []*solver.Sequence
+	total := func(plans []*solver.Sequence) uint32 {
+		sum := uint32(0)
+		for _, plan := range plans {
+			sum += plan.Size
+		}
+		return sum
+	}
+	for _, candidate := range candidates(scenario) {
+		plans := []*solver.Sequence{candidate}
+		if stage+1 < len(self.Stages) {
+			next, err := self.Stages[stage+1].startFrom(candidate.Resources)
+			if err != nil {
+				continue // No plan can carry on from this candidate
+			}
+			rest := self.plan(stage+1, next)
+			if rest == nil {
+				continue
+			}
+			plans = append(plans, rest...)
+		}
+		if best == nil || total(plans) < total(best) ||
+			(total(plans) == total(best) && plans[len(plans)-1].Score() < best[len(best)-1].Score()) {
+			best = plans
+		}
+	}
+	return best
+}
+
+// campaignCommand implements the "campaign" subcommand (see playCampaign), exiting with an error if
+// the campaign can't be completed
+func campaignCommand(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: ", os.Args[0], " campaign CAMPAIGN.json")
+	}
+	if !playCampaign(readCampaign(args[0])) {
+		os.Exit(1)
+	}
+}
+
+// playCampaign plans every stage of the campaign together and shows how each stage benefits from
+// what the previous ones left behind
+func playCampaign(campaign *Campaign) bool {
+	plans := campaign.plan(0, campaign.Stages[0].scenario)
+	if plans == nil {
+		fmt.Println(solver.Colorize("red", "The campaign can not be completed"))
+		return false
+	}
+	total := uint32(0)
+	for i, plan := range plans {
+		fmt.Println()
+		fmt.Println(solver.Colorize("yellow", fmt.Sprintf("STAGE %d: %s", i+1, campaign.Stages[i].Scenario)))
+		printSummary(plan)
+		total += plan.Size
+		if i > 0 {
+			fmt.Println("Started with", plan.Origin().Resources, "carried over from stage", i)
+			if alone := campaign.Stages[i].scenario.BestSolution(); alone == nil {
+				fmt.Println(solver.Colorize("green", "Only possible thanks to the resources carried over"))
+			} else if alone.Size > plan.Size {
+				fmt.Println(solver.Colorize("green", "The resources carried over save ", alone.Size-plan.Size, " actions"), "(it takes", alone.Size, "from its own start)")
+			} else {
+				fmt.Println("Takes", alone.Size, "actions from its own start")
+			}
+		}
+	}
+	fmt.Println()
+	fmt.Println(solver.Colorize("green", "Campaign complete in ", total, " actions over ", len(plans), " stages"))
+	return true
+}
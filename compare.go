@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// compareCommand implements the "compare" subcommand (see compareWithOptimum), exiting with an error
+// if the actual play was illegal or missed the goal
+func compareCommand(args []string) {
+	if len(args) != 2 {
+		log.Fatal("Usage: ", os.Args[0], " compare SCENARIO ACTUAL-PLAN")
+	}
+	if !compareWithOptimum(readScenario(args[0]), args[1]) {
+		os.Exit(1)
+	}
+}
+
+// compareWithOptimum replays the moves actually made in a playthrough alongside the best solution,
+// turn by turn, showing where the two diverged and what each turn's choices cost: the number of
+// actions the best finish from the actual position takes beyond the optimum, and how the resources
+// differ.  Returns false if the actual play was illegal or missed the goal.
+func compareWithOptimum(scenario *solver.Scenario, path string) bool {
+	actual, err := solver.ReplayPlan(scenario, readPlan(path), nil)
+	optimum := scenario.BestSolution()
+	if optimum == nil {
+		fmt.Println(solver.Colorize("red", "The scenario has no solution to compare against"))
+		return err == nil
+	}
+
+	diverged := false
+	lost := uint32(0)
+	for turn := uint32(1); scenario.TurnEnd(turn-1) < actual.Size || scenario.TurnEnd(turn-1) < optimum.Size; turn++ {
+		actualEnd := actual.Ancestor(scenario.TurnEnd(turn))
+		optimalEnd := optimum.Ancestor(scenario.TurnEnd(turn))
+		fmt.Println()
+		fmt.Println(solver.Colorize("yellow", "Turn ", turn))
+		fmt.Println("  actual: ", turnCommands(actualEnd, turn), "\t", actualEnd.Resources)
+		fmt.Println("  optimal:", turnCommands(optimalEnd, turn), "\t", optimalEnd.Resources)
+		if !diverged && turnCommands(actualEnd, turn) != turnCommands(optimalEnd, turn) {
+			diverged = true
+			fmt.Println(solver.Colorize("yellow", "  diverged from the optimal plan here"))
+		}
+		if difference := resourceDifference(&actualEnd.Resources, &optimalEnd.Resources); difference != "" {
+			fmt.Println("  resources vs optimal:", difference)
+		}
+		if actualEnd.Size < scenario.TurnEnd(turn) && actualEnd.Size == actual.Size {
+			continue // The actual play ended during this turn
+		}
+		finish := "the goal can no longer be reached"
+		if found := solver.SolveSerially(actualEnd, 1); len(found) > 0 {
+			if extra := found[0].Size - optimum.Size; extra > lost {
+				finish = fmt.Sprintf("the best finish now takes %d actions, %s", found[0].Size, solver.Colorize("red", "+", extra-lost, " this turn"))
+				lost = extra
+			} else {
+				finish = fmt.Sprintf("the best finish still takes %d actions", found[0].Size)
+			}
+		}
+		fmt.Println("  " + finish)
+	}
+
+	fmt.Println()
+	if err != nil {
+		fmt.Println(solver.Colorize("red", "The actual play failed:"), err)
+		return false
+	}
+	fmt.Printf("Actual play took %d actions against an optimum of %d (%+d)\n", actual.Size, optimum.Size, int(actual.Size)-int(optimum.Size))
+	return true
+}
+
+// turnCommands lists the commands taken during the given turn of the sequence
+func turnCommands(seq *solver.Sequence, turn uint32) string {
+	names := []string{}
+	for prev := seq; prev.Command != nil && prev.Turn() >= turn; prev = prev.Prev {
+		if prev.Turn() == turn {
+			names = append([]string{prev.CommandName()}, names...)
+		}
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, " -> ")
+}
+
+// resourceDifference describes how one set of resources differs from another, e.g. "power -1 data +2"
+func resourceDifference(resources *solver.Resources, other *solver.Resources) string {
+	differences := []string{}
+	for _, resource := range solver.AllResources {
+		if difference := resources.Get(resource) - other.Get(resource); difference != 0 {
+			differences = append(differences, fmt.Sprintf("%s %+d", resource, difference))
+		}
+	}
+	return strings.Join(differences, " ")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// copilotCommand implements the "copilot" subcommand, which plays alongside the game from the
+// scenario's start (see copilot)
+func copilotCommand(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: ", os.Args[0], " copilot SCENARIO")
+	}
+	copilot(solver.StartSequence(readScenario(args[0])), nil, os.Stdin)
+}
+
+// copilot plays alongside the game from start, following plan (if given) or else the best plan it
+// can find.  It shows the next action, then waits for the player to report what actually happened
+// (which may differ from the plan thanks to the dice) and re-plans from there.  Plans are cached by
+// state, and a plan is kept as long as the game follows it, so re-planning is usually instant.
+func copilot(start, plan *solver.Sequence, in io.Reader) {
+	scenario := start.Scenario()
+	input := bufio.NewScanner(in)
+	cache := map[solver.SearchState]*solver.Sequence{}
+	current := start
+	for !current.IsSuccess() {
+		if plan == nil || plan.Size <= current.Size || plan.Ancestor(current.Size).State() != current.State() {
+			key := current.State()
+			cached, ok := cache[key]
+			if !ok {
+				if found := solver.SolveSerially(current, 1); len(found) > 0 {
+					cached = found[0]
+				}
+				cache[key] = cached
+			}
+			plan = cached
+		}
+		if plan == nil {
+			fmt.Println(solver.Colorize("red", "The goal can no longer be reached from here"))
+			return
+		}
+
+		remaining := plan.Commands()[current.Size:]
+		names := []string{}
+		for _, command := range remaining {
+			names = append(names, strings.ToUpper(command.Name))
+		}
+		fmt.Println()
+		fmt.Println(solver.Colorize("gray", "now:"), current.Resources)
+		fmt.Println(solver.Colorize("gray", "plan:"), strings.Join(names, " -> "))
+		fmt.Printf("Next: %s  (Enter if it went as planned, or type the action taken and the resources\n", solver.Colorize("red", names[0]))
+		fmt.Print("shown afterwards, e.g. \"srt comm=3 power=1\"; f if it failed; q to quit) > ")
+		if !input.Scan() {
+			return
+		}
+		fields := strings.Fields(input.Text())
+		if len(fields) > 0 && fields[0] == "q" {
+			return
+		}
+
+		command := remaining[0]
+		if len(fields) > 0 && (fields[0] == "f" || fields[0] == "failed") {
+			command = command.Failed()
+		} else if len(fields) > 0 {
+			if command = scenario.FindCommand(strings.ToLower(fields[0])); command == nil {
+				fmt.Println(solver.Colorize("red", "Unknown command:"), fields[0])
+				continue
+			}
+		}
+		next, violated := current.Step(command)
+		if violated != nil {
+			fmt.Println(solver.Colorize("red", "Can not take action:"), violated.Describe(next))
+			continue
+		}
+		if len(fields) > 1 {
+			actual, err := solver.ParseResourceAssignments(next.Resources, strings.Join(fields[1:], " "))
+			if err != nil {
+				fmt.Println(solver.Colorize("red", err))
+				continue
+			}
+			next = next.Observe(actual)
+		}
+		current = next
+	}
+	fmt.Println(solver.Colorize("green", "Goal reached!"), current.Resources)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+const coverageLimit = 10000
+
+// printCoverage enumerates every shortest solution and reports how often each command appears across
+// them (always, sometimes, or never), showing which actions actually matter for the mission
+func printCoverage(start *solver.Sequence) {
+	best := solver.SolveSerially(start, 1)
+	if len(best) == 0 {
+		fmt.Println(solver.Colorize("red", "No solutions"), "to report command coverage for")
+		return
+	}
+	length := best[0].Size
+	solutions := solver.EnumerateSolutions(start, length, coverageLimit)
+
+	uses := map[string]int{}
+	for _, solution := range solutions {
+		used := map[string]bool{}
+		for _, command := range solution.Commands() {
+			used[command.Name] = true
+		}
+		for name := range used {
+			uses[name]++
+		}
+	}
+
+	fmt.Println()
+	if len(solutions) == coverageLimit {
+		fmt.Println("Command coverage across the first", len(solutions), "shortest solutions of", length, "actions:")
+	} else {
+		fmt.Println("Command coverage across all", len(solutions), "shortest solutions of", length, "actions:")
+	}
+	for _, command := range start.Scenario().Commands {
+		name := strings.ToUpper(command.Name)
+		switch n := uses[command.Name]; {
+		case n == len(solutions):
+			fmt.Println("\t", name, "\t", solver.Colorize("green", "always"))
+		case n > 0:
+			fmt.Println("\t", name, "\t", solver.Colorize("yellow", "sometimes"), fmt.Sprintf("(%d of %d)", n, len(solutions)))
+		default:
+			fmt.Println("\t", name, "\t", solver.Colorize("gray", "never"))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// difficulty summarizes how hard a scenario is to search, for scenario authors, along with the engine
+// settings it would be solved with (see solver.TuneFor)
+type difficulty struct {
+	*solver.Difficulty
+	margins map[string]int // Tightest turn-end margin per bounded resource along the best solution
+	tuning  solver.Tuning
+}
+
+// difficultyCommand implements the "difficulty" subcommand, which estimates how hard a scenario is
+func difficultyCommand(args []string) {
+	flags := flag.NewFlagSet("difficulty", flag.ExitOnError)
+	scenarioFlags := newScenarioFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("Usage: ", os.Args[0], " difficulty [OPTIONS]")
+	}
+	scenario := scenarioFlags.load()
+	estimateDifficulty(scenarioFlags.start(scenario)).print()
+}
+
+func estimateDifficulty(start *solver.Sequence) *difficulty {
+	scenario := start.Scenario()
+	d := &difficulty{Difficulty: solver.EstimateDifficulty(start, 0), margins: map[string]int{}}
+	d.tuning = solver.TuneFor(start, d.Difficulty)
+
+	for seq := d.Best; seq != nil && seq.Size > start.Size; seq = seq.Prev {
+		if !seq.IsTurnEnd() {
+			continue
+		}
+		for _, resource := range solver.AllResources {
+			name := resource.String()
+			value := seq.Resources.Get(resource)
+			if above := scenario.TurnMustEndAbove.Get(resource); solver.IsBounded(above) {
+				d.tighten(name, value-above-1)
+			}
+			if below := scenario.TurnMustEndBelow.Get(resource); solver.IsBounded(below) {
+				d.tighten(name, below-value-1)
+			}
+			if scenario.TurnEndMin != nil && solver.IsBounded(scenario.TurnEndMin.Get(resource)) {
+				d.tighten(name, value-scenario.TurnEndMin.Get(resource))
+			}
+			if scenario.TurnEndMax != nil && solver.IsBounded(scenario.TurnEndMax.Get(resource)) {
+				d.tighten(name, scenario.TurnEndMax.Get(resource)-value)
+			}
+		}
+	}
+	return d
+}
+
+func (self *difficulty) tighten(name string, margin int) {
+	if tightest, ok := self.margins[name]; !ok || margin < tightest {
+		self.margins[name] = margin
+	}
+}
+
+// effort estimates the (log10) size of the tree a naive search would explore to reach the goal
+func (self *difficulty) effort() float64 {
+	if self.Branching <= 1 {
+		return 0
+	}
+	return float64(self.GoalDepth) * math.Log10(self.Branching)
+}
+
+func (self *difficulty) rating() string {
+	switch effort := self.effort(); {
+	case self.Best == nil:
+		return "unsolvable"
+	case effort < 4:
+		return "easy"
+	case effort < 7:
+		return "moderate"
+	case effort < 10:
+		return "hard"
+	default:
+		return "extreme"
+	}
+}
+
+func (self *difficulty) print() {
+	fmt.Printf("branching factor:    %.2f legal actions per state\n", self.Branching)
+	fmt.Printf("distinct states:     %d\n", self.States)
+	if self.Best == nil {
+		fmt.Println("solution density:   ", solver.Colorize("red", "no solutions"))
+	} else {
+		fmt.Printf("shortest solution:   %d actions\n", self.GoalDepth)
+		fmt.Printf("solution density:    %.4f%% of states at depth %d\n", 100*self.Density, self.GoalDepth)
+	}
+	names := []string{}
+	for name := range self.margins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("tightest %-10s %d to spare at a turn end\n", name+":", self.margins[name])
+	}
+	fmt.Printf("estimated effort:    10^%.1f nodes\n", self.effort())
+	fmt.Println("difficulty:         ", solver.Colorize("yellow", self.rating()))
+	fmt.Println("automatic engine:   ", self.tuning.String())
+}
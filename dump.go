@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// nearMiss keeps track of the sequence searched so far which came closest to the goal (see
+// GoalDistance).  It is safe to observe sequences concurrently.
+type nearMiss struct {
+	mutex    sync.Mutex
+	best     *solver.Sequence
+	distance int64
+}
+
+func newNearMiss() *nearMiss {
+	return &nearMiss{distance: math.MaxInt64}
+}
+
+func (self *nearMiss) observe(s parallelsearch.Searchable) {
+	seq := s.(*solver.Sequence)
+	distance := int64(seq.GoalDistance())
+	if distance >= atomic.LoadInt64(&self.distance) {
+		return // Cheap check first, since this is called for every node
+	}
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if distance < self.distance {
+		self.best = seq
+		atomic.StoreInt64(&self.distance, distance)
+	}
+}
+
+func (self *nearMiss) closest() *solver.Sequence {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.best
+}
+
+func printSearchState(progress parallelsearch.Progress, closest *solver.Sequence) {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	fmt.Println(solver.Colorize("yellow", "================ SEARCH STATE ================"))
+	fmt.Println("depth:         ", progress.Depth)
+	fmt.Println("frontier:      ", progress.Queued, "queued")
+	fmt.Println("expanded:      ", progress.Total(), "nodes", progress.Searched[:progress.Depth+1])
+	fmt.Printf("memory:         %d MiB in use (%d MiB from the OS)\n", memory.Alloc>>20, memory.Sys>>20)
+	if closest != nil {
+		fmt.Println("closest so far:", closest.CommandSequence())
+		fmt.Println("               ", closest.Resources)
+		if shortfall := closest.GoalShortfall(); shortfall != "" {
+			fmt.Println("               ", shortfall)
+		}
+	}
+	fmt.Println(solver.Colorize("yellow", "=============================================="))
+}
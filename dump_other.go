@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+
+// dumpOnSignal does nothing, since there is no SIGUSR1 to dump the state of the search on (see
+// dump_unix.go)
+func dumpOnSignal(ps *parallelsearch.ParallelSearch, miss *nearMiss) func() {
+	return func() {}
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+)
+
+// dumpOnSignal prints the state of the search (without stopping it) whenever the process receives
+// SIGUSR1, e.g. from `kill -USR1 PID`.  Call the returned function once the search is over.
+func dumpOnSignal(ps *parallelsearch.ParallelSearch, miss *nearMiss) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-signals:
+				printSearchState(ps.Progress(), miss.closest())
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
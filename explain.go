@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// explainUnsolvable prints why a scenario for which the search found nothing has no solution (see
+// solver.Explain): the parts of the goal which are out of reach, the most and least of each resource
+// any plan can have, and the constraints which cut off every plan (if they ran out before the turns)
+func explainUnsolvable(start *solver.Sequence) {
+	explanation := solver.Explain(start)
+	fmt.Println()
+	if explanation == nil {
+		fmt.Println(solver.Colorize("yellow", "The search found nothing, but the scenario can be solved (try a deeper search)"))
+		return
+	}
+	fmt.Println(solver.Colorize("red", "No solution"), "among", explanation.States, "distinct reachable states")
+	if len(explanation.Unmet) == 0 {
+		fmt.Println("\teach part of the goal can be met, but never all at once")
+	}
+	for _, unmet := range explanation.Unmet {
+		fmt.Println("\t", unmet)
+	}
+	fmt.Println(solver.Colorize("gray", "most reachable:"), &explanation.Most)
+	if least := explanation.Least.String(); least != "" {
+		fmt.Println(solver.Colorize("gray", "least reachable:"), least)
+	}
+	if explanation.PrunedAt > 0 {
+		fmt.Println(solver.Colorize("gray", "every plan breaks a rule by turn ", explanation.PrunedAt, " action ", explanation.Action, ":"))
+		for _, pruner := range explanation.Pruners {
+			fmt.Println("\t", pruner.Example, solver.Colorize("gray", "(", pruner.Count, " plans)"))
+		}
+	}
+}
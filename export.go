@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// solutionPrinters write the solutions (best first) to stdout in each -format other than text
+var solutionPrinters = map[string]func([]*solver.Sequence){
+	"json": printJSON,
+	"csv":  printCSV,
+	"md":   printMarkdown,
+}
+
+// solutionJSON is how -json describes a solution to other tools (overlays, spreadsheets, etc.)
+type solutionJSON struct {
+	Actions   uint32           `json:"actions"`
+	Score     int              `json:"score"`
+	Success   *float64         `json:"success_probability,omitempty"` // If any command may fail
+	Goal      int              `json:"goal,omitempty"`                // Which of goal_any_of was met (from 1), if given
+	Bonuses   []string         `json:"secondary_goals,omitempty"`     // The names of the secondary goals met
+	Resources solver.Resources `json:"resources"`                     // Those left at the end
+	Turns     []turnJSON       `json:"turns"`
+}
+
+type turnJSON struct {
+	Turn    uint32       `json:"turn"`
+	Actions []actionJSON `json:"actions"`
+}
+
+type actionJSON struct {
+	Command   string           `json:"command"`
+	Crew      string           `json:"crew,omitempty"` // Who performed it, in a crewed mission
+	Resources solver.Resources `json:"resources"`      // Those left after the action
+}
+
+func newSolutionJSON(solution *solver.Sequence) solutionJSON {
+	report := solutionJSON{Actions: solution.Size, Score: solution.Score(), Resources: solution.Resources, Turns: []turnJSON{}}
+	if solution.Scenario().HasFailureRates() {
+		success := solution.SuccessProbability()
+		report.Success = &success
+	}
+	if len(solution.Scenario().GoalAnyOf) > 0 {
+		report.Goal = solution.GoalMet() + 1
+	}
+	for _, goal := range solution.SecondaryGoalsMet() {
+		report.Bonuses = append(report.Bonuses, goal.Name)
+	}
+	for _, step := range solution.Steps() {
+		if last := len(report.Turns) - 1; last < 0 || report.Turns[last].Turn != step.Turn() {
+			report.Turns = append(report.Turns, turnJSON{Turn: step.Turn()})
+		}
+		turn := &report.Turns[len(report.Turns)-1]
+		action := actionJSON{Command: step.Command.Name, Resources: step.Resources}
+		if step.Member != nil {
+			action.Crew = step.Member.Name
+		}
+		turn.Actions = append(turn.Actions, action)
+	}
+	return report
+}
+
+// printJSON writes the solutions (best first) to stdout as a single JSON document
+func printJSON(solutions []*solver.Sequence) {
+	reports := []solutionJSON{}
+	for _, solution := range solutions {
+		reports = append(reports, newSolutionJSON(solution))
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(map[string]interface{}{"solutions": reports}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printCSV writes every action of the solutions (best first, numbered from 1) to stdout as a single
+// CSV table, along with the resources left after each
+func printCSV(solutions []*solver.Sequence) {
+	names := usedResources(solutions...)
+	writer := csv.NewWriter(os.Stdout)
+	writer.Write(append([]string{"solution"}, actionHeader(solutions, names)...))
+	for i, solution := range solutions {
+		for _, row := range actionRows(solution, names) {
+			writer.Write(append([]string{fmt.Sprint(i + 1)}, row...))
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printMarkdown writes each solution (best first) to stdout as a Markdown table of its actions, along
+// with the resources left after each
+func printMarkdown(solutions []*solver.Sequence) {
+	for i, solution := range solutions {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("### Solution %d: %d actions (score %d)\n\n", i+1, solution.Size, solution.Score())
+		names := usedResources(solution)
+		header := actionHeader(solutions, names)
+		fmt.Println("| " + strings.Join(header, " | ") + " |")
+		fmt.Println(strings.Repeat("| --- ", len(header)) + "|")
+		for _, row := range actionRows(solution, names) {
+			fmt.Println("| " + strings.Join(row, " | ") + " |")
+		}
+	}
+}
+
+// actionHeader names the columns of actionRows
+func actionHeader(solutions []*solver.Sequence, names []string) []string {
+	header := []string{"turn", "action", "command"}
+	if len(solutions) > 0 && len(solutions[0].Scenario().Crew) > 0 {
+		header = append(header, "by")
+	}
+	return append(header, names...)
+}
+
+// actionRows lists the turn, action (within the turn), command, crew member (in a crewed mission),
+// and the named resources left after each action of the solution
+func actionRows(solution *solver.Sequence, names []string) [][]string {
+	rows := [][]string{}
+	for _, step := range solution.Steps() {
+		row := []string{fmt.Sprint(step.Turn()), fmt.Sprint(step.Action()), strings.ToUpper(step.Command.Name)}
+		if len(solution.Scenario().Crew) > 0 {
+			row = append(row, step.Member.Name)
+		}
+		for _, name := range names {
+			resource, _ := solver.ResourceNamed(name)
+			row = append(row, fmt.Sprint(step.Resources.Get(resource)))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// usedResources lists the resources (in the usual order) which any of the solutions ever has any of,
+// so that tables need not be padded with columns of zeros
+func usedResources(solutions ...*solver.Sequence) []string {
+	names := []string{}
+	for _, resource := range solver.AllResources {
+		used := false
+		for _, solution := range solutions {
+			for step := solution; step != nil && !used; step = step.Prev {
+				used = step.Resources.Get(resource) != 0
+			}
+		}
+		if used {
+			names = append(names, resource.String())
+		}
+	}
+	return names
+}
@@ -1,14 +1,16 @@
 module github.com/david-mccullars/mars-horizon-mission-solver
 
-go 1.17
+go 1.21
 
 require (
-	github.com/gammazero/workerpool v1.1.2
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gookit/color v1.5.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	golang.org/x/term v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/gammazero/deque v0.1.0 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
-	golang.org/x/sys v0.0.0-20210330210617-4fbd30eecc44 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// historySchema records each run of the solver (whether or not it found a solution) along with the
+// solutions it found, best first
+const historySchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	hash      TEXT NOT NULL,
+	solved_at TEXT NOT NULL,
+	start     TEXT NOT NULL,
+	goal      TEXT NOT NULL,
+	scenario  TEXT NOT NULL,
+	nodes     INTEGER NOT NULL,
+	seconds   REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS runs_hash ON runs (hash);
+CREATE TABLE IF NOT EXISTS solutions (
+	run_id   INTEGER NOT NULL REFERENCES runs (id),
+	rank     INTEGER NOT NULL,
+	length   INTEGER NOT NULL,
+	score    INTEGER NOT NULL,
+	plan     TEXT NOT NULL,
+	sequence TEXT NOT NULL,
+	PRIMARY KEY (run_id, rank)
+);
+`
+
+// defaultHistory is where each run is recorded unless -history says otherwise (e.g.
+// ~/.config/mars-horizon-mission-solver/history.db, following XDG_CONFIG_HOME), or "" if there is
+// nowhere, or this build has no SQLite driver (see historyDriver)
+func defaultHistory() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || historyDriver == "" {
+		return ""
+	}
+	return filepath.Join(dir, "mars-horizon-mission-solver", "history.db")
+}
+
+func openHistory(path string) (*sql.DB, error) {
+	if historyDriver == "" {
+		return nil, errors.New("this build has no SQLite driver (build with cgo to record history)")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(historyDriver, path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// recordHistory saves a run of the solver (solutions best first) to the database at path, returning
+// the id of the run
+func recordHistory(path string, scenario *solver.Scenario, found []*solver.Sequence, nodes uint64, elapsed time.Duration) (int64, error) {
+	db, err := openHistory(path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	rawScenario, err := json.Marshal(scenario)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec(
+		"INSERT INTO runs (hash, solved_at, start, goal, scenario, nodes, seconds) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		scenario.Hash(), time.Now().Format(time.RFC3339), scenario.Start.String(), scenario.Goal.String(), string(rawScenario), nodes, elapsed.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	for i, solution := range found {
+		rawSequence, err := json.Marshal(solution)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO solutions (run_id, rank, length, score, plan, sequence) VALUES (?, ?, ?, ?, ?, ?)",
+			id, i+1, solution.Size, solution.Score(), solution.CommandSequence(), string(rawSequence),
+		); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// historyCommand implements the "history" subcommand, which lists past runs (optionally only those
+// whose scenario hash, start, goal or plans contain the search text)
+func historyCommand(args []string) {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	path := flags.String("db", defaultHistory(), "database of past runs")
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		log.Fatal("Usage: ", os.Args[0], " history [-db PATH] [SEARCH]")
+	}
+	db, err := openHistory(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	search := "%" + flags.Arg(0) + "%"
+	rows, err := db.Query(`
+		SELECT runs.id, runs.solved_at, runs.hash, runs.start, runs.goal, COUNT(solutions.rank), MIN(solutions.length)
+		FROM runs LEFT JOIN solutions ON solutions.run_id = runs.id
+		WHERE runs.hash LIKE ? OR runs.start LIKE ? OR runs.goal LIKE ?
+			OR runs.id IN (SELECT run_id FROM solutions WHERE plan LIKE ?)
+		GROUP BY runs.id ORDER BY runs.id`, search, search, search, search)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, count int64
+		var solvedAt, hash, start, goal string
+		var shortest sql.NullInt64
+		if err := rows.Scan(&id, &solvedAt, &hash, &start, &goal, &count, &shortest); err != nil {
+			log.Fatal(err)
+		}
+		outcome := solver.Colorize("red", "unsolved")
+		if count > 0 {
+			outcome = solver.Colorize("green", count, " solutions, shortest ", shortest.Int64)
+		}
+		fmt.Printf("%s  %s  %s  %s\n", solver.Colorize("yellow", fmt.Sprintf("#%-4d", id)), solvedAt, hash[:8], outcome)
+		fmt.Println("\tSTART:", start)
+		fmt.Println("\tGOAL: ", goal)
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// showCommand implements the "show" subcommand, which re-prints the solutions of a past run without
+// solving the scenario again
+func showCommand(args []string) {
+	flags := flag.NewFlagSet("show", flag.ExitOnError)
+	path := flags.String("db", defaultHistory(), "database of past runs")
+	flags.Parse(args)
+	id, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+	if flags.NArg() != 1 || err != nil {
+		log.Fatal("Usage: ", os.Args[0], " show [-db PATH] ID")
+	}
+	db, err := openHistory(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	var rawScenario, solvedAt string
+	var nodes int64
+	var seconds float64
+	err = db.QueryRow("SELECT scenario, solved_at, nodes, seconds FROM runs WHERE id = ?", id).Scan(&rawScenario, &solvedAt, &nodes, &seconds)
+	if err == sql.ErrNoRows {
+		log.Fatal("No run #", id)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	scenario, err := solver.ParseScenario([]byte(rawScenario))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rows, err := db.Query("SELECT sequence FROM solutions WHERE run_id = ? ORDER BY rank DESC", id) // Best last
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		var rawSequence string
+		if err := rows.Scan(&rawSequence); err != nil {
+			log.Fatal(err)
+		}
+		solution := solver.StartSequence(scenario)
+		if err := json.Unmarshal([]byte(rawSequence), solution); err != nil {
+			log.Fatal(err)
+		}
+		printSummary(solution)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println()
+	fmt.Printf("Run #%d solved at %s: %d solutions after searching %d nodes in %.1fs\n", id, solvedAt, count, nodes, seconds)
+}
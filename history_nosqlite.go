@@ -0,0 +1,6 @@
+//go:build !cgo
+
+package main
+
+// historyDriver is empty, since the SQLite driver needs cgo (see history_sqlite.go)
+const historyDriver = ""
@@ -0,0 +1,9 @@
+//go:build cgo
+
+package main
+
+import _ "github.com/mattn/go-sqlite3"
+
+// historyDriver is the database/sql driver recording the history of runs (see openHistory).  The
+// SQLite driver needs cgo, so builds without it record no history.
+const historyDriver = "sqlite3"
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// logLevel is how much is logged (see setupLogging)
+var logLevel = &slog.LevelVar{}
+
+// logger carries diagnostics (the progress and statistics of the search, and warnings) to stderr,
+// keeping stdout for the solutions themselves so that they can be piped to other tools
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+	Level: logLevel,
+	ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+		if attr.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{} // Runs are short enough that the elapsed times logged say more
+		}
+		return attr
+	},
+}))
+
+// setupLogging sets how much is logged: verbose adds detail for debugging, while quiet leaves only
+// warnings and errors
+func setupLogging(verbose bool, quiet bool) {
+	if quiet {
+		logLevel.Set(slog.LevelWarn)
+	} else if verbose {
+		logLevel.Set(slog.LevelDebug)
+	}
+}
+
+// logging is true if messages of the given level are being logged
+func logging(level slog.Level) bool {
+	return logger.Enabled(context.Background(), level)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestSetupLogging(t *testing.T) {
+	defer logLevel.Set(logLevel.Level())
+	for _, test := range []struct {
+		verbose bool
+		quiet   bool
+		least   slog.Level // The least severe level logged
+	}{
+		{false, false, slog.LevelInfo},
+		{true, false, slog.LevelDebug},
+		{false, true, slog.LevelWarn},
+		{true, true, slog.LevelWarn}, // Quiet wins
+	} {
+		logLevel.Set(slog.LevelInfo)
+		setupLogging(test.verbose, test.quiet)
+		if !logging(test.least) || logging(test.least-1) {
+			t.Errorf("verbose %v, quiet %v logs from %s, want %s", test.verbose, test.quiet, logLevel.Level(), test.least)
+		}
+	}
+}
@@ -0,0 +1,666 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+	"github.com/david-mccullars/mars-horizon-mission-solver/scenarios"
+	"github.com/david-mccullars/mars-horizon-mission-solver/shorthand"
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+	"golang.org/x/term"
+)
+
+func copyFileIfNotExist(src string, dst string) {
+	_, err := os.Stat(dst)
+	if !os.IsNotExist(err) {
+		return
+	}
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	from, err := os.Open(src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer from.Close()
+
+	to, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE, srcInfo.Mode())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer to.Close()
+
+	_, err = io.Copy(to, from)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadScenario reads the scenario at path, first opening it in the user's $EDITOR (or vim) if edit
+// is set.  A new scenario file is started from the example.
+func loadScenario(path string, edit bool) *solver.Scenario {
+	if edit {
+		copyFileIfNotExist("example-scenario.yml", path)
+
+		editor := strings.Fields(os.Getenv("EDITOR"))
+		if len(editor) == 0 {
+			editor = []string{"vim"}
+		}
+		cmd := exec.Command(editor[0], append(editor[1:], path)...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Stdin = os.Stdin
+		err := cmd.Run()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return readScenario(path)
+}
+
+// readScenario loads a scenario written in YAML shorthand (see example-scenario.yml), or if the path
+// ends in .json, one already expanded
+func readScenario(path string) *solver.Scenario {
+	scenario, err := solver.ReadScenario(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return scenario
+}
+
+// validateScenario reports any mistakes in the scenario (see reportProblems), and exits if there are any
+func validateScenario(scenario *solver.Scenario, name string, source []byte) {
+	if !reportProblems(scenario, name, source) {
+		os.Exit(1)
+	}
+}
+
+// reportProblems prints any mistakes in the scenario (see Scenario.Validate), with the line of its
+// source on which each was made (if known), returning false if there were any
+func reportProblems(scenario *solver.Scenario, name string, source []byte) bool {
+	problems := scenario.Validate()
+	if len(problems) == 0 {
+		return true
+	}
+	lines := shorthand.Lines(source)
+	for _, problem := range problems {
+		where := name
+		if line, ok := lines[problem.Key]; ok {
+			where = fmt.Sprint(name, ":", line)
+		}
+		fmt.Println(solver.Colorize("red", where, ": ", problem))
+	}
+	return false
+}
+
+// readMission loads one of the standard missions from the built-in library (see scenarios)
+func readMission(name string) *solver.Scenario {
+	raw, err := scenarios.Read(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rawJSON, err := shorthand.ToJSON(raw)
+	if err != nil {
+		log.Fatal(name, ": ", err)
+	}
+	scenario, err := solver.ParseScenario(rawJSON)
+	if err != nil {
+		log.Fatal(name, ": ", err)
+	}
+	return scenario
+}
+
+func printCommands(self *solver.Scenario) {
+	for _, category := range self.Registry().Categories() {
+		if category == "" {
+			fmt.Println(solver.Colorize("yellow", "uncategorized"))
+		} else {
+			fmt.Println(solver.Colorize("yellow", category))
+		}
+		for _, command := range self.Registry().InCategory(category) {
+			fmt.Println("\t", command)
+		}
+	}
+}
+
+func printSummary(self *solver.Sequence) {
+	fmt.Println()
+	fmt.Println(solver.Colorize("yellow", "################################################################################"))
+	fmt.Println()
+	stack := []*solver.Sequence{}
+	for prev := self; prev.Command != nil; prev = prev.Prev {
+		stack = append([]*solver.Sequence{prev}, stack...)
+	}
+	var stage *solver.Stage
+	for len(stack) > 0 {
+		turn := stack[0].Turn()
+		if next := stack[0].Stage(); next != stage {
+			stage = next
+			fmt.Println(solver.Colorize("yellow", "Stage: ", stage.Name))
+		}
+		commands := []string{}
+		var last *solver.Sequence
+		for len(stack) > 0 && stack[0].Turn() == turn {
+			last = stack[0]
+			stack = stack[1:]
+			commands = append(commands, solver.Colorize("red", last.CommandName()))
+		}
+		fmt.Println(solver.Colorize("gray", "[", turn, "]"), strings.Join(commands[:], " -> "))
+		fmt.Println("\t", last.Resources)
+	}
+	if len(self.Scenario().SecondaryGoals) > 0 {
+		fmt.Println(solver.Colorize("gray", self.DescribeSecondaryGoals()))
+	}
+	if len(self.Scenario().GoalAnyOf) > 0 && self.IsSuccess() {
+		fmt.Println(solver.Colorize("gray", self.DescribeGoalMet()))
+	}
+	if self.Scenario().HasFailureRates() {
+		fmt.Printf("%s %.1f%% %s\n", solver.Colorize("gray", "success probability"), 100*self.SuccessProbability(), solver.Colorize("gray", "(score ", self.Score(), ")"))
+	}
+}
+
+// playActions shows each step of a plan given by hand and what the resources look like after each
+// one, reporting every rule an illegal action breaks (see solver.AuditPlan).  Forced, it carries on
+// past illegal actions to check the whole plan.  Exits with an error if any action was illegal.
+func playActions(self *solver.Sequence, force bool, commands ...string) {
+	fmt.Println("START: ", self.Resources)
+	seq, illegal := self, 0
+	for _, step := range solver.AuditPlan(self, commands, force) {
+		if step.Sequence != nil {
+			seq = step.Sequence
+			printSummary(seq)
+			fmt.Println("\t", solver.Colorize("gray", "change:"), step.Change.Change())
+		}
+		for _, problem := range step.Problems {
+			fmt.Println(solver.Colorize("red", "ILLEGAL ", strings.ToUpper(step.Name), ":"), problem)
+		}
+		if len(step.Problems) > 0 {
+			illegal++
+		}
+	}
+	if illegal > 0 {
+		fmt.Println(solver.Colorize("red", illegal, " illegal actions"))
+		os.Exit(1)
+	} else if !seq.IsSuccess() {
+		fmt.Println(solver.Colorize("yellow", "The plan falls short of the goal:"), seq.GoalShortfall())
+	}
+}
+
+// subcommands are what the solver can do, by the name given as its first argument (e.g. "play -scenario
+// lander.yml MR GCC"), each with its own flags.  Without one (or with only flags) it solves.
+var subcommands = map[string]func(args []string){
+	"solve":      solveCommand,
+	"play":       playCommand,
+	"commands":   commandsCommand,
+	"validate":   validateCommand,
+	"bench":      benchmarkCommand,
+	"serve":      serveCommand,
+	"build":      buildCommand,
+	"verify":     verifyCommand,
+	"compare":    compareCommand,
+	"analyze":    analyzeCommand,
+	"difficulty": difficultyCommand,
+	"copilot":    copilotCommand,
+	"campaign":   campaignCommand,
+	"history":    historyCommand,
+	"show":       showCommand,
+	"schema":     schemaCommand,
+	"tree":       treeCommand,
+}
+
+func main() {
+	name, args := "solve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	command, ok := subcommands[name]
+	if !ok {
+		names := []string{}
+		for name := range subcommands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		log.Fatal("Unknown command: ", name, " (try ", strings.Join(names, ", "), ")")
+	}
+	command(args)
+}
+
+// scenarioFlags are the flags shared by the commands which play out a scenario (solve, play, and
+// commands): which scenario, which of its commands are allowed, and where in it to start
+type scenarioFlags struct {
+	path         *string
+	mission      *string
+	edit         *bool
+	exclude      stringsFlag
+	onlyCategory stringsFlag
+	state        *string
+	atTurn       *uint
+	atAction     *uint
+	taken        *string
+}
+
+func newScenarioFlags(flags *flag.FlagSet) *scenarioFlags {
+	self := &scenarioFlags{}
+	self.path = flags.String("scenario", "scenario.yml", "the scenario to play out")
+	self.mission = flags.String("mission", "", "play out a standard mission from the built-in library instead of -scenario: "+strings.Join(scenarios.Names(), ", "))
+	self.edit = flags.Bool("edit", false, "edit the scenario (with $EDITOR) before playing it out")
+	flags.Var(&self.exclude, "exclude", "forbid commands whose names match a pattern, e.g. \"repair*\" (repeatable)")
+	flags.Var(&self.onlyCategory, "only-category", "only allow commands in this category (repeatable)")
+	self.state = flags.String("state", "", "start from a mid-game state, given as the resources after the previous action, e.g. \"power=2 data=5 drift=-1\"")
+	self.atTurn = flags.Uint("at-turn", 1, "with -state, the turn being played")
+	self.atAction = flags.Uint("action", 1, "with -state, the action about to be taken within the turn")
+	self.taken = flags.String("taken", "", "start from the actions already taken, e.g. \"MR GCC SRT\" (with -state, if given, as the resources they actually left)")
+	return self
+}
+
+// load reads the scenario (exiting if it has any mistakes) and drops the commands which aren't allowed
+func (self *scenarioFlags) load() *solver.Scenario {
+	var scenario *solver.Scenario
+	if *self.mission != "" {
+		if *self.edit {
+			log.Fatal("Only one of -mission and -edit may be given")
+		}
+		scenario = readMission(*self.mission)
+		source, _ := scenarios.Read(*self.mission)
+		validateScenario(scenario, *self.mission, source)
+	} else {
+		scenario = loadScenario(*self.path, *self.edit)
+		source, _ := os.ReadFile(*self.path)
+		validateScenario(scenario, *self.path, source)
+	}
+	if len(self.exclude) > 0 || len(self.onlyCategory) > 0 {
+		if err := scenario.RestrictCommands(self.exclude, self.onlyCategory); err != nil {
+			log.Fatal(err)
+		}
+		if err := scenario.Prepare(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return scenario
+}
+
+// start is where play begins: the start of the scenario, or the mid-game state given by -taken or -state
+func (self *scenarioFlags) start(scenario *solver.Scenario) *solver.Sequence {
+	if *self.taken != "" {
+		var observed *solver.Resources
+		if *self.state != "" {
+			resources, err := solver.ParseResourceAssignments(solver.Resources{}, *self.state)
+			if err != nil {
+				log.Fatal(err)
+			}
+			observed = &resources
+		}
+		start, err := solver.ResumePlan(scenario, solver.ParsePlan(*self.taken), observed)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return start
+	} else if *self.state != "" {
+		resources, err := solver.ParseResourceAssignments(solver.Resources{}, *self.state)
+		if err != nil {
+			log.Fatal(err)
+		}
+		atTurn, atAction := *self.atTurn, *self.atAction
+		if atTurn < 1 || atTurn > uint(scenario.Turns) || atAction < 1 || atAction > uint(scenario.ActionsIn(uint32(atTurn))) {
+			log.Fatal("There is no action ", atAction, " of turn ", atTurn, " in this scenario")
+		}
+		return solver.ResumeSequence(scenario, resources, scenario.TurnEnd(uint32(atTurn-1))+uint32(atAction-1))
+	}
+	return solver.StartSequence(scenario)
+}
+
+// playCommand implements the "play" subcommand, which shows each step of a list of actions given by
+// hand (see playActions)
+func playCommand(args []string) {
+	flags := flag.NewFlagSet("play", flag.ExitOnError)
+	scenarioFlags := newScenarioFlags(flags)
+	force := flags.Bool("force", false, "carry on past illegal actions to check the whole plan")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal("Usage: ", os.Args[0], " play [OPTIONS] COMMAND...")
+	}
+	scenario := scenarioFlags.load()
+	playActions(scenarioFlags.start(scenario), *force, flags.Args()...)
+}
+
+// commandsCommand implements the "commands" subcommand, which lists the scenario's commands by category
+func commandsCommand(args []string) {
+	flags := flag.NewFlagSet("commands", flag.ExitOnError)
+	scenarioFlags := newScenarioFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("Usage: ", os.Args[0], " commands [OPTIONS]")
+	}
+	printCommands(scenarioFlags.load())
+}
+
+// validateCommand implements the "validate" subcommand, which reports every mistake in each scenario
+// given (see validateScenario) without solving any, exiting with an error if there were any
+func validateCommand(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal("Usage: ", os.Args[0], " validate SCENARIO...")
+	}
+	valid := true
+	for _, path := range flags.Args() {
+		scenario, err := solver.ReadScenario(path)
+		if err != nil {
+			fmt.Println(solver.Colorize("red", path, ": ", err))
+			valid = false
+			continue
+		}
+		source, _ := os.ReadFile(path)
+		if !reportProblems(scenario, path, source) {
+			valid = false
+			continue
+		}
+		fmt.Println(solver.Colorize("green", path, ": valid"))
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// solveCommand implements the "solve" subcommand (the default), which searches for the best plans
+// meeting the scenario's goal
+func solveCommand(args []string) {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	scenarioFlags := newScenarioFlags(flags)
+	check := flags.Bool("check", false, "replay each solution through an independent simulator to verify it")
+	coverage := flags.Bool("coverage", false, "report how often each command appears across all shortest solutions")
+	prove := flags.Bool("prove", false, "if no solution is found, exhaustively search for one and report why there is none")
+	minimized := flags.Bool("minimize", false, "also show each solution with any redundant actions removed")
+	resilient := flags.Bool("resilient", false, "also search for plans which meet the goal even if any single action fails (produces no output), ranked above fragile ones")
+	analyze := flags.Bool("analyze", false, "report how many of each solution's actions could fail (produce no output) with the rest of the plan still repairable in time, ranking the most robust first")
+	mode := flags.String("mode", "search", "search (full search), turnwise (fast greedy planning one turn at a time), or both (to compare them)")
+	ranking := flags.String("rank", "", "rank solutions by comma-separated objectives, e.g. \"shortest,max:power,min:radiation\" (or \"reliable\" for those most likely to succeed despite failed actions)")
+	improveFor := flags.Duration("improve-for", 0, "after finding a solution, keep searching this long (e.g. 60s) for better ones")
+	tui := flags.Bool("tui", false, "show a live dashboard of the search, with keys to steer or stop it")
+	history := flags.String("history", defaultHistory(), "database in which to record each run (see the history and show commands), or \"\" for none")
+	prioritize := flags.Bool("prioritize", false, "with the parallel engine, expand the most promising partial plans at each depth first (by score) rather than those found first")
+	stream := flags.Bool("stream", term.IsTerminal(int(os.Stdout.Fd())), "print each solution the moment it is found, marked provisional, while the search goes on for better ones (by default, only if stdout is a terminal)")
+	cacheDir := flags.String("cache", defaultSolutionCache(), "directory in which to keep the solutions of each run, returned instantly when the same search is run again, or \"\" for none")
+	engine := flags.String("engine", "auto", "search engine: auto, serial, parallel, best-first, breadth-first, depth-first, or beam (fast but not exhaustive, see -beam)")
+	workers := flags.Int("workers", 0, "workers for every engine but the serial one (0 to choose automatically)")
+	cpus := flags.Int("cpu", 0, "most CPUs to search with at once (see GOMAXPROCS), or 0 for all of them; the worker pool is sized to match")
+	solutions := flags.Int("solutions", solver.DefaultLimit, "how many solutions to find (more gives more variety, but takes longer)")
+	showStats := flags.Bool("stats", false, "after searching, report (to stderr) the nodes searched, pruned, and skipped at each depth, the peak queue, and how busy the workers were")
+	timeout := flags.Duration("timeout", 0, "give up searching after this long (e.g. 30s), showing what was found by then, or else how close the search got")
+	depth := flags.Int("depth", 0, "most actions to search ahead (0 for as many as the scenario allows)")
+	memoryBudget := flags.Int("memory-budget", 0, "MB of memory the parallel engine may queue nodes in before spilling the rest to disk (0 for no limit)")
+	distinct := flags.String("distinct", "", "drop solutions which merely reorder the commands of a better one: multiset (the same commands) or turns (the same commands each turn)")
+	deterministic := flags.Bool("deterministic", false, "find the same solutions on every run (the engines whose workers race are replaced by a slower, exhaustive beam engine)")
+	canonical := flags.Bool("canonical", false, "search only one order of the actions in a turn which could be taken in either order to the same effect, listing commands in scenario order where possible (usually slower, see solver.Options.Canonical)")
+	shallowest := flags.Bool("shallowest", false, "with the parallel engine, stop at the first depth with any solutions and show the best of them")
+	unique := flags.Bool("unique", false, "show only the best solution finishing with each set of resources")
+	beam := flags.Int("beam", -1, "nodes searched per depth by the parallel and breadth-first engines, or kept by the beam engine (0 for no limit, -1 to choose automatically)")
+	avoidRiskyCommands := flags.Bool("avoid-risky", false, "forbid commands marked risky, unless there is no solution without them")
+	weights := solver.DefaultScoreWeights
+	flags.IntVar(&weights.Length, "weight-length", weights.Length, "score cost of each action taken (overrides the scenario's score_weights)")
+	flags.IntVar(&weights.Power, "weight-power", weights.Power, "score reward for each unit of power left over")
+	flags.IntVar(&weights.Radiation, "weight-radiation", weights.Radiation, "score reward for each unit of radiation left over (negative to penalize it)")
+	flags.IntVar(&weights.Surplus, "weight-surplus", weights.Surplus, "score reward for each unit of goal resources beyond the goal")
+	diverse := flags.Bool("diverse", false, "show shortest solutions which differ from each other as much as possible")
+	jsonOutput := flags.Bool("json", false, "print the solutions as JSON (for other tools) instead of a summary (as -format json)")
+	format := flags.String("format", "text", "how to print the solutions: text (a summary), json, or csv or md (Markdown) tables of each action, to paste into a spreadsheet or chat")
+	verbose := flags.Bool("v", false, "log more detail about the search (to stderr)")
+	quiet := flags.Bool("q", false, "log only warnings and errors, not the progress of the search")
+	interactive := flags.Bool("interactive", false, "after solving, step through the best solution one action at a time alongside the game, re-planning if it diverges")
+	prefer := flags.String("prefer", "", "among solutions of equal length, prefer those finishing with the most of a resource (e.g. data, the in-game bonus currency)")
+	watch := flags.Bool("watch", false, "solve again each time the scenario file is saved, for a quick edit-solve loop (until Ctrl-C)")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("Unexpected arguments: ", strings.Join(flags.Args(), " "), " (to play a list of actions, use: ", os.Args[0], " play [OPTIONS] COMMAND...)")
+	}
+	setupLogging(*verbose, *quiet)
+	if *watch {
+		if *scenarioFlags.mission != "" || *scenarioFlags.edit {
+			log.Fatal("-watch needs a -scenario file to watch (and can't be given with -mission or -edit)")
+		}
+		watchAndSolve(*scenarioFlags.path, args)
+		return
+	}
+	if *cpus > 0 {
+		runtime.GOMAXPROCS(*cpus)
+	}
+	if *jsonOutput {
+		*format = "json"
+	}
+	if *format != "text" && solutionPrinters[*format] == nil {
+		log.Fatal("Unknown format: ", *format, " (try text, json, csv, or md)")
+	}
+
+	if *prefer != "" {
+		if *ranking != "" {
+			log.Fatal("Only one of -prefer and -rank may be given")
+		}
+		*ranking = "shortest,max:" + *prefer + ",score"
+	}
+	weightsSet := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "weight-") {
+			weightsSet[f.Name] = true
+		}
+	})
+
+	scenario := scenarioFlags.load()
+	if *ranking != "" || len(weightsSet) > 0 {
+		if *ranking != "" {
+			scenario.Objectives = strings.Split(*ranking, ",")
+		}
+		if len(weightsSet) > 0 {
+			// Only the weights given override those of the scenario
+			overridden := solver.DefaultScoreWeights
+			if scenario.ScoreWeights != nil {
+				overridden = *scenario.ScoreWeights
+			}
+			for name, value := range map[string]int{"length": weights.Length, "power": weights.Power, "radiation": weights.Radiation, "surplus": weights.Surplus} {
+				if weightsSet["weight-"+name] {
+					*overridden.Field(name) = value
+				}
+			}
+			scenario.ScoreWeights, scenario.Scorer = &overridden, nil
+		}
+		if err := scenario.Prepare(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	logger.Debug("scenario loaded", "hash", scenario.Hash(), "turns", scenario.Turns, "actions", scenario.TotalActions(), "commands", len(scenario.Commands))
+	startSequence := scenarioFlags.start(scenario)
+
+	if *avoidRiskyCommands {
+		var unavoidable []string
+		startSequence, unavoidable = solver.AvoidRisky(startSequence)
+		scenario = startSequence.Scenario()
+		if len(unavoidable) > 0 {
+			logger.Warn("no solution avoids these risky actions", "actions", strings.ToUpper(strings.Join(unavoidable, ", ")))
+		}
+	}
+
+	var greedy *solver.Sequence
+	if *mode == "turnwise" || *mode == "both" {
+		greedy = solver.SolveTurnwise(startSequence)
+		printSummary(greedy)
+		if !greedy.IsSuccess() {
+			fmt.Println(solver.Colorize("red", "Turnwise planning got stuck:"), greedy.GoalShortfall())
+		}
+		if *mode == "turnwise" {
+			return
+		}
+	} else if *mode != "search" {
+		log.Fatal("Unknown mode: ", *mode)
+	}
+
+	// Ctrl-C stops the search early, keeping whatever it has found
+	interrupt, stopInterrupting := signal.NotifyContext(context.Background(), os.Interrupt)
+	opts := solver.Options{Start: startSequence, PoolSize: *workers, Limit: *solutions, Depth: *depth, Shallowest: *shallowest, Unique: *unique, Deterministic: *deterministic, Canonical: *canonical, Prioritize: *prioritize, Distinct: *distinct, MemoryBudget: *memoryBudget << 20, Context: interrupt, Timeout: *timeout, Stats: &solver.Stats{}, Profile: *showStats}
+	if *engine != "auto" {
+		opts.Engine = *engine
+	} else if *tui {
+		opts.Engine = "parallel" // The dashboard follows the parallel engine
+	}
+	if *beam == 0 {
+		opts.Beam = -1 // No limit
+	} else if *beam > 0 {
+		opts.Beam = *beam
+	}
+	if *stream && *format == "text" && !*tui {
+		opts.OnFound = printProvisional
+	}
+	miss := newNearMiss()
+	opts.OnParallelSearch = func(ps *parallelsearch.ParallelSearch) func() {
+		var stopShowing func()
+		if *tui {
+			board := newDashboard(ps, miss)
+			ps.Observe(board.observe)
+			stopShowing = board.start()
+		} else {
+			ps.Observe(miss.observe)
+			stopShowing = showProgress(ps)
+		}
+		stopDumping := dumpOnSignal(ps, miss)
+		return func() {
+			stopDumping()
+			stopShowing()
+		}
+	}
+	cacheKey := solutionCacheKey(startSequence, &opts)
+	found, cached := []*solver.Sequence{}, false
+	if *cacheDir != "" {
+		found, cached = loadCachedSolutions(*cacheDir, cacheKey, startSequence)
+	}
+	if cached {
+		logger.Info("solutions found by a previous run", "cache", *cacheDir, "solutions", len(found))
+	} else {
+		results, err := solver.Solve(scenario, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, result := range results {
+			found = append(found, result.Sequence)
+		}
+		settings := opts.Stats.Tuning
+		logger.Info("search finished", "engine", settings.Engine, "workers", settings.PoolSize, "beam", settings.Beam, "searched", opts.Stats.Searched, "elapsed", opts.Stats.Elapsed.Round(time.Millisecond), "solutions", len(found))
+	}
+	interrupted := interrupt.Err() != nil
+	stopInterrupting() // From here on Ctrl-C exits as usual
+	if interrupted {
+		logger.Warn("search interrupted; showing what was found so far", "elapsed", opts.Stats.Elapsed.Round(time.Millisecond))
+	} else if opts.Stats.TimedOut {
+		logger.Warn("search timed out; showing what was found so far", "timeout", *timeout)
+	}
+	stopped := interrupted || opts.Stats.TimedOut
+	if *cacheDir != "" && !cached && !stopped { // A search cut short may have missed better solutions
+		if err := storeCachedSolutions(*cacheDir, cacheKey, found); err != nil {
+			logger.Error("could not cache this run", "cache", *cacheDir, "error", err)
+		}
+	}
+	if *showStats {
+		printStats(opts.Stats)
+	}
+	if stopped && *format == "text" {
+		closest := opts.Stats.Closest
+		if closest == nil {
+			closest = miss.closest()
+		}
+		if len(found) == 0 && closest != nil {
+			fmt.Println("No solution yet.  The closest so far:")
+			printSummary(closest)
+			fmt.Println("\t", closest.GoalShortfall())
+		}
+	}
+	if *diverse && len(found) > 0 && !stopped {
+		found = solver.DiverseSolutions(startSequence, *solutions)
+	}
+	if *history != "" {
+		if _, err := recordHistory(*history, scenario, found, opts.Stats.Searched, opts.Stats.Elapsed); err != nil {
+			logger.Error("could not record this run", "history", *history, "error", err)
+		}
+	}
+	if *format != "text" {
+		solutionPrinters[*format](found)
+		return
+	}
+	if *resilient {
+		plans := solver.SolveResiliently(startSequence, *solutions)
+		if len(plans) == 0 {
+			fmt.Println(solver.Colorize("red", "No plan meets the goal if any single action fails"))
+		}
+		found = append(plans, found...)
+	}
+	var robustness []solver.Robustness
+	if *analyze {
+		robustness = solver.RankByRobustness(found)
+	}
+	for i := len(found) - 1; i >= 0; i-- { // Present the best solution last
+		sequence := found[i]
+		printSummary(sequence)
+		fmt.Println(solver.DescribeScore(sequence))
+		if *resilient {
+			fmt.Println(solver.DescribeResilience(sequence))
+		}
+		if *analyze {
+			fmt.Println(robustness[i])
+		}
+		if *minimized {
+			if minimal := solver.Minimize(sequence); minimal.Size < sequence.Size {
+				fmt.Println(solver.Colorize("yellow", "Minimized by removing ", sequence.Size-minimal.Size, " redundant actions:"))
+				printSummary(minimal)
+			}
+		}
+		if *check {
+			if err := solver.Simulate(scenario, sequence.Commands(), sequence.Crew()); err != nil {
+				fmt.Println(solver.Colorize("red", "CHECK FAILED:"), err)
+			} else {
+				fmt.Println(solver.Colorize("green", "CHECK PASSED"))
+			}
+		}
+	}
+	if *improveFor > 0 && len(found) > 0 {
+		started := time.Now()
+		fmt.Println()
+		fmt.Println("Searching", *improveFor, "for better plans...")
+		exhausted := solver.Improve(startSequence, found[0], started.Add(*improveFor), func(better *solver.Sequence) {
+			printSummary(better)
+			fmt.Println(solver.Colorize("green", "Improved after ", time.Since(started).Round(time.Millisecond)))
+		})
+		if exhausted {
+			fmt.Println(solver.Colorize("green", "Every plan has been considered, so the last one shown is the best"))
+		}
+	}
+	if resource, ok := solver.ResourceNamed(scenario.Maximize); ok && len(found) > 0 {
+		fmt.Println(solver.Colorize("green", "Most ", resource, " achievable: ", found[0].Resources.Get(resource)))
+	}
+	if greedy != nil && greedy.IsSuccess() && len(found) > 0 {
+		fmt.Println()
+		fmt.Printf("Turnwise plan: %d actions (score %d); full search: %d actions (score %d)\n", greedy.Size, greedy.Score(), found[0].Size, found[0].Score())
+	}
+	if len(found) == 0 && !stopped {
+		explainUnsolvable(startSequence)
+	}
+	if len(found) == 0 && *prove {
+		proveUnsolvable(startSequence)
+	}
+	if *coverage {
+		printCoverage(startSequence)
+	}
+	if *interactive && len(found) > 0 && !interrupted {
+		copilot(startSequence, found[0], os.Stdin)
+	}
+}
@@ -0,0 +1,164 @@
+package parallelsearch
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Beam implements a beam search of a tree of searchable "nodes": breadth-first, but keeping only the
+// most promising width "nodes" at each depth, which trades completeness for a search whose cost grows
+// only linearly with depth.  The "nodes" at each depth are ranked by Heuristic (if implemented), then
+// by Score (lower being better), then by the order in which they were found, so the search is
+// deterministic.  Each depth is searched in parallel.
+type Beam struct {
+	poolSize    int
+	depthLimit  int
+	searchLimit int
+	width       int
+	found       []Searchable
+	onFound     func(Searchable)
+	searched    uint64
+	stopped     int32
+	done        chan bool
+}
+
+// NewBeam creates a new beam search, with the same parameters as New plus the number of "nodes" kept
+// at each depth (zero for no limit)
+func NewBeam(poolSize int, depthLimit int, searchLimit int, width int) *Beam {
+	return &Beam{poolSize: poolSize, depthLimit: depthLimit, searchLimit: searchLimit, width: width, done: make(chan bool)}
+}
+
+// Start will initiate a new search with the given starting "node" or "nodes".  If the context is
+// cancelled the search is stopped, and whatever has been found so far is returned by WaitForFound.
+// NOTE: This method should only be called once.
+func (self *Beam) Start(ctx context.Context, searchables ...Searchable) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			atomic.StoreInt32(&self.stopped, 1)
+		case <-self.done:
+		}
+	}()
+	go func() {
+		self.search(searchables)
+		close(self.done)
+	}()
+}
+
+// OnFound registers a function to be called with each result as soon as the depth at which it was
+// found is finished, as ParallelSearch.OnFound.  NOTE: This method should be called before Start.
+func (self *Beam) OnFound(onFound func(Searchable)) {
+	self.onFound = onFound
+}
+
+// WaitForFound will wait until either we have found searchLimit results (having finished the depth at
+// which they were found, so there may be more) or there are no more "nodes" to consider.  Either way
+// the results found (if any) will be sorted by score and returned.
+func (self *Beam) WaitForFound() []Searchable {
+	<-self.done
+	found := self.found
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Score() > found[j].Score()
+	})
+	return found
+}
+
+// Searched is the number of "nodes" searched so far
+func (self *Beam) Searched() uint64 {
+	return atomic.LoadUint64(&self.searched)
+}
+
+func (self *Beam) search(level []Searchable) {
+	level = self.prune(level, 0)
+	for depth := 0; len(level) > 0 && atomic.LoadInt32(&self.stopped) == 0; depth++ {
+		children := self.expand(level, depth)
+		if len(self.found) >= self.searchLimit || depth >= self.depthLimit {
+			return
+		}
+		next := []Searchable{}
+		for _, c := range children {
+			next = append(next, c...)
+		}
+		level = self.prune(next, depth+1)
+	}
+}
+
+// expand searches every "node" at a depth in parallel, returning the children of each (in order)
+func (self *Beam) expand(level []Searchable, depth int) [][]Searchable {
+	children := make([][]Searchable, len(level))
+	found := make([]bool, len(level))
+	next := int64(-1)
+	workers := sync.WaitGroup{}
+	for w := 0; w < self.poolSize; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(level) || atomic.LoadInt32(&self.stopped) != 0 {
+					return
+				}
+				atomic.AddUint64(&self.searched, 1)
+				if found[i] = level[i].IsFound(); !found[i] && depth < self.depthLimit {
+					level[i].Search(func(child Searchable) {
+						children[i] = append(children[i], child)
+					})
+				}
+			}
+		}()
+	}
+	workers.Wait()
+	for i, searchable := range level {
+		if found[i] {
+			self.found = append(self.found, searchable)
+			if self.onFound != nil {
+				self.onFound(searchable)
+			}
+		}
+	}
+	return children
+}
+
+// prune drops "nodes" equivalent to one before them (see Keyed), then keeps the best width of those
+// left
+func (self *Beam) prune(level []Searchable, depth int) []Searchable {
+	type ranked struct {
+		searchable Searchable
+		estimate   int
+		score      int
+	}
+	seen := map[visit]bool{}
+	candidates := []ranked{}
+	for _, searchable := range level {
+		if keyed, ok := searchable.(Keyed); ok {
+			key := visit{depth, keyed.Key()}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		estimate := 0
+		if heuristic, ok := searchable.(Heuristic); ok {
+			estimate = heuristic.Heuristic()
+		}
+		candidates = append(candidates, ranked{searchable, estimate, searchable.Score()})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].estimate != candidates[j].estimate {
+			return candidates[i].estimate < candidates[j].estimate
+		}
+		return candidates[i].score < candidates[j].score
+	})
+	if self.width > 0 && len(candidates) > self.width {
+		candidates = candidates[:self.width]
+	}
+	pruned := make([]Searchable, len(candidates))
+	for i, candidate := range candidates {
+		pruned[i] = candidate.searchable
+	}
+	return pruned
+}
@@ -0,0 +1,31 @@
+package parallelsearch
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBeamKeepsTheBestAtEachDepth(t *testing.T) {
+	for _, test := range []struct {
+		width    int
+		searched uint64
+		found    int
+	}{
+		{2, 1 + 2 + 2 + 2, 2},
+		{0, 1 + 5 + 25 + 125, 125}, // No limit
+	} {
+		beam := NewBeam(4, 3, 1000, test.width)
+		beam.Start(context.Background(), wideTree(5, 3))
+		found := beam.WaitForFound()
+		if beam.Searched() != test.searched || len(found) != test.found {
+			t.Errorf("width %d searched %d and found %d, want %d and %d", test.width, beam.Searched(), len(found), test.searched, test.found)
+		}
+		if test.width > 0 {
+			for _, searchable := range found {
+				if searchable.Score() != 0 {
+					t.Errorf("width %d found %s (score %d), want only the lowest scores kept", test.width, searchable.(*node).key, searchable.Score())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package parallelsearch
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// closest keeps the most promising "node" searched which was not found: the one with the lowest
+// Heuristic (if implemented), then the lowest Score.  It tells a search which found nothing how close
+// it got.
+type closest struct {
+	mutex      sync.Mutex
+	estimate   int64 // Of the searchable, read atomically to skip locking for those which are no closer
+	searchable Searchable
+}
+
+func newClosest() *closest {
+	return &closest{estimate: math.MaxInt64}
+}
+
+// consider keeps the searchable (which was not found) if it is the closest yet.  It is safe to call
+// concurrently.
+func (self *closest) consider(searchable Searchable) {
+	estimate := int64(0)
+	if heuristic, ok := searchable.(Heuristic); ok {
+		estimate = int64(heuristic.Heuristic())
+	}
+	if estimate > atomic.LoadInt64(&self.estimate) {
+		return
+	}
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if current := atomic.LoadInt64(&self.estimate); estimate < current || searchable.Score() < self.searchable.Score() {
+		atomic.StoreInt64(&self.estimate, estimate)
+		self.searchable = searchable
+	}
+}
+
+func (self *closest) get() Searchable {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.searchable
+}
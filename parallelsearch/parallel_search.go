@@ -1,12 +1,12 @@
 package parallelsearch
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"sync"
 	"sync/atomic"
-
-	"github.com/gammazero/workerpool"
+	"time"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -18,17 +18,112 @@ type Searchable interface {
 	Score() int
 }
 
+// Keyed may also be implemented by a Searchable.  Searchables at the same depth with equal keys are
+// considered equivalent (with identical futures), so only the first of them is searched.  Keys must be
+// comparable (e.g. a struct of numbers).
+type Keyed interface {
+	Key() interface{}
+}
+
+// ResultKeyed may also be implemented by a Searchable, so that a ParallelSearch told to find
+// UniqueResults returns only the best of the results with equal keys (however deep they were found).
+// Keys must be comparable, and should identify the result in its canonical form (e.g. the state it
+// arrives at, regardless of the path taken to it).
+type ResultKeyed interface {
+	ResultKey() interface{}
+}
+
+// Prioritized may also be implemented by a Searchable, so that a ParallelSearch told to Prioritize
+// searches those at each depth with the highest Priority first, rather than in the order they were
+// found
+type Prioritized interface {
+	Priority() int
+}
+
+type visit struct {
+	depth int
+	key   interface{}
+}
+
+type result struct {
+	searchable Searchable
+	depth      int
+}
+
+// task is a "node" waiting to be searched
+type task struct {
+	searchable Searchable
+	depth      int
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 // ParallelSearch implements a breadth-first search of a tree of searchable "nodes"
-// This is done in parallel using a FIFO worker pool.
+// This is done in parallel by a fixed set of workers, each taking a "node" at the shallowest depth
+// with any waiting (its own, or else one stolen from another worker, see worker).
 type ParallelSearch struct {
-	workerPool  *workerpool.WorkerPool
-	depthLimit  int
+	workers     []*worker
+	wake        chan struct{} // Signalled when "nodes" are queued while any worker is idle
+	idle        int32         // How many workers are waiting for "nodes" to search
+	depthLimit  int64         // May be lowered (and raised again) while searching, see SetDepthLimit
+	widthLimit  int64         // Zero for unlimited, see SetWidthLimit
 	searchLimit int
-	waiters     []*sync.WaitGroup
+	shallowest  bool // See StopAtShallowest
+	submitted   []*uint64
+	pending     []*int64 // Waiting to be searched (in memory or spilled), by depth
+	unfinished  []*int64 // Submitted but not yet searched, by depth
 	searched    []*uint64
-	found       chan Searchable
+	queued      int64           // How many "nodes" are waiting to be searched in memory
+	completed   int             // The deepest depth known to be finished (-1 if none), see complete
+	completion  sync.Mutex      // Held while completing depths
+	depthDone   []chan struct{} // Closed as each depth is finished
+	finished    int64           // The deepest depth finished (and reported) so far (-1 if none)
+	foundCount  int64
+	foundAt     []*int64     // How many of foundCount were found at each depth
+	started     atomic.Value // The time.Time when Start was called
+	foundMutex  sync.Mutex
+	found       []result      // At most searchLimit of them, unless stopping at the shallowest
+	done        chan struct{} // Closed once every depth is finished
+	observer    func(Searchable)
+	reporter    func(Progress)
+	onFound     func(Searchable)
+	prioritized *submissions // Nil unless prioritizing, see Prioritize
+	stopped     int32
+	satisfied   int32               // Set once searchLimit results are found, after which no more "nodes" are expanded
+	visited     sync.Map            // Keys of Keyed searchables already submitted, by depth
+	duplicates  bool                // See KeepDuplicates
+	unique      map[interface{}]int // Index in found of the result with each key (nil unless finding UniqueResults)
+	queueLimit  int                 // Zero for unlimited, see SetMemoryBudget
+	encode      func(Searchable) ([]byte, error)
+	restore     func(raw []byte) (Searchable, error)
+	spill       *spillQueue
+	spilled     int64 // How many "nodes" are waiting in the spill queue
+	err         error // The first error spilling "nodes" (guarded by foundMutex), see WaitForFound
+	closest     *closest
+	stats       *statistics // Nil unless collected, see CollectStatistics
+}
+
+// Progress is a snapshot of a search which is still running
+type Progress struct {
+	Depth    int           // The deepest depth reached so far
+	Finished int           // The deepest depth finished so far (-1 if none)
+	Searched []uint64      // How many "nodes" have been searched at each depth
+	Pending  []uint64      // How many "nodes" are still to be searched at each depth (as far as is known)
+	Queued   int           // How many "nodes" are waiting to be searched (in memory)
+	Spilled  int           // How many "nodes" are waiting to be searched on disk (see SetMemoryBudget)
+	Found    int           // How many results have been found so far
+	FoundAt  []int         // How many of them were found at each depth
+	Elapsed  time.Duration // Since Start
+	Halted   bool          // Whether the search was stopped (or found enough) before finishing every depth, see Report
+}
+
+// Total is the number of "nodes" searched at every depth
+func (self *Progress) Total() uint64 {
+	total := uint64(0)
+	for _, searched := range self.Searched {
+		total += searched
+	}
+	return total
 }
 
 // New creates a new parallel search.  The poolSize determines the number of simultaneous
@@ -36,81 +131,535 @@ type ParallelSearch struct {
 // breadth-first search to proceed.  The searchLimit determines how many results we are
 // looking for before stopping.
 func New(poolSize int, depthLimit int, searchLimit int) *ParallelSearch {
+	if poolSize < 1 {
+		poolSize = 1
+	}
 	ps := &ParallelSearch{}
-	ps.workerPool = workerpool.New(poolSize)
-	ps.depthLimit = depthLimit
-	ps.searchLimit = searchLimit
-	ps.waiters = make([]*sync.WaitGroup, depthLimit+1) // Allow for depth of 0 in addition to other depths
-	for depth := range ps.waiters {
-		ps.waiters[depth] = &sync.WaitGroup{}
+	ps.workers = make([]*worker, poolSize)
+	for i := range ps.workers {
+		ps.workers[i] = &worker{id: i}
 	}
+	ps.wake = make(chan struct{}, poolSize)
+	ps.depthLimit = int64(depthLimit)
+	ps.searchLimit = searchLimit
+	// Allow for depth of 0 in addition to other depths
+	ps.submitted = make([]*uint64, depthLimit+1)
+	ps.pending = make([]*int64, depthLimit+1)
+	ps.unfinished = make([]*int64, depthLimit+1)
 	ps.searched = make([]*uint64, depthLimit+1)
+	ps.foundAt = make([]*int64, depthLimit+1)
+	ps.depthDone = make([]chan struct{}, depthLimit+1)
 	for depth := range ps.searched {
-		d := uint64(0)
+		s, p, u, d, f := uint64(0), int64(0), int64(0), uint64(0), int64(0)
+		ps.submitted[depth] = &s
+		ps.pending[depth] = &p
+		ps.unfinished[depth] = &u
 		ps.searched[depth] = &d
+		ps.foundAt[depth] = &f
+		ps.depthDone[depth] = make(chan struct{})
 	}
-	ps.found = make(chan Searchable, searchLimit)
+	ps.completed, ps.finished = -1, -1
+	ps.done = make(chan struct{})
+	ps.closest = newClosest()
 	return ps
 }
 
 // Start will initiate a new search with the given starting "node" or "nodes".  It will
-// announce the completion of each depth/layer as it proceeds.  NOTE: This method should
-// only be called once to avoid duplicate depth announcement.
-func (self *ParallelSearch) Start(searchables ...Searchable) {
+// report the completion of each depth/layer as it proceeds (see Report).  If the context is
+// cancelled the search is stopped (see Stop).  NOTE: This method should only be called once
+// to avoid duplicate depth reports.
+func (self *ParallelSearch) Start(ctx context.Context, searchables ...Searchable) {
+	self.started.Store(time.Now())
+	if self.stats != nil {
+		self.stats.started = time.Now()
+	}
+	done := make(chan bool)
+	go func() {
+		select {
+		case <-ctx.Done():
+			self.Stop()
+		case <-done:
+		}
+	}()
+	batch := []task{}
 	for _, searchable := range searchables {
-		self.asyncSearch(searchable, 0)
+		batch = self.asyncSearch(batch, searchable, 0)
+	}
+	self.queue(self.workers[0], batch)
+	for _, worker := range self.workers {
+		go self.work(worker)
+	}
+	self.complete() // In case there was nothing to search
+	go func() {
+		self.reportDepthCompletion()
+		close(done)
+	}()
+}
+
+// Observe registers a function to be called (concurrently) with every "node" searched.  NOTE: This
+// method should be called before Start.
+func (self *ParallelSearch) Observe(observer func(Searchable)) {
+	self.observer = observer
+}
+
+// OnFound registers a function to be called with each result the moment it is found (never
+// concurrently, but from the workers, so it should be quick), before WaitForFound returns them all.
+// NOTE: This method should be called before Start.
+func (self *ParallelSearch) OnFound(onFound func(Searchable)) {
+	self.onFound = onFound
+}
+
+// Report registers a function to be called with the progress of the search each time a depth is
+// finished.  Once the search is halted (see Stop) the depths it gives up on are not reported; instead
+// it is called one last time with the progress marked Halted.  NOTE: This method should be called
+// before Start.
+func (self *ParallelSearch) Report(reporter func(Progress)) {
+	self.reporter = reporter
+}
+
+// Prioritize makes the search take the "nodes" waiting at the shallowest depth in order of their
+// Priority (highest first, see Prioritized) rather than first-in first-out, so that the most promising
+// branches are expanded (and any results in them found) first.  Depths are still searched in order.
+// NOTE: This method should be called before Start.
+func (self *ParallelSearch) Prioritize() {
+	self.prioritized = &submissions{}
+}
+
+// KeepDuplicates makes the search expand every "node", even those with the same key (see Keyed) as one
+// already submitted at the same depth, which spares it remembering every key.  This only pays for trees
+// in which such duplicates are rare.  NOTE: This method should be called before Start.
+func (self *ParallelSearch) KeepDuplicates() {
+	self.duplicates = true
+}
+
+// StopAtShallowest makes the search stop going deeper as soon as anything is found, so that only the
+// results at the shallowest depth with any are returned: all of them, however many more than
+// searchLimit there are (so that the caller may choose the best).  NOTE: This method should be called
+// before Start.
+func (self *ParallelSearch) StopAtShallowest() {
+	self.shallowest = true
+}
+
+// UniqueResults makes the search keep only the best of the results with the same key (see
+// ResultKeyed), by Score and then the shallowest, so that results reached by equivalent paths are
+// returned once and don't use up searchLimit.  NOTE: This method should be called before Start.
+func (self *ParallelSearch) UniqueResults() {
+	self.unique = map[interface{}]int{}
+}
+
+// SetMemoryBudget bounds the memory held by "nodes" waiting to be searched: once more than maxQueued
+// are waiting, any more are serialized (with encode) to a temporary file, and restored (with restore)
+// as the queue empties again.  Nodes which can not be serialized are kept in memory.  If the file can
+// not be read back, or a "node" restored, the search is stopped and WaitForFound returns the error.
+// NOTE: This method should be called before Start.
+func (self *ParallelSearch) SetMemoryBudget(maxQueued int, encode func(Searchable) ([]byte, error), restore func(raw []byte) (Searchable, error)) error {
+	spill, err := newSpillQueue()
+	if err != nil {
+		return err
+	}
+	self.queueLimit, self.encode, self.restore, self.spill = maxQueued, encode, restore, spill
+	return nil
+}
+
+// SetDepthLimit changes how deep the search may proceed.  It may be lowered and raised again while
+// searching, but never beyond the depthLimit given to New.
+func (self *ParallelSearch) SetDepthLimit(depthLimit int) {
+	if depthLimit > len(self.searched)-1 {
+		depthLimit = len(self.searched) - 1
 	}
-	go self.announceDepthCompletion()
+	atomic.StoreInt64(&self.depthLimit, int64(depthLimit))
+}
+
+// DepthLimit is how deep the search may currently proceed
+func (self *ParallelSearch) DepthLimit() int {
+	return int(atomic.LoadInt64(&self.depthLimit))
+}
+
+// SetWidthLimit restricts how many "nodes" may be searched at each depth (zero for no limit).  Any
+// beyond this are simply dropped, which makes the search much cheaper but no longer exhaustive.
+func (self *ParallelSearch) SetWidthLimit(widthLimit int) {
+	atomic.StoreInt64(&self.widthLimit, int64(widthLimit))
+}
+
+// WidthLimit is how many "nodes" may currently be searched at each depth (zero for no limit)
+func (self *ParallelSearch) WidthLimit() int {
+	return int(atomic.LoadInt64(&self.widthLimit))
+}
+
+// Stop abandons the search.  Any "nodes" still queued are drained without being searched, and
+// whatever has been found so far is returned by WaitForFound.
+func (self *ParallelSearch) Stop() {
+	atomic.StoreInt32(&self.stopped, 1)
+}
+
+// halted is true once the search has been stopped, or has found all the results it needs
+func (self *ParallelSearch) halted() bool {
+	return atomic.LoadInt32(&self.stopped) != 0 || atomic.LoadInt32(&self.satisfied) != 0
+}
+
+// Progress reports how far the search has proceeded, without interrupting it
+func (self *ParallelSearch) Progress() Progress {
+	progress := Progress{
+		Finished: int(atomic.LoadInt64(&self.finished)),
+		Queued:   int(atomic.LoadInt64(&self.queued)),
+		Spilled:  int(atomic.LoadInt64(&self.spilled)),
+		Found:    int(atomic.LoadInt64(&self.foundCount)),
+	}
+	if started, ok := self.started.Load().(time.Time); ok {
+		progress.Elapsed = time.Since(started)
+	}
+	for depth := range self.searched {
+		searched := atomic.LoadUint64(self.searched[depth])
+		if searched > 0 {
+			progress.Depth = depth
+		}
+		progress.Searched = append(progress.Searched, searched)
+		progress.Pending = append(progress.Pending, uint64(atomic.LoadInt64(self.pending[depth])))
+		progress.FoundAt = append(progress.FoundAt, int(atomic.LoadInt64(self.foundAt[depth])))
+	}
+	return progress
 }
 
 // WaitForFound will wait until either we have found searchLimit results or we have reached
-// the depthLimit with no more "nodes" to consider.  Either way the results found (if any)
-// will be sorted by score and returned.
-func (self *ParallelSearch) WaitForFound() []Searchable {
+// the depthLimit with no more "nodes" to consider (or, see StopAtShallowest, the shallowest
+// depth with results has been finished).  Either way the results found (if any) will be
+// sorted by score and returned, once the workers have drained whatever was still queued (without
+// searching it), so that none of the "nodes" are still in use.  An error is returned (along with
+// whatever was found) if the search was stopped because spilled "nodes" were lost, see
+// SetMemoryBudget.
+func (self *ParallelSearch) WaitForFound() ([]Searchable, error) {
+	<-self.done
+	self.foundMutex.Lock()
+	results, err := self.found, self.err
+	self.foundMutex.Unlock()
+	shallowest := -1
+	for _, result := range results {
+		if shallowest < 0 || result.depth < shallowest {
+			shallowest = result.depth
+		}
+	}
 	found := []Searchable{}
-	for searchable := range self.found {
-		found = append(found, searchable)
-		if len(found) >= self.searchLimit {
-			break
+	for _, result := range results {
+		if !self.shallowest || result.depth == shallowest { // Anything found deeper is worse
+			found = append(found, result.searchable)
 		}
 	}
 	// Sort results by "Score"
 	sort.Slice(found, func(i, j int) bool {
 		return found[i].Score() > found[j].Score()
 	})
-	return found
+	return found, err
 }
 
-func (self *ParallelSearch) asyncSearch(searchable Searchable, depth int) {
+// WaitForFoundWithTimeout is WaitForFound, except that once the timeout has passed the search is
+// stopped, and whatever has been found by then is returned.  The closest "node" reached which was not
+// found (see Closest) is also returned, so that if nothing was found the caller can tell how close
+// the search got.
+func (self *ParallelSearch) WaitForFoundWithTimeout(timeout time.Duration) ([]Searchable, Searchable, error) {
+	timer := time.AfterFunc(timeout, self.Stop)
+	defer timer.Stop()
+	found, err := self.WaitForFound()
+	return found, self.Closest(), err
+}
+
+// Closest is the most promising "node" searched so far which was not found (see closest), or nil if
+// nothing has been searched
+func (self *ParallelSearch) Closest() Searchable {
+	return self.closest.get()
+}
+
+// asyncSearch submits a "node" found at the given depth, adding it to the batch to be queued (see
+// queue) unless it is skipped as a duplicate, dropped beyond the width limit, or spilled
+func (self *ParallelSearch) asyncSearch(batch []task, searchable Searchable, depth int) []task {
+	// Skip anything equivalent to what has already been submitted at this depth
+	if keyed, ok := searchable.(Keyed); ok && !self.duplicates {
+		if _, seen := self.visited.LoadOrStore(visit{depth, keyed.Key()}, true); seen {
+			if self.stats != nil {
+				atomic.AddUint64(&self.stats.duplicates[depth], 1)
+			}
+			return batch
+		}
+	}
+
+	// Drop anything beyond the width limit for this depth
+	submitted := atomic.AddUint64(self.submitted[depth], 1)
+	if widthLimit := atomic.LoadInt64(&self.widthLimit); widthLimit > 0 && submitted > uint64(widthLimit) {
+		if self.stats != nil {
+			atomic.AddUint64(&self.stats.dropped[depth], 1)
+		}
+		return batch
+	}
+	if self.stats != nil {
+		self.stats.enqueued(1)
+	}
+
 	// Keep track of how many items we have started searching at this depth
-	self.waiters[depth].Add(1)
+	atomic.AddInt64(self.unfinished[depth], 1)
+	atomic.AddInt64(self.pending[depth], 1)
 
-	// Add the searchable to the pool
-	self.workerPool.Submit(func() {
-		self.search(searchable, depth)
-	})
+	// Once over budget (or while older "nodes" are still spilled, to keep them in order), spill it
+	if self.queueLimit > 0 && (atomic.LoadInt64(&self.spilled) > 0 || int(atomic.LoadInt64(&self.queued))+len(batch) >= self.queueLimit) {
+		if raw, err := self.encode(searchable); err == nil && self.spill.push(depth, raw) == nil {
+			atomic.AddInt64(&self.spilled, 1)
+			return batch
+		}
+	}
+	return append(batch, task{searchable, depth})
+}
+
+// queue hands a batch of "nodes" to the worker which found them (or if prioritizing, to whichever
+// worker takes them first), waking idle workers to steal them
+func (self *ParallelSearch) queue(worker *worker, batch []task) {
+	if len(batch) == 0 {
+		return
+	}
+	atomic.AddInt64(&self.queued, int64(len(batch)))
+	if self.prioritized != nil {
+		self.prioritized.push(batch)
+	} else {
+		worker.push(batch)
+	}
+	for wanted := min(int(atomic.LoadInt32(&self.idle)), len(batch)); wanted > 0; wanted-- {
+		select {
+		case self.wake <- struct{}{}:
+		default:
+			return // Every idle worker is being woken already
+		}
+	}
+}
+
+// reloadSpilled moves "nodes" from the spill queue back to the worker while there is room for them
+func (self *ParallelSearch) reloadSpilled(worker *worker) {
+	for atomic.LoadInt64(&self.spilled) > 0 && int(atomic.LoadInt64(&self.queued)) < self.queueLimit/2+1 {
+		depth, raw, ok, err := self.spill.pop()
+		if err != nil {
+			self.fail(fmt.Errorf("could not reload spilled search: %w", err))
+			for depth, waiting := range self.spill.abandon() {
+				atomic.AddInt64(&self.spilled, -int64(waiting))
+				self.discard(depth, waiting)
+			}
+			return
+		} else if !ok {
+			return
+		}
+		atomic.AddInt64(&self.spilled, -1)
+		if self.halted() {
+			self.discard(depth, 1)
+			continue
+		}
+		searchable, err := self.restore(raw)
+		if err != nil {
+			self.fail(fmt.Errorf("could not restore spilled search: %w", err))
+			self.discard(depth, 1)
+			continue
+		}
+		self.queue(worker, []task{{searchable, depth}})
+	}
 }
 
-func (self *ParallelSearch) search(searchable Searchable, depth int) {
+// discard finishes the given number of spilled "nodes" at the given depth without searching them
+func (self *ParallelSearch) discard(depth int, count int) {
+	atomic.AddInt64(self.pending[depth], -int64(count))
+	if self.stats != nil {
+		self.stats.enqueued(-int64(count))
+	}
+	for ; count > 0; count-- {
+		self.finish(depth)
+	}
+}
+
+// fail stops the search, keeping the first error for WaitForFound
+func (self *ParallelSearch) fail(err error) {
+	self.foundMutex.Lock()
+	if self.err == nil {
+		self.err = err
+	}
+	self.foundMutex.Unlock()
+	self.Stop()
+}
+
+// work searches "nodes" until the search is over, sleeping whenever there are none to take
+func (self *ParallelSearch) work(worker *worker) {
+	for {
+		next, ok := self.take(worker)
+		if !ok {
+			atomic.AddInt32(&self.idle, 1)
+			if next, ok = self.take(worker); !ok { // Any queued before this worker was counted as idle
+				select {
+				case <-self.wake:
+				case <-self.done:
+					return
+				}
+			}
+			atomic.AddInt32(&self.idle, -1)
+			if !ok {
+				continue
+			}
+		}
+		self.search(worker, next.searchable, next.depth)
+	}
+}
+
+// take finds the next "node" for the worker to search: if prioritizing, the most promising of the
+// shallowest waiting (see Prioritize), or else one at the shallowest depth with any waiting, its own
+// if it has one there, or else stolen from another worker
+func (self *ParallelSearch) take(worker *worker) (task, bool) {
+	if self.prioritized != nil {
+		return self.prioritized.pop()
+	}
+	for depth, pending := range self.pending {
+		if atomic.LoadInt64(pending) == 0 {
+			continue
+		}
+		if next, ok := worker.pop(depth); ok {
+			return next, true
+		}
+		for i := 1; i < len(self.workers); i++ {
+			if next, ok := worker.steal(self.workers[(worker.id+i)%len(self.workers)], depth); ok {
+				return next, true
+			}
+		}
+	}
+	return task{}, false
+}
+
+func (self *ParallelSearch) search(worker *worker, searchable Searchable, depth int) {
+	atomic.AddInt64(&self.queued, -1)
+	atomic.AddInt64(self.pending[depth], -1)
+	if !self.halted() && !(self.shallowest && depth > self.DepthLimit()) {
+		if self.stats != nil {
+			started := time.Now()
+			self.searchNow(worker, searchable, depth)
+			atomic.AddInt64(&self.stats.busy, int64(time.Since(started)))
+		} else {
+			self.searchNow(worker, searchable, depth)
+		}
+	}
+	if self.stats != nil {
+		self.stats.enqueued(-1)
+	}
+	if self.spill != nil {
+		self.reloadSpilled(worker)
+	}
+	// Mark this searchable has having been searched
+	self.finish(depth)
+}
+
+func (self *ParallelSearch) searchNow(worker *worker, searchable Searchable, depth int) {
 	atomic.AddUint64(self.searched[depth], 1)
+	if self.observer != nil {
+		self.observer(searchable)
+	}
 	if searchable.IsFound() {
-		self.found <- searchable
-	} else if depth < self.depthLimit { // Don't go past depthLimit
+		self.collect(searchable, depth)
+		return
+	}
+	self.closest.consider(searchable)
+	if depth < self.DepthLimit() && !self.halted() { // Don't go past depthLimit (or keep going once halted)
+		// The children are queued together, so that the worker's queue is locked once for them all
+		batch := worker.batch
 		searchable.Search(func(nextSearchable Searchable) {
-			self.asyncSearch(nextSearchable, depth+1)
+			batch = self.asyncSearch(batch, nextSearchable, depth+1)
 		})
+		self.queue(worker, batch)
+		clear(batch) // Let them be collected once searched
+		worker.batch = batch[:0]
+	}
+}
+
+// collect keeps a result, unless searchLimit have been found already (see StopAtShallowest), or if
+// finding UniqueResults, one with the same key has (in which case the better of them is kept)
+func (self *ParallelSearch) collect(searchable Searchable, depth int) {
+	// Results are collected rather than sent to WaitForFound, so that workers finding more than are
+	// wanted never wait on it (and those beyond searchLimit are simply dropped)
+	self.foundMutex.Lock()
+	defer self.foundMutex.Unlock()
+	var key interface{}
+	if keyed, ok := searchable.(ResultKeyed); ok && self.unique != nil {
+		key = keyed.ResultKey()
+		if i, seen := self.unique[key]; seen {
+			if self.stats != nil {
+				atomic.AddUint64(&self.stats.duplicates[depth], 1)
+			}
+			if kept := self.found[i]; searchable.Score() > kept.searchable.Score() || searchable.Score() == kept.searchable.Score() && depth < kept.depth {
+				self.found[i] = result{searchable, depth}
+				if self.onFound != nil {
+					self.onFound(searchable)
+				}
+			}
+			return
+		}
+	}
+	count := atomic.AddInt64(&self.foundCount, 1)
+	atomic.AddInt64(self.foundAt[depth], 1)
+	if self.shallowest {
+		self.lowerDepthLimit(depth)
+	}
+	if self.shallowest || count <= int64(self.searchLimit) {
+		if key != nil {
+			self.unique[key] = len(self.found)
+		}
+		self.found = append(self.found, result{searchable, depth})
+		if self.onFound != nil {
+			self.onFound(searchable)
+		}
+	}
+	if !self.shallowest && count == int64(self.searchLimit) {
+		atomic.StoreInt32(&self.satisfied, 1) // Whatever is still queued is drained without being searched
+	}
+}
+
+// finish counts a "node" at the given depth as searched, finishing the depth (and any deeper ones
+// which are done with it) if it was the last
+func (self *ParallelSearch) finish(depth int) {
+	if atomic.AddInt64(self.unfinished[depth], -1) == 0 {
+		self.complete()
+	}
+}
+
+// complete finishes each depth in turn which has no "nodes" left to search.  Only "nodes" at the depth
+// before can find more, so once that depth is finished, a depth with none left is finished too.
+func (self *ParallelSearch) complete() {
+	self.completion.Lock()
+	defer self.completion.Unlock()
+	for next := self.completed + 1; next < len(self.depthDone) && atomic.LoadInt64(self.unfinished[next]) == 0; next++ {
+		self.completed = next
+		close(self.depthDone[next])
 	}
-	// Mark this searchable has having been searched
-	self.waiters[depth].Done()
 }
 
-func (self *ParallelSearch) announceDepthCompletion() {
-	for depth, waiter := range self.waiters {
-		waiter.Wait()
-		if *self.searched[depth] > 0 {
-			fmt.Println("================ FINISHED DEPTH ", depth, " [", *self.searched[depth], "] ==================")
+// lowerDepthLimit stops the search going any deeper than the given depth
+func (self *ParallelSearch) lowerDepthLimit(depth int) {
+	for {
+		limit := atomic.LoadInt64(&self.depthLimit)
+		if limit <= int64(depth) || atomic.CompareAndSwapInt64(&self.depthLimit, limit, int64(depth)) {
+			return
 		}
 	}
+}
+
+func (self *ParallelSearch) reportDepthCompletion() {
+	for depth, depthDone := range self.depthDone {
+		<-depthDone
+		atomic.StoreInt64(&self.finished, int64(depth))
+		if self.stats != nil {
+			atomic.StoreInt64(&self.stats.finished[depth], int64(time.Since(self.stats.started)))
+		}
+		if atomic.LoadUint64(self.searched[depth]) > 0 && self.reporter != nil && !self.halted() {
+			self.reporter(self.Progress())
+		}
+	}
+	if self.reporter != nil && self.halted() {
+		progress := self.Progress()
+		progress.Halted = true
+		self.reporter(progress)
+	}
 	// If we've run out of searchables to consider, stop looking for more results
-	close(self.found)
+	if self.spill != nil {
+		self.spill.close()
+	}
+	if self.stats != nil {
+		atomic.StoreInt64(&self.stats.elapsed, int64(time.Since(self.stats.started)))
+	}
+	close(self.done)
 }
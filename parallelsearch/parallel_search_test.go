@@ -0,0 +1,231 @@
+package parallelsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// node is a "node" of a small tree searched in tests, found once it has no children
+type node struct {
+	children []*node
+	key      string
+	score    int
+}
+
+func (self *node) Search(onNext func(Searchable)) {
+	for _, child := range self.children {
+		onNext(child)
+	}
+}
+
+func (self *node) IsFound() bool {
+	return len(self.children) == 0
+}
+
+func (self *node) Score() int {
+	return self.score
+}
+
+func (self *node) ResultKey() interface{} {
+	return self.key
+}
+
+func TestUniqueResultsKeepsTheBest(t *testing.T) {
+	for i := 0; i < 100; i++ { // The workers race, so the results may be found in any order
+		root := &node{children: []*node{
+			{key: "x", score: 1},
+			{children: []*node{{key: "x", score: 5}, {key: "y", score: 2}}},
+			{key: "x", score: 3},
+		}}
+		ps := New(4, 3, 3)
+		ps.UniqueResults()
+		ps.Start(context.Background(), root)
+		found, err := ps.WaitForFound()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 2 || found[0].(*node).key != "x" || found[0].Score() != 5 || found[1].(*node).key != "y" {
+			t.Fatalf("found %v, want the best x (score 5) and y", found)
+		}
+	}
+}
+
+// spilledTree is a tree wide enough to spill (with a memory budget of a few "nodes"), whose nodes
+// are encoded by their keys
+func spilledTree() (*node, map[string]*node) {
+	root, byKey := &node{}, map[string]*node{}
+	for i := 0; i < 20; i++ {
+		parent := &node{key: fmt.Sprint("p", i)}
+		for j := 0; j < 5; j++ {
+			parent.children = append(parent.children, &node{key: fmt.Sprint("c", i, "-", j), score: i*5 + j})
+		}
+		root.children = append(root.children, parent)
+	}
+	for _, parent := range root.children {
+		byKey[parent.key] = parent
+		for _, child := range parent.children {
+			byKey[child.key] = child
+		}
+	}
+	return root, byKey
+}
+
+func encodeNode(searchable Searchable) ([]byte, error) {
+	return []byte(searchable.(*node).key), nil
+}
+
+func TestSpilledNodesAreRestored(t *testing.T) {
+	root, byKey := spilledTree()
+	ps := New(4, 2, 1000)
+	restore := func(raw []byte) (Searchable, error) {
+		return byKey[string(raw)], nil
+	}
+	if err := ps.SetMemoryBudget(3, encodeNode, restore); err != nil {
+		t.Fatal(err)
+	}
+	ps.Start(context.Background(), root)
+	found, err := ps.WaitForFound()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 100 {
+		t.Fatalf("found %d, want all 100 leaves", len(found))
+	}
+}
+
+func TestSpillErrorsAreReturned(t *testing.T) {
+	root, _ := spilledTree()
+	ps := New(4, 2, 1000)
+	failed := errors.New("lost")
+	restore := func(raw []byte) (Searchable, error) {
+		return nil, failed
+	}
+	if err := ps.SetMemoryBudget(3, encodeNode, restore); err != nil {
+		t.Fatal(err)
+	}
+	ps.Start(context.Background(), root)
+	if _, err := ps.WaitForFound(); !errors.Is(err, failed) {
+		t.Fatalf("got %v, want the error restoring a spilled node", err)
+	}
+}
+
+func TestReportCountsResultsByDepth(t *testing.T) {
+	root := &node{children: []*node{
+		{key: "a", score: 1},
+		{children: []*node{{key: "b", score: 2}, {key: "c", score: 3}}},
+	}}
+	reports := []Progress{}
+	ps := New(4, 2, 10)
+	ps.Report(func(progress Progress) { reports = append(reports, progress) })
+	ps.Start(context.Background(), root)
+	if _, err := ps.WaitForFound(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("%d reports, want one for each of 3 depths", len(reports))
+	}
+	for depth, progress := range reports {
+		if progress.Finished != depth || progress.Halted {
+			t.Errorf("report %d is of depth %d (halted %v)", depth, progress.Finished, progress.Halted)
+		}
+		if want := []int{0, 1, 2}[depth]; progress.FoundAt[depth] != want {
+			t.Errorf("%d found at depth %d, want %d", progress.FoundAt[depth], depth, want)
+		}
+		if progress.Elapsed <= 0 || depth > 0 && progress.Elapsed < reports[depth-1].Elapsed {
+			t.Errorf("depth %d finished %s after starting", depth, progress.Elapsed)
+		}
+	}
+}
+
+func TestReportOnceHalted(t *testing.T) {
+	reports := []Progress{}
+	ps := New(4, 3, 1)
+	ps.Report(func(progress Progress) { reports = append(reports, progress) })
+	ps.Start(context.Background(), wideTree(5, 3))
+	if _, err := ps.WaitForFound(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) == 0 || !reports[len(reports)-1].Halted {
+		t.Fatalf("reports %+v, want the last one halted", reports)
+	}
+	for _, progress := range reports[:len(reports)-1] {
+		if progress.Halted {
+			t.Errorf("reported halted before the end: %+v", progress)
+		}
+	}
+	if last := reports[len(reports)-1]; last.Found != 1 || last.FoundAt[3] != 1 {
+		t.Errorf("halted having found %d (%v), want 1 at depth 3", last.Found, last.FoundAt)
+	}
+}
+
+func TestStopAtShallowestFindsEveryResultAtThatDepth(t *testing.T) {
+	deep := &node{children: []*node{{key: "deep", score: 0}}}
+	root := &node{children: []*node{
+		{key: "a", score: 3},
+		deep,
+		{key: "b", score: 1},
+		{children: []*node{deep}},
+		{key: "c", score: 2},
+	}}
+	ps := New(4, 3, 1)
+	ps.StopAtShallowest()
+	ps.Start(context.Background(), root)
+	found, err := ps.WaitForFound()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{}
+	for _, searchable := range found {
+		keys = append(keys, searchable.(*node).key)
+	}
+	if fmt.Sprint(keys) != "[a c b]" {
+		t.Errorf("found %v, want every result at depth 1 (highest score first) and none deeper", keys)
+	}
+	if searched := ps.Progress().Searched; searched[3] != 0 {
+		t.Errorf("searched %v, want nothing below depth 2", searched)
+	}
+}
+
+// endless is a "node" of a tree with no results, which is built as it is searched
+type endless struct {
+	score int
+}
+
+func (self *endless) Search(onNext func(Searchable)) {
+	for i := 0; i < 10; i++ {
+		onNext(&endless{self.score + i})
+	}
+}
+
+func (self *endless) IsFound() bool {
+	return false
+}
+
+func (self *endless) Score() int {
+	return self.score
+}
+
+func TestWaitForFoundWithTimeout(t *testing.T) {
+	ps := New(4, 1000, 1)
+	started := time.Now()
+	ps.Start(context.Background(), &endless{})
+	found, closest, err := ps.WaitForFoundWithTimeout(50 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("gave up after %s", elapsed)
+	}
+	if len(found) != 0 || closest == nil || closest.IsFound() {
+		t.Errorf("found %v, closest %v; want nothing found, but something reached", found, closest)
+	}
+
+	ps = New(4, 3, 1000)
+	ps.Start(context.Background(), wideTree(5, 3))
+	if found, _, err := ps.WaitForFoundWithTimeout(time.Minute); err != nil || len(found) != 125 {
+		t.Errorf("found %d (%v) before the timeout, want all 125", len(found), err)
+	}
+}
@@ -0,0 +1,91 @@
+package parallelsearch
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"sync"
+)
+
+var errSpillAbandoned = errors.New("spill queue abandoned")
+
+////////////////////////////////////////////////////////////////////////////////
+
+// spillQueue is a first-in first-out queue of serialized "nodes" kept in a temporary file, for those
+// which would otherwise take a search over its memory budget (see SetMemoryBudget)
+type spillQueue struct {
+	mutex   sync.Mutex
+	file    *os.File
+	readAt  int64
+	writeAt int64
+	waiting map[int]int // How many "nodes" are waiting in the queue, by depth
+	broken  bool        // Set once the queue is abandoned, after which nothing more may be pushed
+}
+
+func newSpillQueue() (*spillQueue, error) {
+	file, err := os.CreateTemp("", "parallelsearch-*.spill")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(file.Name()) // Where possible, so that it is never left behind (even if the search is abandoned)
+	return &spillQueue{file: file, waiting: map[int]int{}}, nil
+}
+
+// push adds a serialized "node" (and its depth) to the end of the queue
+func (self *spillQueue) push(depth int, raw []byte) error {
+	record := make([]byte, 8, 8+len(raw))
+	binary.LittleEndian.PutUint32(record[0:], uint32(depth))
+	binary.LittleEndian.PutUint32(record[4:], uint32(len(raw)))
+	record = append(record, raw...)
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.broken {
+		return errSpillAbandoned
+	}
+	if _, err := self.file.WriteAt(record, self.writeAt); err != nil {
+		return err
+	}
+	self.writeAt += int64(len(record))
+	self.waiting[depth]++
+	return nil
+}
+
+// pop removes the "node" at the front of the queue, returning false if the queue is empty
+func (self *spillQueue) pop() (depth int, raw []byte, ok bool, err error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.readAt >= self.writeAt {
+		return 0, nil, false, nil
+	}
+	header := make([]byte, 8)
+	if _, err := self.file.ReadAt(header, self.readAt); err != nil {
+		return 0, nil, false, err
+	}
+	raw = make([]byte, binary.LittleEndian.Uint32(header[4:]))
+	if _, err := self.file.ReadAt(raw, self.readAt+8); err != nil {
+		return 0, nil, false, err
+	}
+	self.readAt += 8 + int64(len(raw))
+	depth = int(binary.LittleEndian.Uint32(header[0:]))
+	self.waiting[depth]--
+	return depth, raw, true, nil
+}
+
+// abandon empties the queue without reading it (e.g. once it can no longer be read), returning how
+// many "nodes" were still waiting at each depth.  Nothing more may be pushed afterwards.
+func (self *spillQueue) abandon() map[int]int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	waiting := self.waiting
+	self.waiting, self.readAt, self.broken = map[int]int{}, self.writeAt, true
+	return waiting
+}
+
+// close removes the temporary file (if it has not been already)
+func (self *spillQueue) close() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.file.Close()
+	os.Remove(self.file.Name())
+}
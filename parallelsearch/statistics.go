@@ -0,0 +1,93 @@
+package parallelsearch
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Statistics are a detailed account of a parallel search, for tuning it (see CollectStatistics)
+type Statistics struct {
+	Depths     []DepthStatistics
+	PeakQueued int           // The most "nodes" waiting to be searched at once (in memory or spilled)
+	Workers    int           // The size of the pool
+	Busy       time.Duration // The time the workers spent searching, in all
+	Elapsed    time.Duration
+}
+
+// DepthStatistics account for the "nodes" submitted at one depth of a parallel search
+type DepthStatistics struct {
+	Searched   uint64        // Searched (i.e. expanded, if not found)
+	Duplicates uint64        // Skipped as equivalent to one already submitted (see Keyed) or found (see UniqueResults)
+	Dropped    uint64        // Beyond the width limit (see SetWidthLimit)
+	Finished   time.Duration // When the depth was finished, from the start of the search (zero if it wasn't)
+}
+
+// Utilization is the share of the time the workers spent searching (from 0 to 1)
+func (self *Statistics) Utilization() float64 {
+	if self.Workers == 0 || self.Elapsed == 0 {
+		return 0
+	}
+	return float64(self.Busy) / float64(self.Elapsed) / float64(self.Workers)
+}
+
+// statistics are collected by a parallel search as it goes
+type statistics struct {
+	started    time.Time
+	workers    int
+	duplicates []uint64 // By depth
+	dropped    []uint64
+	finished   []int64 // Nanoseconds from the start
+	queued     int64
+	peakQueued int64
+	busy       int64 // Nanoseconds
+	elapsed    int64
+}
+
+// CollectStatistics makes the search account for itself in detail (see Statistics), at some cost in
+// speed.  NOTE: This method should be called before Start.
+func (self *ParallelSearch) CollectStatistics() {
+	depths := len(self.searched)
+	self.stats = &statistics{
+		workers:    len(self.workers),
+		duplicates: make([]uint64, depths),
+		dropped:    make([]uint64, depths),
+		finished:   make([]int64, depths),
+	}
+}
+
+// Statistics accounts for the search so far, or returns nil unless CollectStatistics was called
+func (self *ParallelSearch) Statistics() *Statistics {
+	if self.stats == nil {
+		return nil
+	}
+	elapsed := time.Duration(atomic.LoadInt64(&self.stats.elapsed))
+	if elapsed == 0 {
+		elapsed = time.Since(self.stats.started)
+	}
+	statistics := &Statistics{
+		PeakQueued: int(atomic.LoadInt64(&self.stats.peakQueued)),
+		Workers:    self.stats.workers,
+		Busy:       time.Duration(atomic.LoadInt64(&self.stats.busy)),
+		Elapsed:    elapsed,
+	}
+	for depth := range self.searched {
+		statistics.Depths = append(statistics.Depths, DepthStatistics{
+			Searched:   atomic.LoadUint64(self.searched[depth]),
+			Duplicates: atomic.LoadUint64(&self.stats.duplicates[depth]),
+			Dropped:    atomic.LoadUint64(&self.stats.dropped[depth]),
+			Finished:   time.Duration(atomic.LoadInt64(&self.stats.finished[depth])),
+		})
+	}
+	return statistics
+}
+
+// enqueued counts a "node" waiting to be searched (or, given -1, one no longer waiting)
+func (self *statistics) enqueued(delta int64) {
+	queued := atomic.AddInt64(&self.queued, delta)
+	for {
+		peak := atomic.LoadInt64(&self.peakQueued)
+		if queued <= peak || atomic.CompareAndSwapInt64(&self.peakQueued, peak, queued) {
+			return
+		}
+	}
+}
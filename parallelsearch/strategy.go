@@ -0,0 +1,245 @@
+package parallelsearch
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Heuristic may be implemented by a Searchable to estimate how many more steps are needed to find a
+// result from it.  For a best-first search to find the shallowest results first, the estimate must
+// never be too high.
+type Heuristic interface {
+	Heuristic() int
+}
+
+func estimate(searchable Searchable) int {
+	if heuristic, ok := searchable.(Heuristic); ok {
+		return heuristic.Heuristic()
+	}
+	return 0
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Strategy decides the order in which a StrategySearch expands the "nodes" waiting to be searched.
+// A Strategy need not be safe for concurrent use (StrategySearch holds a lock around it).
+type Strategy interface {
+	Submit(searchable Searchable, depth int)           // Queues a "node" found at the given depth
+	Next() (searchable Searchable, depth int, ok bool) // Takes the next "node" to search (ok is false if none are queued)
+	Len() int                                          // How many "nodes" are queued
+}
+
+// NewBreadthFirstStrategy searches "nodes" in the order they were submitted, so every depth is
+// searched before the next (as ParallelSearch, but without waiting for each depth to finish)
+func NewBreadthFirstStrategy() Strategy {
+	return &breadthFirst{}
+}
+
+// NewDepthFirstStrategy searches the "node" submitted most recently first, diving to the depth limit
+// before backing up.  It queues far fewer "nodes" than the others, but finds the deepest results
+// first rather than the shallowest.
+func NewDepthFirstStrategy() Strategy {
+	return &depthFirst{}
+}
+
+// NewBestFirstStrategy searches the "node" with the lowest depth plus Heuristic first (A*), so that
+// those which look closer to a result are explored first.  Searchables which do not implement
+// Heuristic are searched breadth-first.
+func NewBestFirstStrategy() Strategy {
+	return &bestFirst{}
+}
+
+// NewBeamStrategy searches the shallowest "nodes" first (as NewBreadthFirstStrategy), but searches at
+// most width of them at each depth, keeping those most promising by Heuristic (then by Score, lower
+// being better) of the ones queued at the time.  Unlike the Beam search, depths are not searched one
+// at a time, so which "nodes" are kept depends on how quickly they were found.
+func NewBeamStrategy(width int) Strategy {
+	return &beam{width: width}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type queuedSearchable struct {
+	searchable Searchable
+	depth      int
+	priority   int // depth + estimate (best-first), estimate (beam), or depth (submissions)
+	estimate   int // Or score (beam), or -Priority (submissions)
+	order      uint64
+}
+
+type breadthFirst struct {
+	queue []queuedSearchable
+	head  int
+}
+
+func (self *breadthFirst) Submit(searchable Searchable, depth int) {
+	self.queue = append(self.queue, queuedSearchable{searchable: searchable, depth: depth})
+}
+
+func (self *breadthFirst) Next() (Searchable, int, bool) {
+	if self.Len() == 0 {
+		return nil, 0, false
+	}
+	next := self.queue[self.head]
+	self.queue[self.head] = queuedSearchable{} // Let it be collected once searched
+	if self.head++; self.head == len(self.queue) {
+		self.queue, self.head = self.queue[:0], 0
+	}
+	return next.searchable, next.depth, true
+}
+
+func (self *breadthFirst) Len() int { return len(self.queue) - self.head }
+
+type depthFirst struct {
+	stack []queuedSearchable
+}
+
+func (self *depthFirst) Submit(searchable Searchable, depth int) {
+	self.stack = append(self.stack, queuedSearchable{searchable: searchable, depth: depth})
+}
+
+func (self *depthFirst) Next() (Searchable, int, bool) {
+	if len(self.stack) == 0 {
+		return nil, 0, false
+	}
+	next := self.stack[len(self.stack)-1]
+	self.stack = self.stack[:len(self.stack)-1]
+	return next.searchable, next.depth, true
+}
+
+func (self *depthFirst) Len() int { return len(self.stack) }
+
+type bestFirst struct {
+	queue  priorityQueue
+	queued uint64 // For breaking ties in favor of the oldest
+}
+
+func (self *bestFirst) Submit(searchable Searchable, depth int) {
+	self.queued++
+	estimate := estimate(searchable)
+	heap.Push(&self.queue, &queuedSearchable{searchable, depth, depth + estimate, estimate, self.queued})
+}
+
+func (self *bestFirst) Next() (Searchable, int, bool) {
+	if self.queue.Len() == 0 {
+		return nil, 0, false
+	}
+	next := heap.Pop(&self.queue).(*queuedSearchable)
+	return next.searchable, next.depth, true
+}
+
+func (self *bestFirst) Len() int { return self.queue.Len() }
+
+type beam struct {
+	width    int
+	levels   [][]queuedSearchable // The "nodes" queued at each depth, most promising first
+	searched []int                // How many have been taken from each depth
+	length   int
+}
+
+func (self *beam) Submit(searchable Searchable, depth int) {
+	for len(self.levels) <= depth {
+		self.levels, self.searched = append(self.levels, nil), append(self.searched, 0)
+	}
+	queued := queuedSearchable{searchable, depth, estimate(searchable), searchable.Score(), 0}
+	level := self.levels[depth]
+	i := sort.Search(len(level), func(i int) bool { // After any as promising, which were queued first
+		if level[i].priority != queued.priority {
+			return level[i].priority > queued.priority
+		}
+		return level[i].estimate > queued.estimate
+	})
+	room := self.width - self.searched[depth] // Never more than width searched at a depth
+	if self.width > 0 && i >= room {
+		return
+	}
+	level = append(level, queuedSearchable{})
+	copy(level[i+1:], level[i:])
+	level[i] = queued
+	self.length++
+	if self.width > 0 && len(level) > room {
+		level = level[:room]
+		self.length--
+	}
+	self.levels[depth] = level
+}
+
+func (self *beam) Next() (Searchable, int, bool) {
+	for depth, level := range self.levels {
+		if len(level) > 0 {
+			self.levels[depth], self.searched[depth] = level[1:], self.searched[depth]+1
+			self.length--
+			return level[0].searchable, depth, true
+		}
+	}
+	return nil, 0, false
+}
+
+func (self *beam) Len() int { return self.length }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// submissions holds the "nodes" submitted to a prioritized ParallelSearch (in place of the workers' own
+// queues) until a worker takes them: the shallowest first, then those with the highest Priority, then
+// the oldest
+type submissions struct {
+	mutex     sync.Mutex
+	queue     priorityQueue
+	submitted uint64
+}
+
+func (self *submissions) push(batch []task) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	for _, next := range batch {
+		priority := 0
+		if prioritized, ok := next.searchable.(Prioritized); ok {
+			priority = prioritized.Priority()
+		}
+		self.submitted++
+		heap.Push(&self.queue, &queuedSearchable{next.searchable, next.depth, next.depth, -priority, self.submitted})
+	}
+}
+
+// pop takes the next "node", returning false if none are waiting
+func (self *submissions) pop() (task, bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.queue.Len() == 0 {
+		return task{}, false
+	}
+	next := heap.Pop(&self.queue).(*queuedSearchable)
+	return task{next.searchable, next.depth}, true
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// priorityQueue implements heap.Interface, ordering by priority, then estimate, then age
+type priorityQueue []*queuedSearchable
+
+func (self priorityQueue) Len() int { return len(self) }
+
+func (self priorityQueue) Less(i, j int) bool {
+	a, b := self[i], self[j]
+	if a.priority != b.priority {
+		return a.priority < b.priority
+	}
+	if a.estimate != b.estimate {
+		return a.estimate < b.estimate
+	}
+	return a.order < b.order
+}
+
+func (self priorityQueue) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+func (self *priorityQueue) Push(x interface{}) { *self = append(*self, x.(*queuedSearchable)) }
+
+func (self *priorityQueue) Pop() interface{} {
+	old := *self
+	last := old[len(old)-1]
+	*self = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,169 @@
+package parallelsearch
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// StrategySearch implements a search of a tree of searchable "nodes" in parallel, expanding them in
+// the order its Strategy decides.  Rather than expanding every node at one depth before moving on (as
+// ParallelSearch), each worker simply takes the next node the Strategy offers.
+type StrategySearch struct {
+	poolSize    int
+	depthLimit  int
+	searchLimit int
+	mutex       sync.Mutex
+	ready       *sync.Cond
+	strategy    Strategy
+	busy        int
+	finished    bool
+	visited     map[visit]bool // Nil if keeping duplicates (see KeepDuplicates)
+	found       []Searchable
+	onFound     func(Searchable)
+	searched    uint64
+	closest     *closest
+	workers     sync.WaitGroup
+}
+
+// NewStrategySearch creates a new search, with the same parameters as New plus the order in which to
+// expand "nodes" (see Strategy)
+func NewStrategySearch(poolSize int, depthLimit int, searchLimit int, strategy Strategy) *StrategySearch {
+	ss := &StrategySearch{poolSize: poolSize, depthLimit: depthLimit, searchLimit: searchLimit, strategy: strategy, visited: map[visit]bool{}, closest: newClosest()}
+	ss.ready = sync.NewCond(&ss.mutex)
+	return ss
+}
+
+// NewBestFirst creates a new best-first (A*) search (see NewBestFirstStrategy), with the same
+// parameters as New
+func NewBestFirst(poolSize int, depthLimit int, searchLimit int) *StrategySearch {
+	return NewStrategySearch(poolSize, depthLimit, searchLimit, NewBestFirstStrategy())
+}
+
+// KeepDuplicates makes the search expand every "node", as ParallelSearch.KeepDuplicates.  NOTE: This
+// method should be called before Start.
+func (self *StrategySearch) KeepDuplicates() {
+	self.visited = nil
+}
+
+// Start will initiate a new search with the given starting "node" or "nodes".  If the context is
+// cancelled the search is stopped, and whatever has been found so far is returned by WaitForFound.
+// NOTE: This method should only be called once.
+func (self *StrategySearch) Start(ctx context.Context, searchables ...Searchable) {
+	self.mutex.Lock()
+	for _, searchable := range searchables {
+		self.push(searchable, 0)
+	}
+	self.mutex.Unlock()
+	for i := 0; i < self.poolSize; i++ {
+		self.workers.Add(1)
+		go self.work()
+	}
+
+	done := make(chan bool)
+	go func() {
+		self.workers.Wait()
+		close(done)
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			self.mutex.Lock()
+			self.finished = true
+			self.ready.Broadcast()
+			self.mutex.Unlock()
+		case <-done:
+		}
+	}()
+}
+
+// OnFound registers a function to be called with each result the moment it is found (never
+// concurrently, but from the workers, so it should be quick), as ParallelSearch.OnFound.  NOTE: This
+// method should be called before Start.
+func (self *StrategySearch) OnFound(onFound func(Searchable)) {
+	self.onFound = onFound
+}
+
+// WaitForFound will wait until either we have found searchLimit results or there are no more
+// "nodes" to consider.  Either way the results found (if any) will be sorted by score and returned.
+func (self *StrategySearch) WaitForFound() []Searchable {
+	self.workers.Wait()
+	found := self.found
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Score() > found[j].Score()
+	})
+	return found
+}
+
+// Closest is the most promising "node" searched so far which was not found (see closest), or nil if
+// nothing has been searched
+func (self *StrategySearch) Closest() Searchable {
+	return self.closest.get()
+}
+
+// Searched is the number of "nodes" searched so far
+func (self *StrategySearch) Searched() uint64 {
+	return atomic.LoadUint64(&self.searched)
+}
+
+// push queues a searchable (unless an equivalent one has been queued already).  The mutex must be
+// held.
+func (self *StrategySearch) push(searchable Searchable, depth int) {
+	if keyed, ok := searchable.(Keyed); ok && self.visited != nil {
+		key := visit{depth, keyed.Key()}
+		if self.visited[key] {
+			return
+		}
+		self.visited[key] = true
+	}
+	self.strategy.Submit(searchable, depth)
+}
+
+func (self *StrategySearch) work() {
+	defer self.workers.Done()
+	for {
+		self.mutex.Lock()
+		for self.strategy.Len() == 0 && self.busy > 0 && !self.finished {
+			self.ready.Wait()
+		}
+		searchable, depth, ok := self.strategy.Next()
+		if self.finished || !ok {
+			self.finished = true
+			self.ready.Broadcast()
+			self.mutex.Unlock()
+			return
+		}
+		self.busy++
+		self.mutex.Unlock()
+
+		atomic.AddUint64(&self.searched, 1)
+		found := searchable.IsFound()
+		if !found {
+			self.closest.consider(searchable)
+		}
+		children := []Searchable{}
+		if !found && depth < self.depthLimit {
+			searchable.Search(func(child Searchable) {
+				children = append(children, child)
+			})
+		}
+
+		self.mutex.Lock()
+		if found {
+			self.found = append(self.found, searchable)
+			self.finished = self.finished || len(self.found) >= self.searchLimit
+			if self.onFound != nil {
+				self.onFound(searchable)
+			}
+		}
+		for _, child := range children {
+			self.push(child, depth+1)
+		}
+		self.busy--
+		self.ready.Broadcast()
+		self.mutex.Unlock()
+	}
+}
@@ -0,0 +1,75 @@
+package parallelsearch
+
+import (
+	"sync"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// worker is one of the goroutines of a ParallelSearch, along with the "nodes" it has found which are
+// waiting to be searched, by depth.  A worker searches its own first (oldest first, as they were
+// found), and steals the newer half of another's once it has none at the shallowest depth with any
+// waiting, so that the work is shared out without every "node" passing through one queue.
+type worker struct {
+	id     int
+	mutex  sync.Mutex
+	queues []taskQueue // By depth
+	batch  []task      // Reused for the children of each "node" the worker expands
+}
+
+type taskQueue struct {
+	tasks []task
+	head  int
+}
+
+func (self *taskQueue) len() int { return len(self.tasks) - self.head }
+
+// push adds a batch of "nodes" to the worker's queues
+func (self *worker) push(batch []task) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	for _, next := range batch {
+		for len(self.queues) <= next.depth {
+			self.queues = append(self.queues, taskQueue{})
+		}
+		queue := &self.queues[next.depth]
+		queue.tasks = append(queue.tasks, next)
+	}
+}
+
+// pop takes the "node" the worker queued first at the given depth, returning false if it has none there
+func (self *worker) pop(depth int) (task, bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if depth >= len(self.queues) || self.queues[depth].len() == 0 {
+		return task{}, false
+	}
+	queue := &self.queues[depth]
+	next := queue.tasks[queue.head]
+	queue.tasks[queue.head] = task{} // Let it be collected once searched
+	if queue.head++; queue.head == len(queue.tasks) {
+		queue.tasks, queue.head = queue.tasks[:0], 0
+	}
+	return next, true
+}
+
+// steal moves the newer half of another worker's "nodes" at the given depth to this one, returning
+// the oldest of them to search now, or false if the other worker has none there
+func (self *worker) steal(victim *worker, depth int) (task, bool) {
+	victim.mutex.Lock()
+	if depth >= len(victim.queues) || victim.queues[depth].len() == 0 {
+		victim.mutex.Unlock()
+		return task{}, false
+	}
+	queue := &victim.queues[depth]
+	kept := len(queue.tasks) - (queue.len()+1)/2
+	stolen := append([]task{}, queue.tasks[kept:]...)
+	clear(queue.tasks[kept:])
+	if queue.tasks = queue.tasks[:kept]; queue.head == kept {
+		queue.tasks, queue.head = queue.tasks[:0], 0
+	}
+	victim.mutex.Unlock() // Before pushing, so that two workers stealing from each other never deadlock
+
+	self.push(stolen[1:])
+	return stolen[0], true
+}
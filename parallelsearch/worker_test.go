@@ -0,0 +1,109 @@
+package parallelsearch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStealingUnderContention(t *testing.T) {
+	const workerCount, batches, batchSize = 8, 200, 16
+	workers := make([]*worker, workerCount)
+	for i := range workers {
+		workers[i] = &worker{id: i}
+	}
+	total := int64(workerCount * batches * batchSize)
+	taken := make([][]int, workerCount)
+	remaining := total
+	var wait sync.WaitGroup
+	for i := range workers {
+		wait.Add(1)
+		go func(self *worker) {
+			defer wait.Done()
+			for b := 0; b < batches; b++ { // Each worker pushes its own "nodes" while the others steal them
+				batch := make([]task, batchSize)
+				for j := range batch {
+					batch[j] = task{&node{score: (self.id*batches+b)*batchSize + j}, b % 3}
+				}
+				self.push(batch)
+				if next, ok := self.pop(b % 3); ok {
+					taken[self.id] = append(taken[self.id], next.searchable.Score())
+					atomic.AddInt64(&remaining, -1)
+				}
+			}
+			for atomic.LoadInt64(&remaining) > 0 {
+				for depth := 0; depth < 3; depth++ {
+					next, ok := self.pop(depth)
+					for i := 1; !ok && i < workerCount; i++ {
+						next, ok = self.steal(workers[(self.id+i)%workerCount], depth)
+					}
+					if ok {
+						taken[self.id] = append(taken[self.id], next.searchable.Score())
+						atomic.AddInt64(&remaining, -1)
+					}
+				}
+			}
+		}(workers[i])
+	}
+	wait.Wait()
+
+	seen := make([]int, total)
+	for _, ids := range taken {
+		for _, id := range ids {
+			seen[id]++
+		}
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Errorf("node %d was taken %d times", id, count)
+		}
+	}
+}
+
+// wideTree builds a tree with the given number of children under every "node" but those at the given
+// depth, which are found
+func wideTree(branching int, depth int) *node {
+	root := &node{}
+	if depth > 0 {
+		for i := 0; i < branching; i++ {
+			child := wideTree(branching, depth-1)
+			child.key, child.score = fmt.Sprint(depth, "-", i), i
+			root.children = append(root.children, child)
+		}
+	}
+	return root
+}
+
+func TestParallelSearchSearchesEveryNode(t *testing.T) {
+	root := wideTree(6, 5)
+	ps := New(16, 5, 1<<30)
+	var searched int64
+	ps.Observe(func(Searchable) { atomic.AddInt64(&searched, 1) })
+	ps.Start(context.Background(), root)
+	found, err := ps.WaitForFound()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes := int64(1 + 6 + 36 + 216 + 1296 + 7776); searched != nodes || len(found) != 7776 {
+		t.Errorf("searched %d nodes and found %d, want %d and 7776", searched, len(found), nodes)
+	}
+}
+
+// BenchmarkParallelSearch measures the overhead of sharing out "nodes" between workers (see worker),
+// the nodes themselves taking no time to search
+func BenchmarkParallelSearch(b *testing.B) {
+	root := wideTree(8, 6)
+	for _, poolSize := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprint("workers=", poolSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ps := New(poolSize, 6, 1<<30)
+				ps.Start(context.Background(), root)
+				if _, err := ps.WaitForFound(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
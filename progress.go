@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+	"golang.org/x/term"
+)
+
+// progressBar follows a parallel search on one line of the terminal (on stderr, see logger): the depth
+// being searched, how much of it is done, and an estimate of how many nodes (and how long) remain at
+// that depth.  Each depth is logged as it finishes.  When stderr is not a terminal (or the log is
+// quiet) only these log lines are shown.
+type progressBar struct {
+	ps           *parallelsearch.ParallelSearch
+	mutex        sync.Mutex
+	depthStarted time.Duration // When (since the search started) the depth being searched was started
+	drawn        bool          // Whether the bar is showing on the current line
+	stopped      bool
+}
+
+// showProgress reports on the search until the returned function is called (once the search is over)
+func showProgress(ps *parallelsearch.ParallelSearch) func() {
+	bar := &progressBar{ps: ps}
+	ps.Report(bar.depthFinished)
+	stop := func() {
+		bar.mutex.Lock()
+		defer bar.mutex.Unlock()
+		bar.clear()
+		bar.stopped = true
+	}
+	if !term.IsTerminal(int(os.Stderr.Fd())) || !logging(slog.LevelInfo) {
+		return stop
+	}
+
+	done := make(chan bool)
+	finished := make(chan bool)
+	go func() {
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				bar.draw()
+			case <-done:
+				close(finished)
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+		stop()
+	}
+}
+
+func (self *progressBar) depthFinished(progress parallelsearch.Progress) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.stopped {
+		return
+	}
+	self.clear()
+	if progress.Halted {
+		logger.Info("search halted", "finished", progress.Finished, "nodes", progress.Total(), "elapsed", progress.Elapsed.Round(time.Millisecond), "solutions", progress.Found)
+		return
+	}
+	depth := progress.Finished
+	elapsed := (progress.Elapsed - self.depthStarted).Round(time.Millisecond)
+	logger.Info("depth finished", "depth", depth, "nodes", progress.Searched[depth], "elapsed", elapsed, "solutions", progress.FoundAt[depth])
+	self.depthStarted = progress.Elapsed
+}
+
+func (self *progressBar) draw() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	progress := self.ps.Progress()
+	depth := progress.Finished + 1
+	if self.stopped || depth >= len(progress.Searched) {
+		return
+	}
+	searched, pending := progress.Searched[depth], progress.Pending[depth]
+	width := 0
+	if total := searched + pending; total > 0 {
+		width = int(30 * searched / total)
+	}
+	remaining := "?"
+	if searched > 0 {
+		elapsed := progress.Elapsed - self.depthStarted
+		remaining = (time.Duration(float64(elapsed) * float64(pending) / float64(searched))).Round(time.Second).String()
+	}
+	bar := strings.Repeat("█", width) + strings.Repeat(" ", 30-width)
+	spilled := ""
+	if progress.Spilled > 0 {
+		spilled = fmt.Sprintf(", %d on disk", progress.Spilled)
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[Kdepth %2d |%s| %d nodes searched, %d to go (about %s%s)", depth, bar, searched, pending, remaining, spilled)
+	self.drawn = true
+}
+
+// clear removes the bar from the current line
+func (self *progressBar) clear() {
+	if self.drawn {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		self.drawn = false
+	}
+}
+
+// printProvisional shows a solution the moment the search finds it, before the search is over (so a
+// better one may yet be found), clearing any progress bar from the line first
+func printProvisional(seq *solver.Sequence) {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Print("\r\033[K")
+	}
+	fmt.Println(solver.Colorize("yellow", "PROVISIONAL:"), strings.ToUpper(seq.CommandSequence()), solver.Colorize("gray", "(", seq.Size, " actions, score ", seq.Score(), "; still searching for better)"))
+}
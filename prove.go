@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// proveUnsolvable runs the serial search to exhaustion and prints a certificate-style report showing
+// that every reachable state at every depth was considered, along with the sequences which came
+// closest to the goal.  This lets users trust "impossible" rather than suspect the search gave up.
+// Returns false (after reporting the solution) if the scenario turns out to be solvable after all.
+func proveUnsolvable(start *solver.Sequence) bool {
+	scenario := start.Scenario()
+	fmt.Println()
+	fmt.Println(solver.Colorize("yellow", "UNSOLVABILITY CERTIFICATE"), "for scenario", scenario.Hash())
+
+	total := 0
+	closest := []*solver.Sequence{}
+	frontier := []*solver.Sequence{start}
+	for depth := start.Size; len(frontier) > 0; depth++ {
+		fmt.Printf("\tdepth %2d: %d distinct states\n", depth, len(frontier))
+		total += len(frontier)
+		closest = append(closest, frontier...)
+		sort.SliceStable(closest, func(i, j int) bool {
+			return closest[i].GoalDistance() < closest[j].GoalDistance()
+		})
+		if len(closest) > 3 {
+			closest = closest[:3]
+		}
+
+		var found []*solver.Sequence
+		frontier, found = solver.ExpandLevel(frontier)
+		if len(found) > 0 {
+			fmt.Println(solver.Colorize("red", "NOT PROVEN:"), "the scenario can be solved in", depth, "actions:", found[0].CommandSequence())
+			return false
+		}
+	}
+	fmt.Printf("\tall depths up to %d exhausted; %d distinct states explored, none meets the goal\n", scenario.TotalActions(), total)
+
+	fmt.Println("closest misses:")
+	for _, seq := range closest {
+		fmt.Println("\t", seq.CommandSequence())
+		fmt.Println("\t\t", seq.GoalShortfall())
+	}
+	return true
+}
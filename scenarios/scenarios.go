@@ -0,0 +1,37 @@
+// Package scenarios is a library of the standard Mars Horizon mission minigames, written in YAML
+// shorthand (see example-scenario.yml) and embedded in the solver so that they need not be transcribed
+// by hand
+package scenarios
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed *.yml
+var missions embed.FS
+
+// Names lists the missions in the library, e.g. "mars-landing"
+func Names() []string {
+	entries, err := missions.ReadDir(".")
+	if err != nil {
+		panic(err) // The embedded files are always readable
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Read returns the YAML shorthand of the named mission
+func Read(name string) ([]byte, error) {
+	raw, err := missions.ReadFile(name + ".yml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown mission %q (expected one of %s)", name, strings.Join(Names(), ", "))
+	}
+	return raw, nil
+}
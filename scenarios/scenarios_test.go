@@ -0,0 +1,42 @@
+package scenarios_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/scenarios"
+	"github.com/david-mccullars/mars-horizon-mission-solver/shorthand"
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+func TestEveryMissionIsValid(t *testing.T) {
+	names := scenarios.Names()
+	for _, want := range []string{"satellite", "probe", "crewed-orbit", "mars-landing"} {
+		if !strings.Contains(" "+strings.Join(names, " ")+" ", " "+want+" ") {
+			t.Errorf("missions %v do not include %s", names, want)
+		}
+	}
+	for _, name := range names {
+		raw, err := scenarios.Read(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rawJSON, err := shorthand.ToJSON(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		scenario, err := solver.ParseScenario(rawJSON)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, problem := range scenario.Validate() {
+			t.Errorf("%s: %v", name, problem)
+		}
+	}
+}
+
+func TestReadUnknownMission(t *testing.T) {
+	if _, err := scenarios.Read("moon-landing"); err == nil || !strings.Contains(err.Error(), "mars-landing") {
+		t.Errorf("got %v, want an error listing the missions", err)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// schemaCommand implements the "schema" subcommand, writing the JSON Schema of scenario files (see
+// solver.Schema) to stdout, e.g. for editors to check scenarios against as they are written
+func schemaCommand(args []string) {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	flags.Parse(args)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(solver.Schema()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+	"github.com/david-mccullars/mars-horizon-mission-solver/scenarios"
+	"github.com/david-mccullars/mars-horizon-mission-solver/shorthand"
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+//go:embed web/index.html
+var webUI []byte
+
+// serveTimeout is the longest a search requested through the web UI may take
+const serveTimeout = time.Minute
+
+// serveMaxBody is the largest scenario which may be posted to /solve, in bytes
+const serveMaxBody = 1 << 20
+
+// serveLimits bound the scenarios /solve will prepare and search (far beyond any in the game)
+var serveLimits = solver.Limits{Turns: 50, Actions: 250, Commands: 200}
+
+// serveCommand implements the "serve" subcommand, which serves the web UI (see serve)
+func serveCommand(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "address to serve the web UI on (e.g. :8080 for every interface)")
+	verbose := flags.Bool("v", false, "log more detail (to stderr)")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("Usage: ", os.Args[0], " serve [-addr ADDRESS]")
+	}
+	setupLogging(*verbose, false)
+	serve(*addr)
+}
+
+// serve runs the web UI (see web/index.html) on the given address, for players who would rather not
+// use a terminal.  Scenarios are posted to /solve (in YAML shorthand or JSON) and the solutions are
+// returned in the same form as -json prints them, which also makes it an API for other tools (e.g.
+// bots) to use.
+func serve(addr string) {
+	logger.Info("serving the web UI", "addr", addr)
+	log.Fatal(http.ListenAndServe(addr, newServeMux()))
+}
+
+// newServeMux routes the requests of the web UI (see serve)
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webUI)
+	})
+	mux.HandleFunc("/missions", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, scenarios.Names())
+	})
+	mux.HandleFunc("/missions/", func(w http.ResponseWriter, r *http.Request) {
+		raw, err := scenarios.Read(strings.TrimPrefix(r.URL.Path, "/missions/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/yaml; charset=utf-8")
+		w.Write(raw)
+	})
+	mux.HandleFunc("/solve", serveSolve)
+	return mux
+}
+
+// serveSolve solves the posted scenario, replying with its goal and solutions (or the problems with
+// it).  Clients which accept application/x-ndjson or text/event-stream are also sent the progress of
+// the search as each depth is finished, as a stream of events ending with the solutions.
+func serveSolve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "scenarios must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+	stream := newEventStream(w, r.Header.Get("Accept"))
+	defer stream.close()
+	source, err := io.ReadAll(http.MaxBytesReader(w, r.Body, serveMaxBody))
+	if err != nil {
+		stream.send(http.StatusRequestEntityTooLarge, "error", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	scenario, err := parseScenarioSource(source)
+	if err != nil {
+		stream.send(http.StatusBadRequest, "error", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	if problems := scenario.Validate(); len(problems) > 0 {
+		lines := shorthand.Lines(source)
+		messages := []string{}
+		for _, problem := range problems {
+			if line, ok := lines[problem.Key]; ok {
+				messages = append(messages, fmt.Sprint("line ", line, ": ", problem))
+			} else {
+				messages = append(messages, problem.Error())
+			}
+		}
+		stream.send(http.StatusBadRequest, "error", map[string]interface{}{"error": strings.Join(messages, "\n")})
+		return
+	}
+
+	if scenario.Maximize != "" { // Maximizing ignores the timeout (see solver.Options.Context)
+		stream.send(http.StatusBadRequest, "error", map[string]interface{}{"error": "maximize is not supported here (solve it from the command line instead)"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), serveTimeout)
+	defer cancel()
+	opts := solver.Options{Context: ctx}
+	settings := opts.Tune(solver.StartSequence(scenario))
+	if settings.Engine == "serial" {
+		settings.Engine = "parallel" // The serial engine ignores the timeout
+	}
+	opts.Tuned = &settings
+	if stream.streaming {
+		opts.OnParallelSearch = func(ps *parallelsearch.ParallelSearch) func() {
+			ps.Report(func(progress parallelsearch.Progress) {
+				stream.send(http.StatusOK, "progress", map[string]interface{}{
+					"depth":    progress.Finished,
+					"searched": progress.Total(),
+					"found":    progress.Found,
+					"halted":   progress.Halted,
+				})
+			})
+			return func() {}
+		}
+	}
+	results, err := solver.Solve(scenario, opts)
+	if err != nil {
+		stream.send(http.StatusBadRequest, "error", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	reports := []solutionJSON{}
+	for _, result := range results {
+		reports = append(reports, newSolutionJSON(result.Sequence))
+	}
+	stream.send(http.StatusOK, "solutions", map[string]interface{}{
+		"start":       scenario.Start,
+		"goal":        scenario.Goal,
+		"goal_any_of": scenario.GoalAnyOf,
+		"solutions":   reports,
+		"timed_out":   errors.Is(ctx.Err(), context.DeadlineExceeded), // Rather than the client going away
+	})
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// eventStream replies with a single JSON document or, if the client accepts them, a stream of events
+// as server-sent events or newline-delimited JSON (in which each event's name is given by its "event")
+type eventStream struct {
+	w         http.ResponseWriter
+	sse       bool
+	streaming bool
+	mutex     sync.Mutex
+	started   bool
+	closed    bool // Events sent after the reply is over (e.g. by a search left running) are dropped
+}
+
+func newEventStream(w http.ResponseWriter, accept string) *eventStream {
+	stream := &eventStream{w: w}
+	stream.sse = strings.Contains(accept, "text/event-stream")
+	stream.streaming = stream.sse || strings.Contains(accept, "application/x-ndjson")
+	return stream
+}
+
+// send writes an event.  The status is only used by the first event sent.
+func (self *eventStream) send(status int, event string, payload map[string]interface{}) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.closed {
+		return
+	}
+	if !self.streaming {
+		writeJSON(self.w, status, payload)
+		return
+	}
+	if !self.started {
+		if self.sse {
+			self.w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			self.w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+		self.w.WriteHeader(status)
+		self.started = true
+	}
+	if !self.sse {
+		payload["event"] = event
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if self.sse {
+		fmt.Fprintf(self.w, "event: %s\ndata: %s\n\n", event, raw)
+	} else {
+		fmt.Fprintf(self.w, "%s\n", raw)
+	}
+	if flusher, ok := self.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (self *eventStream) close() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.closed = true
+}
+
+// parseScenarioSource reads a scenario written in JSON (if it starts with "{") or YAML shorthand, no
+// larger than serveLimits
+func parseScenarioSource(source []byte) (*solver.Scenario, error) {
+	rawJSON := source
+	if !bytes.HasPrefix(bytes.TrimSpace(source), []byte("{")) {
+		var err error
+		if rawJSON, err = shorthand.ToJSON(source); err != nil {
+			return nil, err
+		}
+	}
+	return solver.ParseScenarioWithin(rawJSON, serveLimits)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
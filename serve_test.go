@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// request sends a request to the web UI, returning the response
+func request(t *testing.T, method string, path string, body string, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	newServeMux().ServeHTTP(w, r)
+	return w
+}
+
+func TestServeWebUI(t *testing.T) {
+	if w := request(t, "GET", "/", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<title>Mars Horizon Mission Solver</title>") {
+		t.Errorf("GET / replied %d: %.100s", w.Code, w.Body)
+	}
+	if w := request(t, "GET", "/nowhere", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET /nowhere replied %d", w.Code)
+	}
+
+	missions := []string{}
+	w := request(t, "GET", "/missions", "", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &missions); err != nil || !strings.Contains(strings.Join(missions, " "), "probe") {
+		t.Errorf("GET /missions replied %d: %s", w.Code, w.Body)
+	}
+	if w := request(t, "GET", "/missions/probe", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "commands:") {
+		t.Errorf("GET /missions/probe replied %d: %.100s", w.Code, w.Body)
+	}
+	if w := request(t, "GET", "/missions/moon", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET /missions/moon replied %d", w.Code)
+	}
+}
+
+func TestServeSolve(t *testing.T) {
+	scenario, err := os.ReadFile("example-scenario.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := request(t, "POST", "/solve", string(scenario), "")
+	reply := struct {
+		Solutions []solutionJSON
+		TimedOut  bool `json:"timed_out"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &reply); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("POST /solve replied %d: %s", w.Code, w.Body)
+	}
+	if len(reply.Solutions) == 0 || reply.TimedOut || reply.Solutions[0].Actions == 0 {
+		t.Errorf("POST /solve replied %s", w.Body)
+	}
+
+	if w := request(t, "GET", "/solve", "", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /solve replied %d", w.Code)
+	}
+	if w := request(t, "POST", "/solve", "turns: 0\nactions_per_turn: 1\nstart: w\ngoal: r\ncommands:\n  srt: w r\n", ""); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "line 1") {
+		t.Errorf("POST /solve of a scenario without turns replied %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestServeSolveStreams(t *testing.T) {
+	scenario, err := os.ReadFile("example-scenario.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := request(t, "POST", "/solve", string(scenario), "application/x-ndjson")
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("streamed as %s", contentType)
+	}
+	events := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		event := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("%v in %q", err, line)
+		}
+		events = append(events, event["event"].(string))
+	}
+	if len(events) < 2 || events[0] != "progress" || events[len(events)-1] != "solutions" {
+		t.Errorf("streamed events %v, want progress ending with the solutions", events)
+	}
+
+	w = request(t, "POST", "/solve", string(scenario), "text/event-stream")
+	body := w.Body.String()
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("streamed as %s", contentType)
+	}
+	if !strings.HasPrefix(body, "event: progress\ndata: {") || !strings.Contains(body, "\n\nevent: solutions\ndata: {") {
+		t.Errorf("streamed %.200s", body)
+	}
+}
@@ -0,0 +1,101 @@
+package shorthand
+
+import (
+	"errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// mergedKeys are the top-level keys whose entries are merged one by one rather than replaced whole
+var mergedKeys = map[string]bool{"commands": true, "crew": true}
+
+// Extends finds the file which a scenario (in YAML shorthand) extends, e.g. "extends: lander.yml", or
+// "" if it extends none.  Missions flown with the same vehicle can so share its commands and turn
+// costs, each giving only what differs (see Merge).
+func Extends(rawYAML []byte) (string, error) {
+	document, err := parseDocument(rawYAML)
+	if err != nil {
+		return "", err
+	}
+	base := mappingValue(document.Content[0], "extends")
+	if base == nil {
+		return "", nil
+	} else if base.Kind != yaml.ScalarNode || base.Value == "" {
+		return "", errors.New("extends must be the path of another scenario")
+	}
+	return base.Value, nil
+}
+
+// Merge overlays a scenario (in YAML shorthand) on the base it extends: every top-level key it gives
+// replaces the base's, save for commands and crew, which are merged by name (one given as null, e.g.
+// "srt: ~", is removed).  The extends key itself is dropped.
+func Merge(rawBase []byte, rawYAML []byte) ([]byte, error) {
+	base, err := parseDocument(rawBase)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := parseDocument(rawYAML)
+	if err != nil {
+		return nil, err
+	}
+	merged := base.Content[0]
+	removeKey(merged, "extends") // The base's own base has already been merged into it
+	content := overrides.Content[0].Content
+	for i := 0; i+1 < len(content); i += 2 {
+		key, value := content[i], content[i+1]
+		if key.Value == "extends" {
+			continue
+		}
+		if existing := mappingValue(merged, key.Value); mergedKeys[key.Value] && existing != nil && existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeMappings(existing, value)
+		} else {
+			setKey(merged, key, value)
+		}
+	}
+	return yaml.Marshal(base)
+}
+
+// parseDocument parses a scenario, which must be a mapping
+func parseDocument(rawYAML []byte) (*yaml.Node, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(rawYAML, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("a scenario must be a mapping of keys to values")
+	}
+	return &document, nil
+}
+
+// mergeMappings sets (or with a null value, removes) each entry of overrides in the mapping
+func mergeMappings(mapping *yaml.Node, overrides *yaml.Node) {
+	for i := 0; i+1 < len(overrides.Content); i += 2 {
+		if key, value := overrides.Content[i], overrides.Content[i+1]; value.Tag == "!!null" {
+			removeKey(mapping, key.Value)
+		} else {
+			setKey(mapping, key, value)
+		}
+	}
+}
+
+// setKey replaces the value of a key in a mapping, or adds the key if it has none
+func setKey(mapping *yaml.Node, key *yaml.Node, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key.Value {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, key, value)
+}
+
+func removeKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package shorthand
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is a mistake in a piece of shorthand, found at a (1-based) column of it
+type SyntaxError struct {
+	Text    string
+	Column  int
+	Message string
+}
+
+func (self *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at column %d of %q", self.Message, self.Column, self.Text)
+}
+
+// term is a quantity of one resource as written in shorthand, e.g. "-2d"
+type term struct {
+	quantity int
+	resource string // As named in scenario files
+	column   int
+}
+
+// lex splits shorthand into its terms, each an optional sign and quantity (1 if left out) followed by
+// the letter of a resource (see resourceLetters) in either case.  New resources only need a letter.
+func lex(text string) ([]term, error) {
+	terms := []term{}
+	for i := 0; i < len(text); {
+		start, sign := i, 1
+		if text[i] == '-' {
+			sign, i = -1, i+1
+		}
+		digits := i
+		for i < len(text) && text[i] >= '0' && text[i] <= '9' {
+			i++
+		}
+		quantity := 1
+		if i > digits {
+			var err error
+			if quantity, err = strconv.Atoi(text[digits:i]); err != nil {
+				return nil, &SyntaxError{text, digits + 1, "quantity out of range"}
+			}
+		}
+		if i == len(text) {
+			return nil, &SyntaxError{text, i + 1, fmt.Sprintf("missing the resource after %q", text[start:i])}
+		}
+		resource, ok := resourceLetters[strings.ToLower(text[i:i+1])]
+		if !ok {
+			return nil, &SyntaxError{text, i + 1, fmt.Sprintf("unknown resource %q", text[i:i+1])}
+		}
+		terms = append(terms, term{sign * quantity, resource, start + 1})
+		i++
+	}
+	return terms, nil
+}
+
+// splitCommand separates the input and output of a command written in shorthand, either as
+// "INPUT -> OUTPUT" or "INPUT OUTPUT" (either of which may be left out, as in "-> OUTPUT" or just
+// "OUTPUT")
+func splitCommand(text string) (input string, output string, err error) {
+	if arrow := strings.Index(text, "->"); arrow >= 0 {
+		input, output = strings.TrimSpace(text[:arrow]), strings.TrimSpace(text[arrow+2:])
+		if again := strings.Index(output, "->"); again >= 0 {
+			return "", "", &SyntaxError{text, len(text) - len(output) + again + 1, "more than one ->"}
+		}
+		return input, output, nil
+	}
+	fields := strings.Fields(text)
+	switch len(fields) {
+	case 0:
+		return "", "", nil
+	case 1:
+		return "", fields[0], nil
+	case 2:
+		return fields[0], fields[1], nil
+	}
+	return "", "", &SyntaxError{text, strings.Index(text, fields[2]) + 1, "expected just an input and an output"}
+}
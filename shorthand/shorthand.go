@@ -0,0 +1,529 @@
+package shorthand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Resources are written in shorthand as a quantity (default 1) followed by a letter for each
+// resource, e.g. "4w2r-1d" is 4 power, 2 comm and -1 drift (see lex).
+var resourceLetters = map[string]string{
+	"c": "crew",
+	"r": "comm",  // r = red
+	"b": "data",  // b = blue
+	"p": "nav",   // p = purple
+	"w": "power", // w for "watt"
+	"d": "drift",
+	"h": "heat",
+	"t": "thrust",
+	"x": "radiation", // x for "x-rays"
+}
+
+// Placeholders for resources without a turn-end bound (as historically written by the Ruby
+// scenario_from_shorthand script)
+var (
+	noLowerBound = uniform(math.MinInt64)
+	noUpperBound = uniform(1 << 62)
+)
+
+func uniform(value int) map[string]int {
+	resources := map[string]int{}
+	for _, name := range resourceLetters {
+		resources[name] = value
+	}
+	return resources
+}
+
+// ToResources expands shorthand such as "4w2r" into a map of resource names to quantities.  Any
+// resource mentioned replaces its value in base (which may be nil).
+func ToResources(shorthand string, base map[string]int) (map[string]int, error) {
+	terms, err := lex(shorthand)
+	if err != nil {
+		return nil, err
+	}
+	mentioned := map[string]int{}
+	for _, term := range terms {
+		mentioned[term.resource] += term.quantity
+	}
+	resources := map[string]int{}
+	for name, value := range base {
+		resources[name] = value
+	}
+	for name, value := range mentioned {
+		resources[name] = value
+	}
+	return resources, nil
+}
+
+// Abbreviate rewrites resources written with their names in full as shorthand, e.g. "4 power 2 comm"
+// as "4w2r" (and leaves shorthand as it is), for those who have yet to learn the letters
+func Abbreviate(text string) string {
+	letters := map[string]string{}
+	for letter, name := range resourceLetters {
+		letters[name] = letter
+	}
+	abbreviated := ""
+	for _, word := range strings.Fields(text) {
+		name := strings.TrimLeft(word, "-0123456789")
+		if letter, ok := letters[strings.ToLower(name)]; ok {
+			word = word[:len(word)-len(name)] + letter
+		}
+		abbreviated += word
+	}
+	return abbreviated
+}
+
+// ResourceNames lists the name of every resource with a letter, in alphabetical order
+func ResourceNames() []string {
+	names := []string{}
+	for _, name := range resourceLetters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ToJSON converts a scenario written in YAML shorthand (see example-scenario.yml) into the JSON the
+// solver reads.  Keys other than those below are passed through unchanged.
+func ToJSON(rawYAML []byte) ([]byte, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(rawYAML, &document); err != nil {
+		return nil, err
+	}
+	scenario := map[string]interface{}{}
+	if err := document.Decode(&scenario); err != nil {
+		return nil, err
+	}
+
+	_, hasGoalAnyOf := scenario["goal_any_of"]
+	for _, section := range []struct {
+		key      string
+		required bool
+		base     map[string]int
+		omitted  bool // Left out of the scenario unless given
+	}{
+		{"start", true, nil, false},
+		{"goal", !hasGoalAnyOf, nil, false},
+		{"turn_cost", false, nil, false},
+		{"turn_must_end_above", false, noLowerBound, false},
+		{"turn_must_end_below", false, noUpperBound, false},
+		{"turn_end_min", false, noLowerBound, true},
+		{"turn_end_max", false, noUpperBound, true},
+		{"goal_min", false, noLowerBound, true},
+		{"goal_max", false, noUpperBound, true},
+		{"caps", false, noUpperBound, true},
+		{"fail_above", false, noUpperBound, true},
+	} {
+		value, ok := scenario[section.key]
+		if !ok && section.required {
+			return nil, fmt.Errorf("missing %s", section.key)
+		} else if !ok && section.omitted {
+			continue
+		}
+		resources, err := ToResources(scalar(value), section.base)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", mappingValue(document.Content[0], section.key).Line, section.key, err)
+		}
+		scenario[section.key] = resources
+	}
+
+	if node := mappingValue(document.Content[0], "goal_any_of"); node != nil {
+		goals, err := toGoals(node)
+		if err != nil {
+			return nil, err
+		}
+		scenario["goal_any_of"] = goals
+	}
+	if node := mappingValue(document.Content[0], "secondary_goals"); node != nil {
+		goals, err := toSecondaryGoals(node)
+		if err != nil {
+			return nil, err
+		}
+		scenario["secondary_goals"] = goals
+	}
+	commands, err := toCommands(mappingValue(document.Content[0], "commands"))
+	if err != nil {
+		return nil, err
+	}
+	scenario["commands"] = commands
+	if node := mappingValue(document.Content[0], "events"); node != nil {
+		events, err := toEvents(node)
+		if err != nil {
+			return nil, err
+		}
+		scenario["events"] = events
+	}
+	if node := mappingValue(document.Content[0], "stages"); node != nil {
+		stages, err := toStages(node)
+		if err != nil {
+			return nil, err
+		}
+		scenario["stages"] = stages
+	}
+	if node := mappingValue(document.Content[0], "crew"); node != nil {
+		crew, err := toCrew(node)
+		if err != nil {
+			return nil, err
+		}
+		scenario["crew"] = crew
+	}
+	return json.Marshal(scenario)
+}
+
+// Lines finds the line on which each top-level key of a scenario (in YAML shorthand, or JSON) is
+// written, as well as each command (e.g. "commands.gcc") and crew member (e.g. "crew.aldrin").  Nothing is found if the scenario can't be
+// parsed.
+func Lines(rawYAML []byte) map[string]int {
+	lines := map[string]int{}
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(rawYAML, &document); err != nil || len(document.Content) == 0 {
+		return lines
+	}
+	mapping := document.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return lines
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		lines[key.Value] = key.Line
+		if key.Value == "commands" || key.Value == "crew" {
+			if value.Kind == yaml.MappingNode {
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					lines[key.Value+"."+value.Content[j].Value] = value.Content[j].Line
+				}
+			}
+			if value.Kind == yaml.SequenceNode { // As expanded in JSON
+				for _, named := range value.Content {
+					if name := mappingValue(named, "name"); name != nil {
+						lines[key.Value+"."+name.Value] = named.Line
+					}
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// scalar renders a YAML scalar (or nothing at all) as a string
+func scalar(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// mappingValue finds the value of a key in a YAML mapping, returning nil if there is none
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// toGoals converts a list of alternative goals, each written in shorthand (e.g. "[4b, 3r]")
+func toGoals(list *yaml.Node) ([]map[string]int, error) {
+	if list.Kind != yaml.SequenceNode {
+		return nil, errors.New("goal_any_of must be a list")
+	}
+	goals := []map[string]int{}
+	for i, value := range list.Content {
+		goal, err := ToResources(value.Value, nil)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: goal_any_of %d: %v", value.Line, i+1, err)
+		}
+		goals = append(goals, goal)
+	}
+	return goals, nil
+}
+
+type secondaryGoal struct {
+	Name       string         `json:"name"`
+	Goal       map[string]int `json:"goal"`
+	Conditions []string       `json:"conditions,omitempty"`
+	Reward     int            `json:"reward,omitempty"`
+}
+
+// toSecondaryGoals converts a list of bonus objectives, each a mapping with a name, a goal, and
+// optionally conditions and a reward (e.g. "{name: spare data, goal: 6b, conditions: [heat <= 2]}")
+func toSecondaryGoals(list *yaml.Node) ([]*secondaryGoal, error) {
+	if list.Kind != yaml.SequenceNode {
+		return nil, errors.New("secondary_goals must be a list")
+	}
+	goals := []*secondaryGoal{}
+	for i, value := range list.Content {
+		details := struct {
+			Name       string
+			Goal       string
+			Conditions []string
+			Reward     int
+		}{}
+		if err := decodeStrict(value, &details); err != nil {
+			return nil, fmt.Errorf("line %d: secondary goal %d: %v", value.Line, i+1, err)
+		}
+		goal, err := ToResources(details.Goal, nil)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: secondary goal %d: %v", value.Line, i+1, err)
+		}
+		if details.Name == "" {
+			details.Name = fmt.Sprint("bonus ", i+1)
+		}
+		goals = append(goals, &secondaryGoal{details.Name, goal, details.Conditions, details.Reward})
+	}
+	return goals, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type event struct {
+	Name  string         `json:"name,omitempty"`
+	Turn  int            `json:"turn"`
+	Delta map[string]int `json:"delta"`
+}
+
+// toEvents converts a list of events, each a mapping with a turn, a delta, and optionally a name (e.g.
+// "{name: solar flare, turn: 3, delta: 2x}")
+func toEvents(list *yaml.Node) ([]*event, error) {
+	if list.Kind != yaml.SequenceNode {
+		return nil, errors.New("events must be a list")
+	}
+	events := []*event{}
+	for i, value := range list.Content {
+		details := struct {
+			Name  string
+			Turn  int
+			Delta string
+		}{}
+		if err := decodeStrict(value, &details); err != nil {
+			return nil, fmt.Errorf("line %d: event %d: %v", value.Line, i+1, err)
+		}
+		delta, err := ToResources(details.Delta, nil)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: event %d: %v", value.Line, i+1, err)
+		}
+		events = append(events, &event{details.Name, details.Turn, delta})
+	}
+	return events, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type stage struct {
+	Name           string         `json:"name,omitempty"`
+	Turns          int            `json:"turns"`
+	ActionsPerTurn int            `json:"actions_per_turn,omitempty"`
+	Goal           map[string]int `json:"goal"`
+	Commands       []string       `json:"commands,omitempty"`
+	TurnCost       map[string]int `json:"turn_cost,omitempty"`
+}
+
+// toStages converts a list of stages, each a mapping with turns and optionally a name,
+// actions_per_turn, goal, the names of the commands it offers, and a turn_cost (e.g. "{name: launch,
+// turns: 2, goal: 5t, commands: [burn, vent], turn_cost: 2h}")
+func toStages(list *yaml.Node) ([]*stage, error) {
+	if list.Kind != yaml.SequenceNode {
+		return nil, errors.New("stages must be a list")
+	}
+	stages := []*stage{}
+	for i, value := range list.Content {
+		details := struct {
+			Name           string
+			Turns          int
+			ActionsPerTurn int `yaml:"actions_per_turn"`
+			Goal           string
+			Commands       []string
+			TurnCost       *string `yaml:"turn_cost"`
+		}{}
+		if err := decodeStrict(value, &details); err != nil {
+			return nil, fmt.Errorf("line %d: stage %d: %v", value.Line, i+1, err)
+		}
+		goal, err := ToResources(details.Goal, nil)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: stage %d goal: %v", value.Line, i+1, err)
+		}
+		s := stage{details.Name, details.Turns, details.ActionsPerTurn, goal, details.Commands, nil}
+		if details.TurnCost != nil {
+			if s.TurnCost, err = ToResources(*details.TurnCost, map[string]int{}); err != nil {
+				return nil, fmt.Errorf("line %d: stage %d turn_cost: %v", value.Line, i+1, err)
+			}
+		}
+		stages = append(stages, &s)
+	}
+	return stages, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type crewMember struct {
+	Name  string         `json:"name"`
+	Bonus map[string]int `json:"bonus"`
+}
+
+// toCrew converts the crew, a mapping of each crew member's name to the bonus they add to the output
+// of every action they perform (e.g. "{aldrin: p, ride: 2r}")
+func toCrew(mapping *yaml.Node) ([]*crewMember, error) {
+	if mapping.Kind != yaml.MappingNode {
+		return nil, errors.New("crew must be a mapping of names to bonuses")
+	}
+	crew := []*crewMember{}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name := mapping.Content[i].Value
+		bonus, err := ToResources(mapping.Content[i+1].Value, nil)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: crew %s: %v", mapping.Content[i].Line, name, err)
+		}
+		crew = append(crew, &crewMember{name, bonus})
+	}
+	return crew, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type command struct {
+	Name        string         `json:"name"`
+	Input       map[string]int `json:"input"`
+	Output      map[string]int `json:"output"`
+	Category    string         `json:"category,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	Risky       bool           `json:"risky,omitempty"`
+	FailureRate float64        `json:"failure_rate,omitempty"`
+	Bonus       *bonus         `json:"bonus,omitempty"`
+	MaxPerTurn  int            `json:"max_uses_per_turn,omitempty"`
+	MaxTotal    int            `json:"max_uses_total,omitempty"`
+	FromTurn    int            `json:"available_from_turn,omitempty"`
+	UntilTurn   int            `json:"available_until_turn,omitempty"`
+	Scale       *scale         `json:"scale,omitempty"`
+	CrewLock    int            `json:"crew_lock,omitempty"`
+}
+
+type scale struct {
+	Min int `json:"min" yaml:"min"`
+	Max int `json:"max" yaml:"max"`
+}
+
+type bonus struct {
+	After  string         `json:"after"`
+	Output map[string]int `json:"output"`
+}
+
+// toCommands converts each command, written either as "INPUT -> OUTPUT" (see splitCommand) or as a
+// mapping with input, output, and optionally category, description, icon, risky, failure_rate, a bonus
+// (e.g. "bonus: {after: transmit, output: d}"), max_uses_per_turn, max_uses_total, available_from_turn,
+// available_until_turn, the multiples it may be taken at (e.g. "scale: {min: 1, max: 3}") and the
+// turns for which the crew it spends stays exhausted (crew_lock, negative for the rest of the mission)
+func toCommands(mapping *yaml.Node) ([]*command, error) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, errors.New("missing commands")
+	}
+	commands := []*command{}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		c := command{Name: mapping.Content[i].Value}
+		line := mapping.Content[i].Line
+		input, output := "", ""
+		if value := mapping.Content[i+1]; value.Kind == yaml.MappingNode {
+			details := struct {
+				Input       string
+				Output      string
+				Category    string
+				Description string
+				Icon        string
+				Risky       bool
+				FailureRate float64 `yaml:"failure_rate"`
+				Bonus       *struct {
+					After  string
+					Output string
+				}
+				MaxPerTurn int `yaml:"max_uses_per_turn"`
+				MaxTotal   int `yaml:"max_uses_total"`
+				FromTurn   int `yaml:"available_from_turn"`
+				UntilTurn  int `yaml:"available_until_turn"`
+				Scale      *scale
+				CrewLock   int `yaml:"crew_lock"`
+			}{}
+			if err := decodeStrict(value, &details); err != nil {
+				return nil, fmt.Errorf("line %d: command %s: %v", line, c.Name, err)
+			}
+			input, output = details.Input, details.Output
+			c.Category, c.Description, c.Icon, c.Risky = details.Category, details.Description, details.Icon, details.Risky
+			c.FailureRate = details.FailureRate
+			c.MaxPerTurn, c.MaxTotal = details.MaxPerTurn, details.MaxTotal
+			c.FromTurn, c.UntilTurn, c.Scale = details.FromTurn, details.UntilTurn, details.Scale
+			c.CrewLock = details.CrewLock
+			if details.Bonus != nil {
+				output, err := ToResources(details.Bonus.Output, nil)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: command %s bonus: %v", line, c.Name, err)
+				}
+				c.Bonus = &bonus{details.Bonus.After, output}
+			}
+		} else {
+			var err error
+			if input, output, err = splitCommand(value.Value); err != nil {
+				return nil, fmt.Errorf("line %d: command %s: %v", line, c.Name, err)
+			}
+		}
+		var err error
+		if c.Input, err = ToResources(input, nil); err != nil {
+			return nil, fmt.Errorf("line %d: command %s input: %v", line, c.Name, err)
+		}
+		if c.Output, err = ToResources(output, nil); err != nil {
+			return nil, fmt.Errorf("line %d: command %s output: %v", line, c.Name, err)
+		}
+		commands = append(commands, &c)
+	}
+	prioritize(commands)
+	return commands, nil
+}
+
+// prioritize orders commands so that the most productive are tried first: "dt" first of all and
+// "power" (which merely trades time for power) ahead of anything whose output is worth less than
+// its input
+func prioritize(commands []*command) {
+	priority := func(c *command) float64 {
+		switch c.Name {
+		case "power":
+			return 0
+		case "dt":
+			return -1000
+		}
+		names := map[string]bool{}
+		for _, resources := range []map[string]int{c.Input, c.Output} {
+			for name := range resources {
+				names[name] = name != "heat" && name != "drift" && name != "crew"
+			}
+		}
+		o, i := 0.0, 0.0
+		for name, counted := range names {
+			if counted {
+				o += float64(c.Output[name])
+				i += float64(c.Input[name])
+			}
+		}
+		i += float64(c.Input["power"]) // Penalize power use
+		priority := -100*(o/i) - o
+		if math.IsNaN(priority) {
+			return 0
+		}
+		return priority
+	}
+	sort.SliceStable(commands, func(a, b int) bool {
+		return priority(commands[a]) < priority(commands[b])
+	})
+}
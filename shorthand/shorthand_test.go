@@ -0,0 +1,192 @@
+package shorthand
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToResources(t *testing.T) {
+	for _, test := range []struct {
+		shorthand string
+		base      map[string]int
+		want      map[string]int
+	}{
+		{"", nil, map[string]int{}},
+		{"w", nil, map[string]int{"power": 1}},
+		{"4w2r", nil, map[string]int{"power": 4, "comm": 2}},
+		{"4W2R", nil, map[string]int{"power": 4, "comm": 2}},
+		{"-1d3b", nil, map[string]int{"drift": -1, "data": 3}},
+		{"2p1p", nil, map[string]int{"nav": 3}},
+		{"c10h-x", nil, map[string]int{"crew": 1, "heat": 10, "radiation": -1}},
+		{"2t", map[string]int{"thrust": 5, "heat": 4}, map[string]int{"thrust": 2, "heat": 4}},
+	} {
+		got, err := ToResources(test.shorthand, test.base)
+		if err != nil {
+			t.Errorf("ToResources(%q): %v", test.shorthand, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ToResources(%q) = %v, want %v", test.shorthand, got, test.want)
+		}
+	}
+}
+
+func TestToResourcesErrors(t *testing.T) {
+	for _, test := range []struct {
+		shorthand string
+		column    int
+		message   string
+	}{
+		{"4", 2, `missing the resource after "4"`},
+		{"4w-", 4, `missing the resource after "-"`},
+		{"4q", 2, `unknown resource "q"`},
+		{"2r 3b", 3, `unknown resource " "`},
+		{"99999999999999999999w", 1, "quantity out of range"},
+	} {
+		_, err := ToResources(test.shorthand, nil)
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) {
+			t.Errorf("ToResources(%q) = %v, want a SyntaxError", test.shorthand, err)
+		} else if syntax.Column != test.column || syntax.Message != test.message {
+			t.Errorf("ToResources(%q) = %q at column %d, want %q at column %d", test.shorthand, syntax.Message, syntax.Column, test.message, test.column)
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	for _, test := range []struct {
+		text   string
+		input  string
+		output string
+	}{
+		{"", "", ""},
+		{"3r", "", "3r"},
+		{"2w 3r", "2w", "3r"},
+		{"2w -> 3r", "2w", "3r"},
+		{"2w->3r", "2w", "3r"},
+		{"-> 3r", "", "3r"},
+		{"2w ->", "2w", ""},
+	} {
+		input, output, err := splitCommand(test.text)
+		if err != nil {
+			t.Errorf("splitCommand(%q): %v", test.text, err)
+		} else if input != test.input || output != test.output {
+			t.Errorf("splitCommand(%q) = %q, %q, want %q, %q", test.text, input, output, test.input, test.output)
+		}
+	}
+}
+
+func TestSplitCommandErrors(t *testing.T) {
+	for _, test := range []struct {
+		text    string
+		column  int
+		message string
+	}{
+		{"2w -> 3r -> b", 10, "more than one ->"},
+		{"2w 3r b", 7, "expected just an input and an output"},
+	} {
+		_, _, err := splitCommand(test.text)
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) {
+			t.Errorf("splitCommand(%q) = %v, want a SyntaxError", test.text, err)
+		} else if syntax.Column != test.column || syntax.Message != test.message {
+			t.Errorf("splitCommand(%q) = %q at column %d, want %q at column %d", test.text, syntax.Message, syntax.Column, test.message, test.column)
+		}
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	raw, err := ToJSON([]byte(`
+start: 4w
+goal: 3r-1d
+commands:
+  transmit: 2w -> 3r
+  charge: W
+  fix:
+    input: b
+    output: d
+    bonus: {after: transmit, output: r}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenario := struct {
+		Start    map[string]int
+		Goal     map[string]int
+		Commands []struct {
+			Name   string
+			Input  map[string]int
+			Output map[string]int
+			Bonus  *struct {
+				After  string
+				Output map[string]int
+			}
+		}
+	}{}
+	if err := json.Unmarshal(raw, &scenario); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"power": 4}; !reflect.DeepEqual(scenario.Start, want) {
+		t.Errorf("start = %v, want %v", scenario.Start, want)
+	}
+	if want := map[string]int{"comm": 3, "drift": -1}; !reflect.DeepEqual(scenario.Goal, want) {
+		t.Errorf("goal = %v, want %v", scenario.Goal, want)
+	}
+	commands := map[string]string{}
+	for _, command := range scenario.Commands {
+		expanded, _ := json.Marshal([]interface{}{command.Input, command.Output, command.Bonus})
+		commands[command.Name] = string(expanded)
+	}
+	want := map[string]string{
+		"transmit": `[{"power":2},{"comm":3},null]`,
+		"charge":   `[{},{"power":1},null]`,
+		"fix":      `[{"data":1},{"drift":1},{"After":"transmit","Output":{"comm":1}}]`,
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %v, want %v", commands, want)
+	}
+}
+
+func TestToJSONErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"missing start", "goal: r\ncommands: {a: w}", "missing start"},
+		{"missing goal", "start: w\ncommands: {a: w}", "missing goal"},
+		{"missing commands", "start: w\ngoal: r", "missing commands"},
+		{"bad start", "start: 4q\ngoal: r\ncommands: {a: w}", `line 1: start: unknown resource "q" at column 2 of "4q"`},
+		{"bad goal", "start: w\ngoal: 3\ncommands: {a: w}", `line 2: goal: missing the resource after "3" at column 2 of "3"`},
+		{"bad input", "start: w\ngoal: r\ncommands:\n  a: w\n  b: 2z -> r", `line 5: command b input: unknown resource "z" at column 2 of "2z"`},
+		{"bad output", "start: w\ngoal: r\ncommands:\n  a: w -> r-", `line 4: command a output: missing the resource after "-" at column 3 of "r-"`},
+		{"two arrows", "start: w\ngoal: r\ncommands:\n  a: w -> r -> b", `line 4: command a: more than one -> at column 8 of "w -> r -> b"`},
+		{"misspelled key", "start: w\ngoal: r\ncommands:\n  a:\n    inptu: w", `line 4: command a: `},
+	} {
+		_, err := ToJSON([]byte(test.yaml))
+		if err == nil {
+			t.Errorf("%s: no error, want %q", test.name, test.want)
+		} else if !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("%s: %q, want %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	lines := Lines([]byte(`turns: 2
+actions_per_turn: 2
+
+goal: 4r
+commands:
+  srt: w 2r
+  gcc: 2b rrpp
+`))
+	want := map[string]int{"turns": 1, "actions_per_turn": 2, "goal": 4, "commands": 5, "commands.srt": 6, "commands.gcc": 7}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Lines = %v, want %v", lines, want)
+	}
+	if lines := Lines([]byte("turns: [")); len(lines) != 0 {
+		t.Errorf("found lines %v in a scenario which can't be parsed", lines)
+	}
+}
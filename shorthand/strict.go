@@ -0,0 +1,89 @@
+package shorthand
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeStrict decodes a YAML mapping into v (a pointer to a struct) as Decode does, but rejects any
+// key which names none of its fields (at any depth), as it is most likely misspelled
+func decodeStrict(node *yaml.Node, v interface{}) error {
+	if err := checkKeys(node, reflect.TypeOf(v)); err != nil {
+		return err
+	}
+	return node.Decode(v)
+}
+
+// checkKeys finds the first key of a YAML mapping which names none of the fields of a struct (as
+// yaml.v3 names them: by their yaml tag, or else lowercased)
+func checkKeys(node *yaml.Node, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind != yaml.MappingNode || t.Kind() != reflect.Struct {
+		return nil
+	}
+	fields, names := map[string]reflect.Type{}, []string{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+		fields[name], names = t.Field(i).Type, append(names, name)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("unknown key %q%s", key, DidYouMean(key, names))
+		}
+		if err := checkKeys(node.Content[i+1], field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Suggest finds the known name most like a name which is not (most likely misspelled), or "" if none
+// is close enough to be what was meant
+func Suggest(name string, known []string) string {
+	best, fewest := "", len(name)/3+1 // Edits allowed, e.g. 1 for "heta", 4 for "turn_must_end_abve"
+	for _, candidate := range known {
+		if edits := editDistance(strings.ToLower(name), strings.ToLower(candidate)); edits <= fewest && (best == "" || edits < fewest) {
+			best, fewest = candidate, edits
+		}
+	}
+	return best
+}
+
+// DidYouMean suggests (see Suggest) the known name meant by a name which is not, e.g. " (did you mean
+// turn_cost?)", or "" if there is no suggestion
+func DidYouMean(name string, known []string) string {
+	if suggestion := Suggest(name, known); suggestion != "" {
+		return " (did you mean " + suggestion + "?)"
+	}
+	return ""
+}
+
+// editDistance counts the fewest insertions, deletions, and substitutions of bytes which turn a into b
+func editDistance(a string, b string) int {
+	prev, next := make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		next[0] = i
+		for j := 1; j <= len(b); j++ {
+			substitution := prev[j-1]
+			if a[i-1] != b[j-1] {
+				substitution++
+			}
+			next[j] = min(prev[j]+1, next[j-1]+1, substitution)
+		}
+		prev, next = next, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// defaultSolutionCache is where the solutions of past runs are kept unless -cache says otherwise
+// (e.g. ~/.cache/mars-horizon-mission-solver, following XDG_CACHE_HOME), or "" if there is nowhere
+func defaultSolutionCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mars-horizon-mission-solver")
+}
+
+// solutionCacheKey hashes what decides the solutions a run will find: the scenario (normalized by
+// Scenario.Hash, once prepared), where the search starts from (including any actions already taken),
+// and the options of the search (those which marshal, see solver.Options)
+func solutionCacheKey(start *solver.Sequence, opts *solver.Options) string {
+	raw, err := json.Marshal([]interface{}{start, opts})
+	if err != nil {
+		panic(err) // Sequences and options always marshal
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCachedSolutions returns the solutions (best first) which a past run stored under the key, and
+// false if there are none (or they can no longer be replayed against the scenario)
+func loadCachedSolutions(dir string, key string, start *solver.Sequence) ([]*solver.Sequence, bool) {
+	raw, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	rawSequences := []json.RawMessage{}
+	if err := json.Unmarshal(raw, &rawSequences); err != nil {
+		logger.Warn("ignoring unreadable cached solutions", "key", key, "error", err)
+		return nil, false
+	}
+	found := []*solver.Sequence{}
+	for _, rawSequence := range rawSequences {
+		solution := solver.StartSequence(start.Scenario())
+		if err := json.Unmarshal(rawSequence, solution); err != nil {
+			logger.Warn("ignoring unreadable cached solutions", "key", key, "error", err)
+			return nil, false
+		}
+		found = append(found, solution)
+	}
+	return found, true
+}
+
+// storeCachedSolutions saves the solutions (best first) of a completed run under the key
+func storeCachedSolutions(dir string, key string, found []*solver.Sequence) error {
+	raw, err := json.Marshal(found)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, key+".json")
+	if err := os.WriteFile(path+".tmp", raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path) // So that a concurrent run never reads half a file
+}
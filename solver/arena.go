@@ -0,0 +1,125 @@
+package solver
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// arenaBlockSize is how many sequences an arena allocates at once
+const arenaBlockSize = 512
+
+// sequenceArena allocates the sequences of one size (i.e. depth) reached while searching in blocks,
+// rather than one at a time, so deep searches make far fewer allocations (and leave the garbage
+// collector far less to trace).  A block is only freed once none of its sequences are reachable.
+type sequenceArena struct {
+	block atomic.Pointer[sequenceBlock]
+	mutex sync.Mutex // Held only to replace a full block
+}
+
+type sequenceBlock struct {
+	sequences [arenaBlockSize]Sequence
+	used      int32
+}
+
+// new returns an unused sequence from the arena (safe for concurrent use)
+func (self *sequenceArena) new() *Sequence {
+	for {
+		block := self.block.Load()
+		if block != nil {
+			if i := atomic.AddInt32(&block.used, 1) - 1; i < arenaBlockSize {
+				return &block.sequences[i]
+			}
+		}
+		self.mutex.Lock()
+		if self.block.Load() == block {
+			self.block.Store(&sequenceBlock{})
+		}
+		self.mutex.Unlock()
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// search holds what one search (see Solve) keeps for itself rather than on the scenario, which other
+// searches may share.  Every sequence the search reaches points to it.  A nil search (e.g. of the
+// sequences made by StartSequence, or stepped outside a search) allocates sequences one at a time and
+// counts nothing.
+type search struct {
+	canonical bool            // See Options.Canonical
+	arenas    []sequenceArena // The sequences reached, by size (see newSequence), or nil to allocate them alone
+	pruned    []uint64        // Actions ruled out by a constraint, by the size they would have reached (only while profiling, see Options.Profile)
+	order     []int           // The index of each command in the order they are tried, or nil for the scenario's order
+	pruneDead bool            // Skip states known to be dead ends (see componentCache)
+	mutex     sync.Mutex      // Held while recording the states visited
+	visiting  bool            // Record the states visited, to be remembered as dead ends if nothing is found
+	visited   []SearchState   // At most maxDeadStates of them
+	overflown bool            // Set once there were too many states visited to record
+}
+
+// within returns a copy of the sequence which belongs to the given search, as do those reached from it
+func (self *Sequence) within(search *search) *Sequence {
+	within := *self
+	within.search = search
+	return &within
+}
+
+// newSequence returns an unused sequence of the given size, from the search's arena for that size if
+// it has one, otherwise allocating it alone
+func (self *search) newSequence(size uint32) *Sequence {
+	if self != nil && int(size) < len(self.arenas) {
+		return self.arenas[size].new()
+	}
+	return &Sequence{}
+}
+
+// isCanonical is true if the search takes the actions of each turn in a canonical order (see
+// Options.Canonical)
+func (self *search) isCanonical() bool {
+	return self != nil && self.canonical
+}
+
+// finish ends the search, so that sequences stepped from those it reached (e.g. its solutions) are
+// allocated alone, in the scenario's order, without the search's settings or its cache
+func (self *search) finish() {
+	self.canonical, self.arenas, self.order, self.pruneDead = false, nil, nil, false
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.visiting, self.visited = false, nil
+}
+
+// commandAt is the index of the command to be tried the given number of commands into the order
+func (self *search) commandAt(i int) int {
+	if self != nil && self.order != nil {
+		return self.order[i]
+	}
+	return i
+}
+
+// isDeadEnd is true if the sequence is in a state known to be a dead end, if skipping them
+func (self *search) isDeadEnd(seq *Sequence) bool {
+	return self != nil && self.pruneDead && searchCache.isDead(seq.scenario, seq.State())
+}
+
+// visit records the state of a sequence expanded, if recording them
+func (self *search) visit(seq *Sequence) {
+	if self == nil || !self.visiting {
+		return
+	}
+	state := seq.State()
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.overflown {
+		return
+	} else if len(self.visited) < maxDeadStates {
+		self.visited = append(self.visited, state)
+	} else {
+		self.overflown, self.visited = true, nil
+	}
+}
+
+// prune counts an action ruled out by a constraint, if profiling
+func (self *search) prune(size uint32) {
+	if self != nil && int(size) < len(self.pruned) {
+		atomic.AddUint64(&self.pruned[size], 1)
+	}
+}
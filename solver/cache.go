@@ -0,0 +1,156 @@
+package solver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// maxDeadStates bounds how many dead states componentCache remembers in all.  Those of the goals added
+// to least recently are forgotten first.
+const maxDeadStates = 1 << 20
+
+// maxCachedRules bounds how many sets of rules componentCache remembers the command usage of.  Those
+// added to least recently are forgotten first.
+const maxCachedRules = 256
+
+// componentCache remembers what was learned while solving a scenario which remains true when only
+// its starting resources change, as they do when re-planning mid-game (see copilot) or analyzing a
+// range of starts.  Knowledge of which states can never reach the goal depends on the rules and the
+// goal, while which commands tend to be useful depends only on the rules, so each is keyed on just
+// those components of the scenario.  It is shared by every search in the process (e.g. each request
+// to serve), so its size is bounded.
+type componentCache struct {
+	maxDead    int // See maxDeadStates
+	maxRules   int // See maxCachedRules
+	mutex      sync.RWMutex
+	dead       map[string]map[SearchState]bool // By goalKey, states from which the goal can't be reached
+	deadCount  int                             // Of every goalKey
+	deadOrder  []string                        // Each goalKey, least recently added to first
+	usage      map[string]map[string]int       // By rulesKey, then command name
+	usageOrder []string                        // Each rulesKey, least recently added to first
+}
+
+var searchCache = newComponentCache(maxDeadStates, maxCachedRules)
+
+func newComponentCache(maxDead int, maxRules int) *componentCache {
+	return &componentCache{maxDead: maxDead, maxRules: maxRules, dead: map[string]map[SearchState]bool{}, usage: map[string]map[string]int{}}
+}
+
+// componentKeys hashes the parts of the scenario that govern which sequences are legal (everything
+// but the start and goals, save for the crew replenished each turn) and, separately, those along with
+// the goal
+func (self *Scenario) componentKeys() (rulesKey string, goalKey string) {
+	rules := *self
+	rules.Start, rules.Goal, rules.GoalMin, rules.GoalMax, rules.GoalConditions = Resources{Crew: self.Start[Crew]}, Resources{}, nil, nil, nil
+	rules.GoalAnyOf, rules.SecondaryGoals = nil, nil
+	rulesKey = hashJSON(&rules)
+	if len(self.GoalAnyOf) > 0 {
+		return rulesKey, hashJSON([]interface{}{rulesKey, self.Goal, self.GoalMin, self.GoalMax, self.GoalConditions, self.GoalAnyOf})
+	} else if self.GoalMin == nil && self.GoalMax == nil && len(self.GoalConditions) == 0 {
+		return rulesKey, hashJSON([]interface{}{rulesKey, self.Goal})
+	}
+	return rulesKey, hashJSON([]interface{}{rulesKey, self.Goal, self.GoalMin, self.GoalMax, self.GoalConditions})
+}
+
+func hashJSON(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// hasDeadStates is true if any states are known to be dead ends for the scenario
+func (self *componentCache) hasDeadStates(scenario *Scenario) bool {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+	return len(self.dead[scenario.goalKey]) > 0
+}
+
+// isDead is true if the state is known to be a dead end for the scenario
+func (self *componentCache) isDead(scenario *Scenario, state SearchState) bool {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+	return self.dead[scenario.goalKey][state]
+}
+
+// addDeadStates records states from which the goal proved unreachable, forgetting those of other
+// goals (least recently added to first) to stay within its bound, and if need be some of these
+func (self *componentCache) addDeadStates(scenario *Scenario, states []SearchState) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	key := scenario.goalKey
+	self.deadOrder = touch(self.deadOrder, key)
+	for len(self.deadOrder) > 1 && self.deadCount+len(states) > self.maxDead {
+		self.deadCount -= len(self.dead[self.deadOrder[0]])
+		delete(self.dead, self.deadOrder[0])
+		self.deadOrder = self.deadOrder[1:]
+	}
+	dead := self.dead[key]
+	if dead == nil {
+		dead = map[SearchState]bool{}
+		self.dead[key] = dead
+	}
+	for _, state := range states {
+		if self.deadCount >= self.maxDead {
+			break
+		}
+		if !dead[state] {
+			dead[state] = true
+			self.deadCount++
+		}
+	}
+}
+
+// addSolutions records which commands were used by solutions, forgetting the usage of other rules
+// (least recently added to first) to stay within its bound
+func (self *componentCache) addSolutions(solutions []*Sequence) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	for _, solution := range solutions {
+		key := solution.scenario.rulesKey
+		self.usageOrder = touch(self.usageOrder, key)
+		if len(self.usageOrder) > self.maxRules {
+			delete(self.usage, self.usageOrder[0])
+			self.usageOrder = self.usageOrder[1:]
+		}
+		usage := self.usage[key]
+		if usage == nil {
+			usage = map[string]int{}
+			self.usage[key] = usage
+		}
+		for prev := solution; prev.Command != nil; prev = prev.Prev {
+			usage[prev.Command.Name]++
+		}
+	}
+}
+
+// touch moves (or adds) the key to the end of the keys
+func touch(keys []string, key string) []string {
+	for i := range keys {
+		if keys[i] == key {
+			return append(append(keys[:i:i], keys[i+1:]...), key)
+		}
+	}
+	return append(keys, key)
+}
+
+// commandOrder lists the scenario's commands with those used most often by previous solutions first
+// (otherwise in scenario order)
+func (self *componentCache) commandOrder(scenario *Scenario) []*Command {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+	usage := self.usage[scenario.rulesKey]
+	commands := make([]*Command, len(scenario.Commands))
+	for i := range scenario.Commands {
+		commands[i] = &scenario.Commands[i]
+	}
+	sort.SliceStable(commands, func(i, j int) bool {
+		return usage[commands[i].Name] > usage[commands[j].Name]
+	})
+	return commands
+}
@@ -0,0 +1,87 @@
+package solver
+
+import (
+	"reflect"
+	"testing"
+)
+
+// deadEndScenario can never meet its goal, which its search proves by exhausting every state
+const deadEndScenario = `
+turns: 3
+actions_per_turn: 2
+start: 3w
+goal: 9b
+commands:
+  scan: w 2b
+  idle: ""
+turn_must_end_above: ""
+turn_must_end_below: ""
+`
+
+func TestAddDeadStatesInPlace(t *testing.T) {
+	cache := newComponentCache(100, 10)
+	scenario := &Scenario{goalKey: "goal"}
+	cache.addDeadStates(scenario, []SearchState{{size: 1}})
+	dead := cache.dead["goal"]
+	cache.addDeadStates(scenario, []SearchState{{size: 2}, {size: 1}})
+	if reflect.ValueOf(cache.dead["goal"]).Pointer() != reflect.ValueOf(dead).Pointer() {
+		t.Error("dead states were copied rather than added to")
+	}
+	if cache.deadCount != 2 || !cache.isDead(scenario, SearchState{size: 2}) {
+		t.Errorf("remembered %d dead states, want 2", cache.deadCount)
+	}
+}
+
+func TestDeadStatesAreBounded(t *testing.T) {
+	cache := newComponentCache(3, 10)
+	first, second := &Scenario{goalKey: "first"}, &Scenario{goalKey: "second"}
+	cache.addDeadStates(first, []SearchState{{size: 1}, {size: 2}})
+	cache.addDeadStates(second, []SearchState{{size: 1}, {size: 2}})
+	if cache.hasDeadStates(first) || !cache.hasDeadStates(second) {
+		t.Error("the dead states of the goal added to least recently were not forgotten first")
+	}
+	cache.addDeadStates(second, []SearchState{{size: 3}, {size: 4}, {size: 5}})
+	if cache.deadCount != 3 || len(cache.dead["second"]) != 3 {
+		t.Errorf("remembered %d dead states, want at most 3", cache.deadCount)
+	}
+}
+
+func TestCommandUsageIsBounded(t *testing.T) {
+	cache := newComponentCache(100, 2)
+	scenario := readExample(t)
+	for _, key := range []string{"a", "b", "c"} {
+		rules := *scenario
+		rules.rulesKey = key
+		solution := StartSequence(&rules).AttemptAction(rules.FindCommand("power"))
+		cache.addSolutions([]*Sequence{solution})
+	}
+	if len(cache.usage) != 2 || cache.usage["a"] != nil {
+		t.Errorf("remembered the usage of %d sets of rules, want the 2 most recent", len(cache.usage))
+	}
+	rules := *scenario
+	rules.rulesKey = "c"
+	if order := cache.commandOrder(&rules); order[0].Name != "power" {
+		t.Errorf("%s ordered first, not the command used before", order[0].Name)
+	}
+}
+
+func TestDefaultEnginesRememberDeadEnds(t *testing.T) {
+	for _, engine := range []string{"parallel", "best-first"} {
+		scenario := parseTestScenario(t, deadEndScenario)
+		scenario.goalKey += engine // Unlike any searched before
+		stats := Stats{}
+		if found, err := Solve(scenario, Options{Engine: engine, Stats: &stats}); err != nil || len(found) > 0 {
+			t.Fatalf("%s: found %d solutions (%v), want none", engine, len(found), err)
+		}
+		if !searchCache.hasDeadStates(scenario) {
+			t.Fatalf("%s: remembered no dead states", engine)
+		}
+		again := Stats{}
+		if _, err := Solve(scenario, Options{Engine: engine, Stats: &again}); err != nil {
+			t.Fatal(err)
+		}
+		if again.Searched >= stats.Searched {
+			t.Errorf("%s: searched %d nodes again, not fewer than the %d first searched", engine, again.Searched, stats.Searched)
+		}
+	}
+}
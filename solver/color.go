@@ -0,0 +1,17 @@
+package solver
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gookit/color"
+)
+
+// Colorize renders its arguments in the named color, but only when writing to a terminal
+func Colorize(colorName string, a ...interface{}) string {
+	s := fmt.Sprint(a...)
+	if fileInfo, _ := os.Stdout.Stat(); (fileInfo.Mode() & os.ModeCharDevice) != 0 {
+		return color.Sprint("<", colorName, ">", s, "</>")
+	}
+	return s
+}
@@ -0,0 +1,152 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// CommandRegistry indexes a scenario's commands by name and by category
+type CommandRegistry struct {
+	byName     map[string]*Command
+	byCategory map[string][]*Command
+	index      map[string]int // Of each command in the scenario's list
+}
+
+func newCommandRegistry(commands []Command) *CommandRegistry {
+	registry := &CommandRegistry{map[string]*Command{}, map[string][]*Command{}, map[string]int{}}
+	for i := range commands {
+		command := &commands[i]
+		registry.byName[command.Name] = command
+		registry.index[command.Name] = i
+		registry.byCategory[command.Category] = append(registry.byCategory[command.Category], command)
+	}
+	return registry
+}
+
+func (self *CommandRegistry) lookup(name string) *Command {
+	return self.byName[name]
+}
+
+// Categories lists every category in use, sorted by name.  Commands without a category are listed
+// under the empty string.
+func (self *CommandRegistry) Categories() []string {
+	categories := []string{}
+	for category := range self.byCategory {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+func (self *CommandRegistry) InCategory(category string) []*Command {
+	return self.byCategory[category]
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (self *Command) String() string {
+	s := strings.ToUpper(self.Name)
+	if self.Icon != "" {
+		s = self.Icon + " " + s
+	}
+	s += ": " + Colorize("gray", "[", &self.Input, "]") + " => " + Colorize("gray", "[", &self.Output, "]")
+	if self.Description != "" {
+		s += "  " + self.Description
+	}
+	return s
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// RestrictCommands removes every command matching one of the excluded name patterns (e.g. "repair*",
+// see path.Match) and, if any categories are given, every command outside of them.  Patterns are
+// matched against the name of the command as written, so that excluding a scaled command (see Scale)
+// excludes every multiple of it.  The scenario must be prepared again afterwards.
+func (self *Scenario) RestrictCommands(exclude []string, onlyCategories []string) error {
+	allowed := []Command{}
+	for _, command := range self.Commands {
+		excluded := false
+		for _, pattern := range exclude {
+			matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(command.baseName()))
+			if err != nil {
+				return fmt.Errorf("invalid command pattern %q: %v", pattern, err)
+			}
+			excluded = excluded || matched
+		}
+		inCategory := len(onlyCategories) == 0
+		for _, category := range onlyCategories {
+			inCategory = inCategory || strings.EqualFold(category, command.Category)
+		}
+		if !excluded && inCategory {
+			allowed = append(allowed, command)
+		}
+	}
+	if len(allowed) == 0 {
+		return errors.New("every command has been excluded")
+	}
+	self.Commands = allowed
+	return nil
+}
+
+// AvoidRisky re-roots the sequence in a variant of its scenario without any risky commands.  If that
+// leaves the goal out of reach, as few risky commands as possible are put back, and their names are
+// returned as unavoidable.
+func AvoidRisky(start *Sequence) (*Sequence, []string) {
+	rebase := func(scenario *Scenario) *Sequence {
+		if start.Size == 0 {
+			return StartSequence(scenario)
+		}
+		return ResumeSequence(scenario, start.Resources, start.Size)
+	}
+	without := func(excluded map[string]bool) *Scenario {
+		variant, err := start.scenario.Variant(func(variant *Scenario) {
+			variant.Commands = []Command{}
+			for _, command := range start.scenario.Commands {
+				if !excluded[command.Name] {
+					variant.Commands = append(variant.Commands, command)
+				}
+			}
+		})
+		if err != nil {
+			return nil // The scenario can not do without them (e.g. a rule names one of them)
+		}
+		return variant
+	}
+	solvable := func(scenario *Scenario) bool {
+		return scenario != nil && len(SolveSerially(rebase(scenario), 1)) > 0
+	}
+
+	risky := map[string]bool{}
+	for _, command := range start.scenario.Commands {
+		if command.Risky {
+			risky[command.Name] = true
+		}
+	}
+	if safe := without(risky); safe != nil && len(safe.Commands) > 0 && solvable(safe) {
+		return rebase(safe), nil
+	}
+
+	if len(SolveSerially(start, 1)) == 0 {
+		return start, nil // Risky or not, there is no solution
+	}
+
+	// Exclude each risky command in turn, keeping it excluded if the goal can still be reached
+	excluded := map[string]bool{}
+	unavoidable := []string{}
+	for _, command := range start.scenario.Commands {
+		if !command.Risky {
+			continue
+		}
+		excluded[command.Name] = true
+		if !solvable(without(excluded)) {
+			delete(excluded, command.Name)
+			unavoidable = append(unavoidable, command.Name)
+		}
+	}
+	return rebase(without(excluded)), unavoidable
+}
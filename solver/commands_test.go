@@ -0,0 +1,47 @@
+package solver
+
+import "testing"
+
+func TestRestrictCommandsExcludesScaledMultiples(t *testing.T) {
+	scenario, err := ParseScenario([]byte(`{
+		"turns": 2, "actions_per_turn": 2, "start": {"power": 4}, "goal": {"comm": 2},
+		"commands": [
+			{"name": "convert", "input": {"power": 1}, "output": {"data": 1}, "scale": {"min": 1, "max": 3}},
+			{"name": "srt", "input": {"power": 1}, "output": {"comm": 2}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{"convert", "CONV*"} {
+		variant, err := scenario.Variant(func(variant *Scenario) {
+			if err := variant.RestrictCommands([]string{pattern}, nil); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, command := range variant.Commands {
+			names = append(names, command.Name)
+		}
+		if len(names) != 1 || names[0] != "srt" {
+			t.Errorf("excluding %q leaves %v, want just srt", pattern, names)
+		}
+	}
+}
+
+func TestVariantReportsInvalidModifications(t *testing.T) {
+	scenario := readExample(t)
+	variant, err := scenario.Variant(func(variant *Scenario) {
+		variant.ActionsPerTurn = 0
+		variant.ActionsSchedule = nil
+	})
+	if err == nil || variant != nil {
+		t.Fatalf("variant without actions prepared as %v, want an error", variant)
+	}
+	if scenario.ActionsPerTurn == 0 {
+		t.Error("modifying the variant modified the scenario")
+	}
+}
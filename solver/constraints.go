@@ -0,0 +1,318 @@
+package solver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Constraint is a mission rule which every step of a Sequence must obey.  Allows is called for every
+// node in the search and should be cheap; Describe is only used to explain a violation to the user.
+type Constraint interface {
+	Allows(seq *Sequence) bool
+	Describe(seq *Sequence) string
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NonNegativeConstraint forbids spending more of a resource than is available
+type NonNegativeConstraint struct{}
+
+// Allows implements Constraint
+func (self NonNegativeConstraint) Allows(seq *Sequence) bool {
+	// Ignore Drift, Thrust, & Radiation
+	return seq.Resources[Comm] >= 0 &&
+		seq.Resources[Data] >= 0 &&
+		seq.Resources[Nav] >= 0 &&
+		seq.Resources[Power] >= 0 &&
+		seq.Resources[Heat] >= 0 &&
+		seq.Resources[Crew] >= 0
+}
+
+// Describe implements Constraint
+func (self NonNegativeConstraint) Describe(seq *Sequence) string {
+	negative := []string{}
+	for _, resource := range []Resource{Comm, Data, Nav, Power, Heat, Crew} {
+		if value := seq.Resources.Get(resource); value < 0 {
+			negative = append(negative, fmt.Sprint(resource, " went negative (", value, ")"))
+		}
+	}
+	return strings.Join(negative, ", ")
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TurnEndConstraint requires every resource to end each turn between Min and Max, inclusive (see
+// Scenario.turnEndConstraint)
+type TurnEndConstraint struct {
+	Min Resources
+	Max Resources
+}
+
+// Allows implements Constraint
+func (self *TurnEndConstraint) Allows(seq *Sequence) bool {
+	return !seq.IsTurnEnd() || seq.Resources.within(&self.Min, &self.Max)
+}
+
+// Describe implements Constraint
+func (self *TurnEndConstraint) Describe(seq *Sequence) string {
+	broken := []string{}
+	for _, resource := range AllResources {
+		value := seq.Resources.Get(resource)
+		if least := self.Min.Get(resource); value < least {
+			broken = append(broken, fmt.Sprint("turn must end with ", resource, " at least ", least, " (was ", value, ")"))
+		}
+		if most := self.Max.Get(resource); value > most {
+			broken = append(broken, fmt.Sprint("turn must end with ", resource, " at most ", most, " (was ", value, ")"))
+		}
+	}
+	return strings.Join(broken, ", ")
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// UsageConstraint forbids taking a command more often than its MaxUsesPerTurn and MaxUsesTotal allow.
+// Uses before the origin of a resumed sequence are unknown, so are not counted.
+type UsageConstraint struct{}
+
+// Allows implements Constraint
+func (self UsageConstraint) Allows(seq *Sequence) bool {
+	command := seq.Command
+	if command == nil || !command.isLimited() {
+		return true
+	}
+	inTurn, total := seq.uses(command.baseName())
+	return (command.MaxUsesPerTurn <= 0 || inTurn <= command.MaxUsesPerTurn) &&
+		(command.MaxUsesTotal <= 0 || total <= command.MaxUsesTotal)
+}
+
+// Describe implements Constraint
+func (self UsageConstraint) Describe(seq *Sequence) string {
+	if self.Allows(seq) {
+		return ""
+	}
+	command := seq.Command
+	inTurn, total := seq.uses(command.baseName())
+	if command.MaxUsesPerTurn > 0 && inTurn > command.MaxUsesPerTurn {
+		return fmt.Sprint(command.baseName(), " may only be taken ", command.MaxUsesPerTurn, " times per turn")
+	}
+	if command.MaxUsesTotal > 0 && total > command.MaxUsesTotal {
+		return fmt.Sprint(command.baseName(), " may only be taken ", command.MaxUsesTotal, " times in all")
+	}
+	return ""
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// AvailabilityConstraint forbids taking a command outside the turns it is available in (see
+// Command.AvailableFromTurn and AvailableUntilTurn), or in a stage which does not offer it
+type AvailabilityConstraint struct{}
+
+// Allows implements Constraint
+func (self AvailabilityConstraint) Allows(seq *Sequence) bool {
+	return seq.Command == nil || seq.scenario.offers(seq.Command, seq.Turn())
+}
+
+// Describe implements Constraint
+func (self AvailabilityConstraint) Describe(seq *Sequence) string {
+	if self.Allows(seq) {
+		return ""
+	} else if seq.Command.isAvailableIn(seq.Turn()) {
+		return fmt.Sprint(seq.Command.Name, " is not available in stage ", seq.Stage().Name)
+	} else if seq.Command.AvailableUntilTurn == 0 {
+		return fmt.Sprint(seq.Command.Name, " is not available until turn ", seq.Command.AvailableFromTurn)
+	} else if seq.Command.AvailableFromTurn <= 1 {
+		return fmt.Sprint(seq.Command.Name, " is only available until turn ", seq.Command.AvailableUntilTurn)
+	}
+	return fmt.Sprint(seq.Command.Name, " is only available in turns ", seq.Command.AvailableFromTurn, " to ", seq.Command.AvailableUntilTurn)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// StageConstraint requires the goal of each stage of a multi-stage mission (but the last, whose goal
+// is the scenario's) to have been met by the end of its final turn
+type StageConstraint struct{}
+
+// Allows implements Constraint
+func (self StageConstraint) Allows(seq *Sequence) bool {
+	return self.Describe(seq) == ""
+}
+
+// Describe implements Constraint
+func (self StageConstraint) Describe(seq *Sequence) string {
+	if !seq.IsTurnEnd() {
+		return ""
+	}
+	stage := seq.scenario.stageEndingAt(seq.Turn())
+	if stage < 0 || seq.Resources.within(&seq.scenario.stageLower[stage], &seq.scenario.stageUpper[stage]) {
+		return ""
+	}
+	short := shortfall(&seq.Resources, &seq.scenario.stageLower[stage], &seq.scenario.stageUpper[stage])
+	return fmt.Sprint("stage ", seq.scenario.Stages[stage].Name, " ", strings.Join(short, ", "))
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// StepCapConstraint forbids any resource from exceeding Max after any step.  Resources which should
+// not be capped must be given a suitably large maximum.
+type StepCapConstraint struct {
+	Max Resources
+}
+
+// Allows implements Constraint
+func (self *StepCapConstraint) Allows(seq *Sequence) bool {
+	return seq.Resources.atMost(&self.Max)
+}
+
+// Describe implements Constraint
+func (self *StepCapConstraint) Describe(seq *Sequence) string {
+	broken := []string{}
+	for _, resource := range AllResources {
+		if value, max := seq.Resources.Get(resource), self.Max.Get(resource); value > max {
+			broken = append(broken, fmt.Sprint(resource, " may not exceed ", max, " (was ", value, ")"))
+		}
+	}
+	return strings.Join(broken, ", ")
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ExpressionConstraint is a custom rule written in the scenario as a comparison between two linear
+// combinations of resources, e.g. "heat + 2*drift <= 6" or "data >= comm".  It is checked after every
+// step.
+type ExpressionConstraint struct {
+	Source       string
+	coefficients Resources // lhs - rhs
+	constant     int
+	comparator   string
+}
+
+var comparators = []string{"<=", ">=", "==", "!=", "<", ">"}
+
+func ParseExpressionConstraint(source string) (*ExpressionConstraint, error) {
+	constraint := ExpressionConstraint{Source: source}
+	for _, comparator := range comparators {
+		if i := strings.Index(source, comparator); i >= 0 {
+			constraint.comparator = comparator
+			if err := constraint.addTerms(source[:i], 1); err != nil {
+				return nil, err
+			}
+			if err := constraint.addTerms(source[i+len(comparator):], -1); err != nil {
+				return nil, err
+			}
+			return &constraint, nil
+		}
+	}
+	return nil, fmt.Errorf("constraint %q has no comparison (one of %s)", source, strings.Join(comparators, " "))
+}
+
+// addTerms parses a sum of terms such as "2*heat - drift + 3" and adds each to the constraint with
+// the given sign
+func (self *ExpressionConstraint) addTerms(expression string, sign int) error {
+	expression = strings.ReplaceAll(expression, " ", "")
+	if expression == "" {
+		return fmt.Errorf("constraint %q has an empty side", self.Source)
+	}
+	start := 0
+	for i := 1; i <= len(expression); i++ {
+		if i == len(expression) || expression[i] == '+' || expression[i] == '-' {
+			if err := self.addTerm(expression[start:i], sign); err != nil {
+				return err
+			}
+			start = i
+		}
+	}
+	return nil
+}
+
+func (self *ExpressionConstraint) addTerm(term string, sign int) error {
+	if strings.HasPrefix(term, "+") {
+		term = term[1:]
+	} else if strings.HasPrefix(term, "-") {
+		term = term[1:]
+		sign = -sign
+	}
+	name := strings.TrimLeftFunc(term, func(r rune) bool { return unicode.IsDigit(r) || r == '*' })
+	prefix := term[:len(term)-len(name)]
+	digits := strings.TrimSuffix(prefix, "*")
+	if term == "" || (digits != prefix && (name == "" || digits == "")) {
+		return fmt.Errorf("constraint %q has an invalid term %q", self.Source, term)
+	}
+	coefficient := 1
+	if digits != "" {
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			return fmt.Errorf("constraint %q has an invalid term %q", self.Source, term)
+		}
+		coefficient = n
+	}
+	if name == "" {
+		self.constant += sign * coefficient
+		return nil
+	}
+	resource, ok := ResourceNamed(name)
+	if !ok {
+		return fmt.Errorf("constraint %q refers to unknown resource %q", self.Source, name)
+	}
+	self.coefficients.Set(resource, self.coefficients.Get(resource)+sign*coefficient)
+	return nil
+}
+
+func (self *ExpressionConstraint) evaluate(resources *Resources) int {
+	value := self.constant
+	for _, resource := range AllResources {
+		value += self.coefficients.Get(resource) * resources.Get(resource)
+	}
+	return value
+}
+
+// Allows implements Constraint
+func (self *ExpressionConstraint) Allows(seq *Sequence) bool {
+	value := self.evaluate(&seq.Resources)
+	switch self.comparator {
+	case "<=":
+		return value <= 0
+	case ">=":
+		return value >= 0
+	case "==":
+		return value == 0
+	case "!=":
+		return value != 0
+	case "<":
+		return value < 0
+	default:
+		return value > 0
+	}
+}
+
+// Describe implements Constraint
+func (self *ExpressionConstraint) Describe(seq *Sequence) string {
+	return fmt.Sprintf("constraint %q does not hold", self.Source)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// CrewConstraint requires every action of a crewed mission to be performed by a crew member who has
+// not already acted in the turn.  As with UsageConstraint, actions before the origin of a resumed
+// sequence are unknown, so are not counted.
+type CrewConstraint struct{}
+
+// Allows implements Constraint
+func (self CrewConstraint) Allows(seq *Sequence) bool {
+	return self.Describe(seq) == ""
+}
+
+// Describe implements Constraint
+func (self CrewConstraint) Describe(seq *Sequence) string {
+	if seq.Command == nil {
+		return ""
+	} else if seq.Member == nil {
+		return "every crew member has already acted this turn"
+	} else if seq.Prev.hasActed(seq.Member, seq.Turn()) {
+		return seq.Member.Name + " has already acted this turn"
+	}
+	return ""
+}
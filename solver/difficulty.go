@@ -0,0 +1,61 @@
+package solver
+
+import (
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+)
+
+// Difficulty summarizes how hard a search is, see EstimateDifficulty
+type Difficulty struct {
+	Branching float64   // Average number of legal actions per state expanded
+	States    int       // Distinct states explored
+	Expanded  int       // States expanded (those which did not meet the goal)
+	Children  int       // Legal actions taken from the states expanded
+	Repeats   int       // Those of the legal actions reaching a state reached already at the same depth
+	GoalDepth uint32    // Actions taken (from the start) by the shortest solution, or 0 if none was reached
+	Density   float64   // Fraction of the distinct states at the goal depth which meet the goal
+	Best      *Sequence // The best of the shortest solutions, or nil if none was reached
+}
+
+// EstimateDifficulty explores the distinct states reachable from start level by level (as
+// ExpandLevel), until the goal is met or, if maxStates is positive, once more than maxStates have been
+// explored.  Each state is expanded only once.
+func EstimateDifficulty(start *Sequence, maxStates int) *Difficulty {
+	difficulty := &Difficulty{}
+	frontier := []*Sequence{start}
+	for len(frontier) > 0 {
+		difficulty.States += len(frontier)
+		next, found := []*Sequence{}, []*Sequence{}
+		seen := map[SearchState]bool{}
+		for _, seq := range frontier {
+			if seq.IsFound() {
+				found = append(found, seq)
+				continue
+			}
+			difficulty.Expanded++
+			seq.Search(func(s parallelsearch.Searchable) {
+				difficulty.Children++
+				if child := s.(*Sequence); !seen[child.State()] {
+					seen[child.State()] = true
+					next = append(next, child)
+				} else {
+					difficulty.Repeats++
+				}
+			})
+		}
+		if len(found) > 0 {
+			difficulty.GoalDepth = found[0].Size - start.Size
+			difficulty.Density = float64(len(found)) / float64(len(frontier))
+			Rank(found)
+			difficulty.Best = found[0]
+			break
+		}
+		if maxStates > 0 && difficulty.States > maxStates && len(next) > 0 {
+			break
+		}
+		frontier = next
+	}
+	if difficulty.Expanded > 0 {
+		difficulty.Branching = float64(difficulty.Children) / float64(difficulty.Expanded)
+	}
+	return difficulty
+}
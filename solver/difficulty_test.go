@@ -0,0 +1,74 @@
+package solver
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateDifficulty(t *testing.T) {
+	scenario := readExample(t)
+	start := StartSequence(scenario)
+	difficulty := EstimateDifficulty(start, 0)
+	if difficulty.Best == nil || !difficulty.Best.IsSuccess() || difficulty.Best.Size != difficulty.GoalDepth {
+		t.Fatalf("best solution %v, want one of %d actions", difficulty.Best, difficulty.GoalDepth)
+	}
+	found, err := Solve(scenario, Options{Engine: "serial", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) == 0 {
+		t.Fatal("found no solutions")
+	}
+	if uint32(len(found[0].Commands)) != difficulty.GoalDepth {
+		t.Errorf("shortest solution has %d actions, not %d", len(found[0].Commands), difficulty.GoalDepth)
+	}
+
+	sampled := EstimateDifficulty(start, 10)
+	if sampled.Best != nil || sampled.States <= 10 || sampled.Branching == 0 {
+		t.Errorf("sampling 10 states explored %d (branching %.2f)", sampled.States, sampled.Branching)
+	}
+}
+
+func TestTuneForPicksBeamAndDedupe(t *testing.T) {
+	start := StartSequence(readExample(t))
+	depth := float64(start.scenario.TotalActions() - start.Size)
+	branching := func(magnitude float64) float64 { return math.Pow(10, magnitude/depth) }
+
+	if tuning := TuneFor(start, &Difficulty{Branching: branching(3), States: 50}); tuning.Engine != "serial" || !tuning.Dedupe {
+		t.Errorf("small tree tuned as %+v", tuning)
+	}
+	tuning := TuneFor(start, &Difficulty{Branching: branching(8), States: 2 * tuningSample, Repeats: 10})
+	if tuning.Engine != "parallel" || tuning.Beam != 0 || !tuning.Dedupe {
+		t.Errorf("medium tree tuned as %+v", tuning)
+	}
+	tuning = TuneFor(start, &Difficulty{Branching: branching(14), States: 2 * tuningSample, Repeats: 10})
+	if tuning.Engine != "parallel" || tuning.Beam != autoBeamWidth || !tuning.Dedupe {
+		t.Errorf("large tree tuned as %+v", tuning)
+	}
+	tuning = TuneFor(start, &Difficulty{Branching: branching(14), States: 2 * tuningSample})
+	if tuning.Dedupe {
+		t.Errorf("tree without repeated states tuned as %+v", tuning)
+	}
+	if tuning := TuneFor(start, &Difficulty{Branching: branching(30), States: 2 * tuningSample, Repeats: 10}); tuning.Engine != "best-first" {
+		t.Errorf("huge tree tuned as %+v", tuning)
+	}
+}
+
+func TestSolveReportsTuning(t *testing.T) {
+	scenario := readExample(t)
+	stats := &Stats{}
+	if _, err := Solve(scenario, Options{Limit: 1, Stats: stats}); err != nil {
+		t.Fatal(err)
+	}
+	if want := AutoTune(StartSequence(scenario)); stats.Tuning.Engine != want.Engine || stats.Tuning.Beam != want.Beam || stats.Tuning.Dedupe != want.Dedupe {
+		t.Errorf("solved with %+v, tuned for %+v", stats.Tuning, want)
+	}
+
+	tuned := Tuning{Engine: "parallel", PoolSize: 3, Beam: 50}
+	if _, err := Solve(scenario, Options{Limit: 1, Stats: stats, Tuned: &tuned}); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Tuning != tuned {
+		t.Errorf("solved with %+v, not %+v", stats.Tuning, tuned)
+	}
+}
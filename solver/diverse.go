@@ -0,0 +1,144 @@
+package solver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diversityPool is how many shortest solutions are considered when choosing diverse ones
+const diversityPool = 2000
+
+// DiverseSolutions picks up to limit shortest solutions which differ from one another as much as
+// possible, rather than several permutations of the same actions.  Starting from the best solution,
+// each pick is the one farthest (see planDistance) from all those already picked, with ties going to
+// the better ranked.
+func DiverseSolutions(start *Sequence, limit int) []*Sequence {
+	best := SolveSerially(start, 1)
+	if len(best) == 0 {
+		return nil
+	}
+	pool := EnumerateSolutions(start, best[0].Size, diversityPool)
+	Rank(pool)
+
+	picked := []*Sequence{best[0]}
+	nearest := make([]int, len(pool)) // Distance from each candidate to the nearest pick
+	for i, candidate := range pool {
+		nearest[i] = planDistance(candidate, best[0])
+	}
+	for len(picked) < limit {
+		farthest := -1
+		for i := range pool {
+			if nearest[i] > 0 && (farthest < 0 || nearest[i] > nearest[farthest]) {
+				farthest = i
+			}
+		}
+		if farthest < 0 {
+			break // Everything left is the same as something picked
+		}
+		pick := pool[farthest]
+		picked = append(picked, pick)
+		for i, candidate := range pool {
+			if distance := planDistance(candidate, pick); distance < nearest[i] {
+				nearest[i] = distance
+			}
+		}
+	}
+	return picked
+}
+
+// planDistance counts the commands used by one plan but not the other (as multisets), plus those used
+// in different turns, so plans using different actions are farther apart than those which merely
+// reschedule them
+func planDistance(a *Sequence, b *Sequence) int {
+	type use struct {
+		name string
+		turn uint32
+	}
+	overall := map[string]int{}
+	byTurn := map[use]int{}
+	for prev := a; prev.Command != nil; prev = prev.Prev {
+		overall[prev.Command.Name]++
+		byTurn[use{prev.Command.Name, prev.Turn()}]++
+	}
+	for prev := b; prev.Command != nil; prev = prev.Prev {
+		overall[prev.Command.Name]--
+		byTurn[use{prev.Command.Name, prev.Turn()}]--
+	}
+	distance := 0
+	for _, count := range overall {
+		distance += abs(count)
+	}
+	for _, count := range byTurn {
+		distance += abs(count)
+	}
+	return distance
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// distinctOversample is how many times more solutions Solve searches for when only distinct ones are
+// wanted, since many are usually dropped
+const distinctOversample = 8
+
+// Distinct drops each solution which is merely a reordering of a better one before it: one taking the
+// same commands (by "multiset"), or the same commands in each turn (by "turns")
+func Distinct(solutions []*Sequence, by string) ([]*Sequence, error) {
+	var key func(seq *Sequence) string
+	switch by {
+	case "multiset":
+		key = func(seq *Sequence) string { return commandMultiset(seq, 0) }
+	case "turns":
+		key = func(seq *Sequence) string {
+			turns := []string{}
+			for turn := seq.Origin().Turn(); turn <= seq.Turn(); turn++ {
+				turns = append(turns, commandMultiset(seq, turn))
+			}
+			return strings.Join(turns, " | ")
+		}
+	default:
+		return nil, fmt.Errorf("invalid distinct mode %q (expected multiset or turns)", by)
+	}
+	seen := map[string]bool{}
+	distinct := []*Sequence{}
+	for _, solution := range solutions {
+		if k := key(solution); !seen[k] {
+			seen[k] = true
+			distinct = append(distinct, solution)
+		}
+	}
+	return distinct, nil
+}
+
+// Unique drops each solution which arrives at the same final state (see Sequence.ResultKey) as a
+// better one before it
+func Unique(solutions []*Sequence) []*Sequence {
+	seen := map[interface{}]bool{}
+	unique := []*Sequence{}
+	for _, solution := range solutions {
+		if k := solution.ResultKey(); !seen[k] {
+			seen[k] = true
+			unique = append(unique, solution)
+		}
+	}
+	return unique
+}
+
+// commandMultiset lists (in a canonical order) the commands taken in a turn, or in every turn if zero
+func commandMultiset(seq *Sequence, turn uint32) string {
+	names := []string{}
+	for prev := seq; prev.Command != nil; prev = prev.Prev {
+		if turn == 0 || prev.Turn() == turn {
+			names = append(names, prev.Command.Name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
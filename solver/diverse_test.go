@@ -0,0 +1,50 @@
+package solver
+
+import (
+	"testing"
+)
+
+func TestDistinct(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 2
+actions_per_turn: 2
+start: 4w
+goal: 2r2p
+commands:
+  srt: w r
+  pl: w p
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	solutions := []*Sequence{}
+	for _, plan := range []string{"SRT PL SRT PL", "PL SRT PL SRT", "SRT SRT PL PL", "PL PL SRT SRT"} {
+		seq, err := ReplayPlan(scenario, ParsePlan(plan), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		solutions = append(solutions, seq)
+	}
+	for by, want := range map[string][]int{"multiset": {0}, "turns": {0, 2, 3}} {
+		distinct, err := Distinct(solutions, by)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plans := []string{}
+		for _, seq := range distinct {
+			plans = append(plans, seq.CommandSequence())
+		}
+		if len(distinct) != len(want) {
+			t.Errorf("distinct by %s: %v", by, plans)
+			continue
+		}
+		for i, j := range want {
+			if distinct[i] != solutions[j] {
+				t.Errorf("distinct by %s: %v, want solutions %v", by, plans, want)
+				break
+			}
+		}
+	}
+	if _, err := Distinct(solutions, "commands"); err == nil {
+		t.Error("distinct by commands is not an error")
+	}
+}
@@ -0,0 +1,126 @@
+package solver
+
+import (
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+)
+
+// SolveSerially is a deterministic, single-threaded breadth-first search from the given sequence.  It
+// explores the same tree as the parallel search but always visits commands in scenario order, which
+// makes it suitable for fuzzing, regression checks and analyses which re-solve a scenario many times.
+// Up to limit solutions are returned, best first.
+//
+// States which an earlier search (of the same rules and goal, see componentCache) proved to be dead
+// ends are skipped, and if this search proves the start to be a dead end, every state it visited is
+// recorded as one.
+func SolveSerially(start *Sequence, limit int) []*Sequence {
+	return solveSeriallyWithin(start, limit, int(start.scenario.TotalActions()-start.Size))
+}
+
+// solveSeriallyWithin is SolveSerially, searching no more than depth actions ahead
+func solveSeriallyWithin(start *Sequence, limit int, depth int) []*Sequence {
+	exhaustive := depth >= int(start.scenario.TotalActions()-start.Size)
+	pruning := searchCache.hasDeadStates(start.scenario)
+	dead := func(seq *Sequence) bool { return pruning && searchCache.isDead(seq.scenario, seq.State()) }
+	visited := []SearchState{}
+	found := []*Sequence{}
+	frontier := []*Sequence{start}
+	if dead(start) {
+		frontier = nil
+	}
+	for len(frontier) > 0 && len(found) < limit {
+		for _, seq := range frontier {
+			visited = append(visited, seq.State())
+		}
+		if int(frontier[0].Size-start.Size) >= depth {
+			for _, seq := range frontier {
+				if seq.IsFound() {
+					found = append(found, seq)
+				}
+			}
+			break
+		}
+		var next, solutions []*Sequence
+		next, solutions = ExpandLevel(frontier)
+		found = append(found, solutions...)
+		frontier = next[:0]
+		for _, seq := range next {
+			if !dead(seq) {
+				frontier = append(frontier, seq)
+			}
+		}
+	}
+	if len(found) == 0 && len(visited) > 0 && exhaustive {
+		searchCache.addDeadStates(start.scenario, visited)
+	} else {
+		searchCache.addSolutions(found)
+	}
+	Rank(found)
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// ExpandLevel takes one step of the serial breadth-first search, separating the sequences in the
+// frontier which meet the goal from those which must be expanded into the next frontier.
+//
+// Sequences in the same state have identical futures (and scores, see SearchState), so only the
+// first of them is kept.  This keeps unsolvable scenarios tractable.
+func ExpandLevel(frontier []*Sequence) (next []*Sequence, found []*Sequence) {
+	seen := map[SearchState]bool{}
+	for _, seq := range frontier {
+		if seq.IsFound() {
+			found = append(found, seq)
+			continue
+		}
+		seq.Search(func(s parallelsearch.Searchable) {
+			if child := s.(*Sequence); !seen[child.State()] {
+				seen[child.State()] = true
+				next = append(next, child)
+			}
+		})
+	}
+	return next, found
+}
+
+// EnumerateSolutions lists (up to limit) every distinct plan of exactly the given length which meets
+// the goal, in a deterministic order.  States from which the goal proved unreachable are remembered
+// so that they are only explored once.
+func EnumerateSolutions(start *Sequence, length uint32, limit int) []*Sequence {
+	found := []*Sequence{}
+	dead := map[SearchState]bool{}
+	var visit func(seq *Sequence) bool
+	visit = func(seq *Sequence) bool {
+		if seq.IsFound() {
+			if seq.Size == length {
+				found = append(found, seq)
+				return true
+			}
+			return false // Searching stops at the goal, so this can't be extended to the given length
+		}
+		key := seq.State()
+		if seq.Size >= length || dead[key] {
+			return false
+		}
+		reachable := false
+		seq.Search(func(s parallelsearch.Searchable) {
+			if len(found) < limit && visit(s.(*Sequence)) {
+				reachable = true
+			}
+		})
+		if !reachable && len(found) < limit {
+			dead[key] = true
+		}
+		return reachable
+	}
+	visit(start)
+	return found
+}
+
+// BestSolution re-solves the scenario, returning nil if it has no solution
+func (self *Scenario) BestSolution() *Sequence {
+	if found := SolveSerially(StartSequence(self), 1); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
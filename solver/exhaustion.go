@@ -0,0 +1,29 @@
+package solver
+
+import (
+	"fmt"
+)
+
+// lockedCrew is how much crew the actions taken so far leave exhausted in the given turn (see
+// Command.CrewLock), and so not replenished at its start.  Actions before the origin of a resumed
+// sequence are unknown, so exhaust none.
+func (self *Sequence) lockedCrew(turn uint32) int {
+	locked := 0
+	for prev := self; prev.Command != nil; prev = prev.Prev {
+		if lock := prev.Command.CrewLock; prev.Command.Input[Crew] > 0 && (lock < 0 || lock > 0 && prev.Turn()+uint32(lock) >= turn) {
+			locked += prev.Command.Input.Get(Crew)
+		}
+	}
+	return locked
+}
+
+// crewLocks describes the crew exhausted in each turn after this one for as long as any is (see
+// SearchState), as sequences with the same resources but different crew to come have different futures
+func (self *Sequence) crewLocks() string {
+	locked := []int{}
+	turn := self.Turn()
+	for later := turn + 1; later <= self.scenario.Turns && later <= turn+1+self.scenario.longestCrewLock; later++ {
+		locked = append(locked, self.lockedCrew(later))
+	}
+	return fmt.Sprint(locked)
+}
@@ -0,0 +1,113 @@
+package solver
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Explanation tells why a scenario has no solution: which parts of the goal no plan can reach, how
+// far each resource can be pushed within the turn budget, and (if the search ran out of legal actions
+// before it ran out of turns) which constraints cut off the last of the plans
+type Explanation struct {
+	Unmet    []string  // The parts of the goal which no reachable state meets, even on its own
+	Most     Resources // The most of each resource held by any reachable state
+	Least    Resources // The least of each resource held by any reachable state
+	States   int       // The number of distinct states explored
+	PrunedAt uint32    // The turn at which every remaining plan broke a constraint (0 if none did)
+	Action   uint32    // The action within that turn
+	Pruners  []Pruner  // The constraints which broke those plans, most often broken first
+}
+
+// Pruner is a constraint which cut off plans, with how many times it did so and an example of how
+type Pruner struct {
+	Constraint Constraint
+	Count      int
+	Example    string
+}
+
+// Explain exhaustively explores every state reachable from start, level by level (as ExpandLevel),
+// to explain why none of them meets the goal.  Returns nil if one does after all.
+func Explain(start *Sequence) *Explanation {
+	scenario := start.scenario
+	explanation := &Explanation{Most: start.Resources, Least: start.Resources}
+	frontier := []*Sequence{start}
+	for len(frontier) > 0 {
+		explanation.States += len(frontier)
+		next := []*Sequence{}
+		seen := map[SearchState]bool{}
+		pruned := map[Constraint]*Pruner{}
+		for _, seq := range frontier {
+			if seq.IsFound() {
+				return nil
+			}
+			explanation.observe(&seq.Resources)
+			if !seq.hasMoreActionsAvailable() {
+				continue
+			}
+			turn, _ := scenario.position(seq.Size + 1)
+			for i := range scenario.Commands {
+				if !scenario.offers(&scenario.Commands[i], turn) {
+					continue // Not a plan cut off by a rule, just a command not yet (or no longer) offered
+				}
+				for _, member := range seq.freeCrew() {
+					child, violated := seq.StepAs(&scenario.Commands[i], member)
+					if violated == nil {
+						if !seen[child.State()] {
+							seen[child.State()] = true
+							next = append(next, child)
+						}
+					} else if pruner := pruned[violated]; pruner != nil {
+						pruner.Count++
+					} else {
+						pruned[violated] = &Pruner{violated, 1, violated.Describe(child)}
+					}
+				}
+			}
+		}
+		if len(next) == 0 && len(pruned) > 0 && frontier[0].hasMoreActionsAvailable() {
+			explanation.PrunedAt, explanation.Action = scenario.position(frontier[0].Size + 1)
+			for _, pruner := range pruned {
+				explanation.Pruners = append(explanation.Pruners, *pruner)
+			}
+			sort.SliceStable(explanation.Pruners, func(i, j int) bool {
+				return explanation.Pruners[i].Count > explanation.Pruners[j].Count
+			})
+		}
+		frontier = next
+	}
+	explanation.Unmet = explanation.unmet(scenario)
+	return explanation
+}
+
+func (self *Explanation) observe(resources *Resources) {
+	for i, value := range resources {
+		self.Most[i], self.Least[i] = max(self.Most[i], value), min(self.Least[i], value)
+	}
+}
+
+// unmet lists the goal bounds which lie beyond the Most (or Least) of their resource.  If there are
+// alternative goals, nothing is listed unless every one of them has such a bound (each labelled with
+// its goal).
+func (self *Explanation) unmet(scenario *Scenario) []string {
+	unmet := []string{}
+	for i := range scenario.goalLowers {
+		which := ""
+		if len(scenario.goalLowers) > 1 {
+			which = fmt.Sprint("goal ", i+1, " ")
+		}
+		unmetBefore := len(unmet)
+		for _, resource := range AllResources {
+			least, most := scenario.goalLowers[i].Get(resource), scenario.goalUppers[i].Get(resource)
+			if reached := self.Most.Get(resource); reached < least {
+				unmet = append(unmet, fmt.Sprint(which, "needs ", resource, " ", least, " (at most ", reached, " is reachable)"))
+			}
+			if reached := self.Least.Get(resource); reached > most {
+				unmet = append(unmet, fmt.Sprint(which, "needs ", resource, " at most ", most, " (at least ", reached, " is unavoidable)"))
+			}
+		}
+		if len(unmet) == unmetBefore {
+			return []string{} // This goal may yet be met
+		}
+	}
+	return unmet
+}
@@ -0,0 +1,40 @@
+package solver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 3
+actions_per_turn: 1
+start: 1w
+goal: 3r
+commands:
+  srt: w r
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	explanation := Explain(StartSequence(scenario))
+	if explanation == nil {
+		t.Fatal("explained nothing, though the goal is out of reach")
+	}
+	if got := strings.Join(explanation.Unmet, "; "); got != "needs comm 3 (at most 1 is reachable)" {
+		t.Errorf("unmet %q", got)
+	}
+	if most := explanation.Most.Get(Comm); most != 1 {
+		t.Errorf("at most %d comm reachable, want 1", most)
+	}
+	if explanation.PrunedAt != 2 || explanation.Action != 1 {
+		t.Errorf("pruned at turn %d action %d, want turn 2 action 1", explanation.PrunedAt, explanation.Action)
+	}
+	if len(explanation.Pruners) != 1 || !strings.Contains(explanation.Pruners[0].Example, "power went negative") {
+		t.Errorf("pruned by %+v, want power going negative", explanation.Pruners)
+	}
+
+	scenario.Start.Set(Power, 3) // Enough for all three turns
+	if explanation := Explain(StartSequence(scenario)); explanation != nil {
+		t.Errorf("explained %+v, though the goal is reachable", explanation)
+	}
+}
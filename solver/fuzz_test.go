@@ -0,0 +1,328 @@
+package solver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/scenarios"
+	"github.com/david-mccullars/mars-horizon-mission-solver/shorthand"
+)
+
+// fuzzLimits bound the scenarios fuzzed, to those quick to prepare and search
+var fuzzLimits = Limits{Turns: 16, Actions: 64, Commands: 32}
+
+// parseFuzzedScenario reads a scenario in YAML shorthand (as ReadScenario), or returns nil if it is
+// invalid or too large to fuzz quickly (see fuzzLimits)
+func parseFuzzedScenario(rawYAML []byte) *Scenario {
+	rawJSON, err := shorthand.ToJSON(rawYAML)
+	if err != nil {
+		return nil
+	}
+	scenario, err := ParseScenarioWithin(rawJSON, fuzzLimits)
+	if err != nil {
+		return nil
+	}
+	return scenario
+}
+
+// addEmbeddedSeeds seeds a fuzz target with each of the embedded missions, along with the plan of a
+// solution to it (found by the beam engine, which is quick)
+func addEmbeddedSeeds(f *testing.F) {
+	for _, name := range scenarios.Names() {
+		raw, err := scenarios.Read(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		scenario := parseFuzzedScenario(raw)
+		if scenario == nil {
+			f.Fatalf("%s: could not be parsed", name)
+		}
+		plan := ""
+		if found, err := Solve(scenario, Options{Engine: "beam", Limit: 1, Deterministic: true}); err == nil && len(found) > 0 {
+			plan = found[0].Sequence.CommandSequence()
+		}
+		f.Add(raw, plan)
+		f.Add(raw, "")
+	}
+}
+
+// FuzzParseScenario throws (corrupted) scenarios in YAML shorthand at the shorthand and scenario
+// parsers, which may reject them but never panic.  Every shorthand error must say where it is.
+func FuzzParseScenario(f *testing.F) {
+	addEmbeddedSeeds(f)
+	f.Fuzz(func(t *testing.T, rawYAML []byte, _ string) {
+		if scenario := parseFuzzedScenario(rawYAML); scenario != nil {
+			scenario.Validate()
+		}
+	})
+}
+
+// FuzzSimulateAgainstEngine takes a plan both with the engine (stepping each action in turn) and with
+// the independent simulator (see Simulate), which must agree on whether the plan is legal and meets
+// the goal.  Every plan the engine accepts must also obey the invariants of checkInvariants.
+func FuzzSimulateAgainstEngine(f *testing.F) {
+	addEmbeddedSeeds(f)
+	f.Fuzz(func(t *testing.T, rawYAML []byte, plan string) {
+		scenario := parseFuzzedScenario(rawYAML)
+		if scenario == nil || len(scenario.Constraints) > 0 { // Custom constraints aren't simulated
+			return
+		}
+		seq, legal := StartSequence(scenario), true
+		for _, name := range ParsePlan(plan) {
+			command, member, err := seq.findAction(name)
+			if err != nil || !seq.hasMoreActionsAvailable() {
+				return
+			}
+			next, violated := seq.StepAs(command, member)
+			seq, legal = next, legal && violated == nil
+		}
+		accepted := legal && seq.IsSuccess()
+		simulated := Simulate(scenario, seq.Commands(), seq.Crew())
+		if accepted && simulated != nil {
+			t.Fatalf("%s: the engine accepts it, but the simulator does not: %v", seq.CommandSequence(), simulated)
+		} else if !accepted && simulated == nil {
+			t.Fatalf("%s: the simulator accepts it, but the engine does not", seq.CommandSequence())
+		} else if accepted {
+			if err := checkInvariants(seq); err != nil {
+				t.Fatalf("%s: %v", seq.CommandSequence(), err)
+			}
+		}
+	})
+}
+
+// FuzzEngine generates a small random scenario (see randomScenario) from each seed, and throws
+// randomly corrupted plans and constraint expressions at it.  Nothing may panic, every solution the
+// serial engine finds must obey the scenario's invariants (see checkInvariants), and searching in
+// canonical order (see Options.Canonical) must find solutions just as short.
+func FuzzEngine(f *testing.F) {
+	for seed := int64(0); seed < 20; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		r := rand.New(rand.NewSource(seed))
+		scenario := randomScenario(r)
+		ReplayPlan(scenario, ParsePlan(randomPlan(r, scenario)), nil)
+		if constraint, err := ParseExpressionConstraint(randomExpression(r)); err == nil {
+			constraint.Allows(StartSequence(scenario))
+		}
+		for _, solution := range SolveSerially(StartSequence(scenario), 4) {
+			if err := checkInvariants(solution); err != nil {
+				t.Fatalf("%s: %v", solution.CommandSequence(), err)
+			}
+		}
+		all, err := Solve(scenario, Options{Engine: "serial", Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		canonical, err := Solve(scenario, Options{Engine: "serial", Limit: 1, Canonical: true})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(all) != len(canonical) {
+			t.Fatalf("%d solutions, but %d in canonical order", len(all), len(canonical))
+		} else if len(all) > 0 && all[0].Sequence.Size != canonical[0].Sequence.Size {
+			t.Fatalf("shortest solution takes %d actions, but %d in canonical order", all[0].Sequence.Size, canonical[0].Sequence.Size)
+		}
+	})
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// checkInvariants confirms a solution is legal at every step (according to both the engine and the
+// independent simulator), meets the goal (certainly, unless actions may fail), and survives being
+// persisted and restored
+func checkInvariants(solution *Sequence) error {
+	for seq := solution; seq != nil && seq.Size > 0; seq = seq.Prev {
+		if !(NonNegativeConstraint{}).Allows(seq) {
+			return errors.New("negative resources after " + seq.CommandName())
+		}
+		if violated := seq.Violation(); violated != nil {
+			return errors.New(violated.Describe(seq))
+		}
+	}
+	if !solution.IsSuccess() {
+		return errors.New("does not meet the goal")
+	}
+	if err := Simulate(solution.Scenario(), solution.Commands(), solution.Crew()); err != nil {
+		return err
+	}
+	if p := solution.SuccessProbability(); p < 0 || p > 1+1e-9 || (p < 1-1e-9 && !solution.Scenario().HasFailureRates()) {
+		return fmt.Errorf("succeeds with probability %g", p)
+	}
+	total := 0
+	for _, term := range solution.ScoreBreakdown() {
+		total += term.Points
+	}
+	if total != solution.Score() {
+		return fmt.Errorf("score of %d breaks down into %d", solution.Score(), total)
+	}
+	rawJSON, err := json.Marshal(solution)
+	if err != nil {
+		return err
+	}
+	restored := StartSequence(solution.Scenario())
+	if err := json.Unmarshal(rawJSON, restored); err != nil {
+		return err
+	}
+	if restored.CommandSequence() != solution.CommandSequence() {
+		return errors.New("restores as " + restored.CommandSequence())
+	}
+	return nil
+}
+
+// randomScenario generates a small (quickly solvable) but otherwise arbitrary scenario
+func randomScenario(r *rand.Rand) *Scenario {
+	scenario := &Scenario{
+		Turns:            uint32(1 + r.Intn(3)),
+		ActionsPerTurn:   uint32(1 + r.Intn(3)),
+		Start:            randomResources(r, 0, 4),
+		Goal:             randomResources(r, 0, 4),
+		TurnCost:         randomResources(r, -1, 1),
+		TurnMustEndAbove: NoLowerBound,
+		TurnMustEndBelow: NoUpperBound,
+	}
+	if r.Intn(3) == 0 {
+		for turn := uint32(0); turn < scenario.Turns; turn++ {
+			scenario.ActionsSchedule = append(scenario.ActionsSchedule, uint32(1+r.Intn(3)))
+		}
+	}
+	for i := 1 + r.Intn(5); i > 0; i-- {
+		scenario.Commands = append(scenario.Commands, Command{
+			Name:   fmt.Sprint("cmd", i),
+			Input:  randomResources(r, 0, 2),
+			Output: randomResources(r, 0, 3),
+		})
+	}
+	for i := range scenario.Commands {
+		if r.Intn(4) == 0 {
+			after := scenario.Commands[r.Intn(len(scenario.Commands))].Name
+			scenario.Commands[i].Bonus = &Bonus{After: after, Output: randomResources(r, 0, 2)}
+		}
+		if r.Intn(4) == 0 {
+			scenario.Commands[i].FailureRate = float64(r.Intn(10)) / 10
+		}
+		if r.Intn(4) == 0 {
+			scenario.Commands[i].MaxUsesPerTurn = r.Intn(3)
+			scenario.Commands[i].MaxUsesTotal = r.Intn(4)
+		}
+		if r.Intn(4) == 0 {
+			scenario.Commands[i].AvailableFromTurn = uint32(r.Intn(int(scenario.Turns) + 1))
+			scenario.Commands[i].AvailableUntilTurn = scenario.Commands[i].AvailableFromTurn + uint32(r.Intn(3))
+		}
+		if r.Intn(5) == 0 {
+			scenario.Commands[i].CrewLock = r.Intn(4) - 1
+		}
+		if r.Intn(5) == 0 {
+			least := uint32(1 + r.Intn(2))
+			scenario.Commands[i].Scale = &Scale{Min: least, Max: least + uint32(r.Intn(3))}
+		}
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		scenario.Events = append(scenario.Events, Event{
+			Name:  fmt.Sprint("event", i),
+			Turn:  uint32(1 + r.Intn(int(scenario.Turns))),
+			Delta: randomResources(r, -1, 2),
+		})
+	}
+	if r.Intn(3) == 0 {
+		effect := TurnEffect{Resource: ResourceNames[r.Intn(len(ResourceNames))], Change: r.Intn(5) - 2}
+		if r.Intn(2) == 0 {
+			floor := 0
+			effect.Floor = &floor
+		}
+		scenario.TurnEffects = append(scenario.TurnEffects, effect)
+	}
+	if r.Intn(2) == 0 {
+		scenario.TurnMustEndBelow.Set(Heat, 2+r.Intn(6))
+	}
+	if r.Intn(4) == 0 {
+		for i := 1 + r.Intn(3); i > 0; i-- {
+			scenario.Crew = append(scenario.Crew, CrewMember{Name: fmt.Sprint("astronaut", i), Bonus: randomResources(r, 0, 1)})
+		}
+	}
+	if r.Intn(4) == 0 {
+		caps := NoUpperBound
+		caps.Set(Data, 3+r.Intn(4))
+		caps.Set(Power, 3+r.Intn(4))
+		scenario.Caps = &caps
+		if r.Intn(2) == 0 {
+			scenario.Overflow = map[string]string{"power": "invalid"}
+		}
+	}
+	if r.Intn(4) == 0 {
+		turnEndMin, turnEndMax := NoLowerBound, NoUpperBound
+		turnEndMin.Set(Power, r.Intn(2))
+		turnEndMax.Set(Heat, 1+r.Intn(6))
+		scenario.TurnEndMin, scenario.TurnEndMax = &turnEndMin, &turnEndMax
+	}
+	if r.Intn(4) == 0 {
+		failAbove := NoUpperBound
+		failAbove.Set(Heat, 3+r.Intn(4))
+		failAbove.Set(Data, 4+r.Intn(4))
+		scenario.FailAbove = &failAbove
+	}
+	if r.Intn(4) == 0 {
+		goalMax := NoUpperBound
+		goalMax.Set(Power, 2+r.Intn(4))
+		scenario.GoalMax = &goalMax
+	}
+	if r.Intn(4) == 0 {
+		comparators := []string{"<=", ">=", "==", "within"}
+		comparator, value := comparators[r.Intn(len(comparators))], r.Intn(5)-2
+		if comparator == "within" {
+			value += 2
+		}
+		scenario.GoalConditions = append(scenario.GoalConditions, fmt.Sprint(ResourceNames[r.Intn(len(ResourceNames))], " ", comparator, " ", value))
+	}
+	if len(scenario.ActionsSchedule) == 0 && r.Intn(4) == 0 {
+		ascent := Stage{Name: "ascent", Turns: 1, Goal: randomResources(r, 0, 2)}
+		if r.Intn(2) == 0 {
+			ascent.Commands = []string{scenario.Commands[0].Name}
+		}
+		if r.Intn(2) == 0 {
+			cost := randomResources(r, -1, 1)
+			ascent.TurnCost = &cost
+		}
+		cruise := Stage{Name: "cruise", Turns: scenario.Turns, ActionsPerTurn: uint32(1 + r.Intn(3))}
+		scenario.Stages, scenario.Turns = []Stage{ascent, cruise}, 0 // Turns are those of the stages
+	}
+	if err := scenario.Prepare(); err != nil {
+		panic(err)
+	}
+	return scenario
+}
+
+// randomResources sets roughly a third of the resources to a value in [min, max]
+func randomResources(r *rand.Rand, min int, max int) Resources {
+	resources := Resources{}
+	for _, resource := range AllResources {
+		if r.Intn(3) == 0 {
+			resources.Set(resource, min+r.Intn(max-min+1))
+		}
+	}
+	return resources
+}
+
+func randomPlan(r *rand.Rand, scenario *Scenario) string {
+	words := []string{"->", "[", "]", "[ 1 ]", ",", "\n", "", "???"}
+	for _, command := range scenario.Commands {
+		words = append(words, command.Name, strings.ToUpper(command.Name))
+	}
+	plan := []string{}
+	for i := r.Intn(12); i > 0; i-- {
+		plan = append(plan, words[r.Intn(len(words))])
+	}
+	return strings.Join(plan, " ")
+}
+
+func randomExpression(r *rand.Rand) string {
+	words := append([]string{"<=", ">=", "==", "!=", "<", ">", "+", "-", "*", "2", "-3", "10", " "}, ResourceNames...)
+	expression := ""
+	for i := r.Intn(8); i > 0; i-- {
+		expression += words[r.Intn(len(words))]
+	}
+	return expression
+}
@@ -0,0 +1,231 @@
+package solver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Goals lists the goals reaching any one of which is success: those of GoalAnyOf if it is given,
+// otherwise just Goal
+func (self *Scenario) Goals() []Resources {
+	if len(self.GoalAnyOf) > 0 {
+		return self.GoalAnyOf
+	}
+	return []Resources{self.Goal}
+}
+
+// prepareGoals works out the bounds of each goal (see goalBounds)
+func (self *Scenario) prepareGoals() {
+	self.goalLowers, self.goalUppers = nil, nil
+	for _, goal := range self.Goals() {
+		lower, upper := self.goalBounds(&goal)
+		self.goalLowers, self.goalUppers = append(self.goalLowers, lower), append(self.goalUppers, upper)
+	}
+}
+
+// goalBounds combines a goal (Goal, or one of GoalAnyOf) with GoalConditions, GoalMin and GoalMax
+// into the lowest and highest each resource may finish at (see goalBand).  Any condition on a resource
+// replaces what the goal asks of it, and GoalMin and GoalMax tighten the bounds, save that any drift
+// bound they give replaces the band altogether.
+func (self *Scenario) goalBounds(goal *Resources) (lower Resources, upper Resources) {
+	lower, upper = goalBand(goal)
+	applyGoalConditions(self.GoalConditions, &lower, &upper)
+	if self.GoalMin != nil && IsBounded(self.GoalMin.Get(Drift)) || self.GoalMax != nil && IsBounded(self.GoalMax.Get(Drift)) {
+		lower[Drift], upper[Drift] = NoLowerBound[Drift], NoUpperBound[Drift]
+	}
+	for _, resource := range AllResources {
+		if self.GoalMin != nil && IsBounded(self.GoalMin.Get(resource)) && self.GoalMin[resource] > lower[resource] {
+			lower[resource] = self.GoalMin[resource]
+		}
+		if self.GoalMax != nil && IsBounded(self.GoalMax.Get(resource)) && self.GoalMax[resource] < upper[resource] {
+			upper[resource] = self.GoalMax[resource]
+		}
+	}
+	return lower, upper
+}
+
+// goalBand finds the lowest and highest each resource may finish at to meet a goal: at least the
+// goal's comm, data, nav, and power (and thrust, if given), and a band of ±Drift around zero; heat,
+// crew, and radiation are ignored
+func goalBand(goal *Resources) (lower Resources, upper Resources) {
+	lower, upper = NoLowerBound, NoUpperBound
+	for _, resource := range []Resource{Comm, Data, Nav, Power} {
+		lower[resource] = goal[resource]
+	}
+	if goal[Thrust] != 0 {
+		lower[Thrust] = goal[Thrust]
+	}
+	lower.Set(Drift, -goal.Get(Drift))
+	upper[Drift] = goal[Drift]
+	return lower, upper
+}
+
+// applyGoalConditions replaces the bounds of each resource compared by the conditions (see
+// parseGoalCondition) with those the conditions give
+func applyGoalConditions(sources []string, lower *Resources, upper *Resources) {
+	conditions := []*goalCondition{}
+	for _, source := range sources {
+		if condition, err := parseGoalCondition(source); err == nil { // Those which can't be parsed are rejected by Prepare
+			conditions = append(conditions, condition)
+			lower[condition.resource], upper[condition.resource] = NoLowerBound[condition.resource], NoUpperBound[condition.resource]
+		}
+	}
+	for _, condition := range conditions {
+		lower.Set(condition.resource, max(lower.Get(condition.resource), condition.lower))
+		upper.Set(condition.resource, min(upper.Get(condition.resource), condition.upper))
+	}
+}
+
+// goalCondition is one of a scenario's GoalConditions: the lowest and highest a resource may finish at
+type goalCondition struct {
+	resource Resource
+	lower    int
+	upper    int
+}
+
+// parseGoalCondition parses a comparison of a resource with a number, e.g. "drift <= -2", "heat == 0",
+// "power >= 3", or "drift within 1" (a band of ±1 around zero)
+func parseGoalCondition(source string) (*goalCondition, error) {
+	var name, value, comparator string
+	for _, comparator = range []string{"<=", ">=", "==", " within "} {
+		var ok bool
+		if name, value, ok = strings.Cut(source, comparator); ok {
+			break
+		}
+		comparator = ""
+	}
+	if comparator == "" {
+		return nil, fmt.Errorf("goal condition %q has no comparison (one of <= >= == within)", source)
+	}
+	resource, ok := ResourceNamed(strings.ToLower(strings.TrimSpace(name)))
+	if !ok {
+		return nil, fmt.Errorf("goal condition %q refers to unknown resource %q", source, strings.ToLower(strings.TrimSpace(name)))
+	}
+	condition := goalCondition{resource: resource}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || comparator == " within " && n < 0 {
+		return nil, fmt.Errorf("goal condition %q has an invalid number %q", source, strings.TrimSpace(value))
+	}
+	condition.lower, condition.upper = NoLowerBound.Get(resource), NoUpperBound.Get(resource)
+	switch comparator {
+	case "<=":
+		condition.upper = n
+	case ">=":
+		condition.lower = n
+	case "==":
+		condition.lower, condition.upper = n, n
+	default:
+		condition.lower, condition.upper = -n, n
+	}
+	return &condition, nil
+}
+
+// describeGoal describes what a goal asks for, e.g. "4 data, 3 comm, drift within ±1"
+func describeGoal(goal *Resources) string {
+	terms := []string{}
+	for _, resource := range []Resource{Comm, Data, Nav, Power, Thrust} {
+		if goal[resource] != 0 {
+			terms = append(terms, fmt.Sprint(goal[resource], " ", resource))
+		}
+	}
+	if goal[Drift] != 0 {
+		terms = append(terms, fmt.Sprint("drift within ±", goal[Drift]))
+	}
+	if len(terms) == 0 {
+		return "nothing"
+	}
+	return strings.Join(terms, ", ")
+}
+
+// GoalMet finds which of the scenario's Goals this sequence's resources meet (by index, the first if
+// more than one), or -1 if none.  Unlike IsSuccess, it ignores whether the last stage has been reached.
+func (self *Sequence) GoalMet() int {
+	for i := range self.scenario.goalLowers {
+		if self.Resources.within(&self.scenario.goalLowers[i], &self.scenario.goalUppers[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// goal is the goal this sequence meets (see GoalMet), or if none, the first of the scenario's Goals
+func (self *Sequence) goal() *Resources {
+	goals := self.scenario.Goals()
+	if met := self.GoalMet(); met >= 0 {
+		return &goals[met]
+	}
+	return &goals[0]
+}
+
+// DescribeGoalMet describes which of the scenario's Goals this sequence meets, e.g. "meets goal 2 of 3
+// (3 comm)"
+func (self *Sequence) DescribeGoalMet() string {
+	goals := self.scenario.Goals()
+	met := self.GoalMet()
+	if met < 0 {
+		return "meets none of the goals"
+	}
+	return fmt.Sprintf("meets goal %d of %d (%s)", met+1, len(goals), describeGoal(&goals[met]))
+}
+
+// nearestGoal finds the goal (by index) this sequence is closest to meeting (see GoalDistance)
+func (self *Sequence) nearestGoal() int {
+	nearest, distance := 0, -1
+	for i := range self.scenario.goalLowers {
+		if d := goalDistance(&self.Resources, &self.scenario.goalLowers[i], &self.scenario.goalUppers[i]); distance < 0 || d < distance {
+			nearest, distance = i, d
+		}
+	}
+	return nearest
+}
+
+// GoalShortfall describes which parts of the goal this sequence has not (yet) met (of the goal it is
+// closest to meeting, if there are alternatives)
+func (self *Sequence) GoalShortfall() string {
+	i := self.nearestGoal()
+	short := shortfall(&self.Resources, &self.scenario.goalLowers[i], &self.scenario.goalUppers[i])
+	if len(self.scenario.goalLowers) > 1 && len(short) > 0 {
+		return fmt.Sprintf("goal %d of %d: %s", i+1, len(self.scenario.goalLowers), strings.Join(short, ", "))
+	}
+	return strings.Join(short, ", ")
+}
+
+// shortfall describes how the resources fall outside the lower and upper bounds
+func shortfall(resources *Resources, lower *Resources, upper *Resources) []string {
+	short := []string{}
+	for _, resource := range AllResources {
+		has, least, most := resources.Get(resource), lower.Get(resource), upper.Get(resource)
+		if has >= least && has <= most {
+			continue
+		} else if least == -most {
+			short = append(short, fmt.Sprint("needs ", resource, " within ±", most, " (has ", has, ")"))
+		} else if has < least {
+			short = append(short, fmt.Sprint("needs ", resource, " ", least, " (has ", has, ")"))
+		} else {
+			short = append(short, fmt.Sprint("needs ", resource, " at most ", most, " (has ", has, ")"))
+		}
+	}
+	return short
+}
+
+// GoalDistance measures how far this sequence is from meeting the goal, as the total shortfall (or
+// excess) across all goal resources (zero if the goal is met), of the goal it is closest to meeting
+func (self *Sequence) GoalDistance() int {
+	i := self.nearestGoal()
+	return goalDistance(&self.Resources, &self.scenario.goalLowers[i], &self.scenario.goalUppers[i])
+}
+
+// goalDistance is the total amount by which the resources fall outside the lower and upper bounds
+func goalDistance(resources *Resources, lower *Resources, upper *Resources) int {
+	distance := 0
+	for _, resource := range AllResources {
+		has, least, most := resources.Get(resource), lower.Get(resource), upper.Get(resource)
+		if has < least {
+			distance += least - has
+		} else if has > most {
+			distance += has - most
+		}
+	}
+	return distance
+}
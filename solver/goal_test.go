@@ -0,0 +1,81 @@
+package solver
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGoalConditions(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 1
+actions_per_turn: 3
+start: 3w
+goal: 1r
+goal_conditions: ["drift <= -2"]
+commands:
+  srt: w r
+  fire: w -1d
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	found := SolveSerially(StartSequence(scenario), 1)
+	if len(found) == 0 || found[0].Size != 3 || found[0].Resources.Get(Drift) != -2 {
+		t.Errorf("solved as %v, want srt and fire twice, reducing drift to -2", found)
+	}
+
+	scenario.GoalConditions = nil
+	if err := scenario.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	if found := SolveSerially(StartSequence(scenario), 1); len(found) == 0 || found[0].CommandSequence() != "SRT" {
+		t.Errorf("solved as %v without conditions, want just SRT (drift within ±0)", found)
+	}
+
+	for source, want := range map[string]string{
+		"drift <= -2":     "-32768..-2",
+		"heat == 0":       "0..0",
+		" Power >= 3 ":    "3..32767",
+		"drift within 1":  "-1..1",
+		"drift < 1":       "",
+		"warmth == 0":     "",
+		"drift within -1": "",
+		"comm >= some":    "",
+	} {
+		condition, err := parseGoalCondition(source)
+		if want == "" && err == nil {
+			t.Errorf("%q: parsed, though invalid", source)
+		} else if want != "" && (err != nil || fmt.Sprint(condition.lower, "..", condition.upper) != want) {
+			t.Errorf("%q: got %+v (%v), want %s", source, condition, err, want)
+		}
+	}
+}
+
+func TestGoalAnyOf(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 1
+actions_per_turn: 3
+start: 3w
+goal_any_of: [3b, 2r]
+commands:
+  lab: w b
+  srt: w r
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	found := SolveSerially(StartSequence(scenario), 1)
+	if len(found) == 0 || found[0].CommandSequence() != "SRT -> SRT" {
+		t.Fatalf("solved as %v, want SRT -> SRT", found)
+	}
+	if got := found[0].DescribeGoalMet(); got != "meets goal 2 of 2 (2 comm)" {
+		t.Errorf("solution %s", got)
+	}
+	for plan, want := range map[string]string{
+		"LAB LAB LAB": "meets goal 1 of 2 (3 data)",
+		"LAB SRT LAB": "meets none of the goals",
+	} {
+		seq, err := ReplayPlan(scenario, ParsePlan(plan), nil)
+		if got := seq.DescribeGoalMet(); got != want {
+			t.Errorf("%s %s (%v), want %s", plan, got, err, want)
+		}
+	}
+}
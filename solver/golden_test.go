@@ -0,0 +1,75 @@
+package solver
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "record the solutions now found as the expected ones in testdata/golden")
+
+// goldenCase is a scenario along with the best solution the solver is known to find for it.  An
+// empty Plan means the scenario is expected to be unsolvable.
+type goldenCase struct {
+	Scenario json.RawMessage `json:"scenario"`
+	Length   uint32          `json:"length"`
+	Score    int             `json:"score"`
+	Plan     string          `json:"plan"`
+}
+
+// TestGolden solves every scenario in testdata/golden with the serial engine and compares the best
+// solution found against the recorded length and score, guarding against regressions in the search.
+// With -update the recorded expectations are rewritten instead.
+func TestGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "golden", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := goldenCase{}
+			if err := json.Unmarshal(raw, &golden); err != nil {
+				t.Fatal(err)
+			}
+			scenario, err := ParseScenario(golden.Scenario)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := goldenCase{Scenario: golden.Scenario}
+			if found := SolveSerially(StartSequence(scenario), 1); len(found) > 0 {
+				actual.Length = found[0].Size
+				actual.Score = found[0].Score()
+				actual.Plan = found[0].CommandSequence()
+			}
+
+			if *update {
+				if err := writeGolden(path, &actual); err != nil {
+					t.Fatal(err)
+				}
+			} else if actual.Length != golden.Length || actual.Score != golden.Score || (actual.Plan == "") != (golden.Plan == "") {
+				t.Errorf("expected length %d, score %d: %s", golden.Length, golden.Score, golden.Plan)
+				t.Errorf("actual   length %d, score %d: %s", actual.Length, actual.Score, actual.Plan)
+			}
+		})
+	}
+}
+
+func writeGolden(path string, golden *goldenCase) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false) // Keep "->" in plans readable
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(golden)
+}
@@ -0,0 +1,108 @@
+package solver
+
+// actionLimits finds, for each resource, the most a single action (including the cost and effects of
+// a new turn or events which may precede it, any bonus it may earn, and the skill of the crew member
+// performing it) can raise or lower it
+func (self *Scenario) actionLimits() (raise Resources, lower Resources) {
+	for _, resource := range AllResources {
+		raised, lowered := 0, 0
+		for i := range self.Commands {
+			command := &self.Commands[i]
+			net := command.Output.Get(resource) - command.Input.Get(resource)
+			most, least := net, net
+			if command.Bonus != nil {
+				if bonus := command.Bonus.Output.Get(resource); bonus > 0 {
+					most += bonus
+				} else {
+					least += bonus
+				}
+			}
+			raised, lowered = max(raised, most), max(lowered, -least)
+		}
+		most, least := 0, 0 // Of any one crew member's bonus
+		for _, member := range self.Crew {
+			if bonus := member.Bonus.Get(resource); bonus > most {
+				most = bonus
+			} else if bonus < least {
+				least = bonus
+			}
+		}
+		raised += most
+		lowered -= least
+		most, least = 0, 0 // Of any one turn's cost (and effects)
+		for _, cost := range self.turnCosts() {
+			if delta := cost.Get(resource) + self.turnEffectOn(resource); delta > most {
+				most = delta
+			} else if delta < least {
+				least = delta
+			}
+		}
+		raised += most
+		lowered -= least
+		most, least = 0, 0 // Of any one turn's events (all of which happen before its first action)
+		for _, delta := range self.eventTotals(resource) {
+			if delta > most {
+				most = delta
+			} else if delta < least {
+				least = delta
+			}
+		}
+		raised += most
+		lowered -= least
+		raise.Set(resource, raised)
+		lower.Set(resource, lowered)
+	}
+	return raise, lower
+}
+
+// eventTotals sums the changes to the resource made by the events of each turn with any
+func (self *Scenario) eventTotals(resource Resource) map[uint32]int {
+	totals := map[uint32]int{}
+	for i := range self.Events {
+		totals[self.Events[i].Turn] += self.Events[i].Delta.Get(resource)
+	}
+	return totals
+}
+
+// Heuristic implements parallelsearch.Heuristic by estimating the actions still needed to meet the
+// goal from how far short each goal resource is and the most one action can make up (the fewest of any
+// goal, if there are alternatives).  It never overestimates (unless the goal is out of reach
+// altogether), so a best-first search still finds the shortest solutions first.
+func (self *Sequence) Heuristic() int {
+	fewest := -1
+	for i := range self.scenario.goalLowers {
+		if needed := self.actionsNeeded(&self.scenario.goalLowers[i], &self.scenario.goalUppers[i]); fewest < 0 || needed < fewest {
+			fewest = needed
+		}
+	}
+	return fewest
+}
+
+// actionsNeeded estimates (as Heuristic) the actions still needed to bring every resource within the
+// lower and upper bounds of a goal
+func (self *Sequence) actionsNeeded(lower *Resources, upper *Resources) int {
+	unreachable := int(self.scenario.TotalActions()-self.Size) + 1
+	needed := 0
+	need := func(shortfall int, perAction int) {
+		if shortfall <= 0 {
+			return
+		}
+		actions := unreachable
+		if perAction > 0 {
+			actions = (shortfall + perAction - 1) / perAction
+		}
+		if actions > needed {
+			needed = actions
+		}
+	}
+	for _, resource := range AllResources {
+		has := self.Resources.Get(resource)
+		if least := lower.Get(resource); IsBounded(least) {
+			need(least-has, self.scenario.raise.Get(resource))
+		}
+		if most := upper.Get(resource); IsBounded(most) {
+			need(has-most, self.scenario.lower.Get(resource))
+		}
+	}
+	return needed
+}
@@ -0,0 +1,73 @@
+package solver
+
+import (
+	"testing"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/shorthand"
+)
+
+// parseTestScenario prepares a scenario written in shorthand
+func parseTestScenario(t testing.TB, rawYAML string) *Scenario {
+	t.Helper()
+	rawJSON, err := shorthand.ToJSON([]byte(rawYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenario, err := ParseScenario(rawJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scenario
+}
+
+// twoEventsScenario has two events at the start of turn 3, which together make up a third of the goal
+const twoEventsScenario = `
+turns: 3
+actions_per_turn: 2
+start: 5w
+goal: 9r
+commands:
+  srt: w r
+  big: 4w 3r
+  power: w
+events:
+  - {turn: 3, delta: 1r}
+  - {turn: 3, delta: 2r}
+turn_must_end_above: ""
+turn_must_end_below: ""
+`
+
+func TestHeuristicCountsEveryEventInATurn(t *testing.T) {
+	scenario := parseTestScenario(t, twoEventsScenario)
+	start := StartSequence(scenario)
+	shortest := EstimateDifficulty(start, 0).GoalDepth
+	found := EnumerateSolutions(start, shortest, 1000)
+	if len(found) == 0 {
+		t.Fatal("found no solutions")
+	}
+	for _, solution := range found {
+		for _, step := range append([]*Sequence{start}, solution.Steps()...) {
+			if remaining := int(solution.Size - step.Size); step.Heuristic() > remaining {
+				t.Errorf("after %q the heuristic is %d, but %s needs only %d more actions", step.CommandSequence(), step.Heuristic(), solution.CommandSequence(), remaining)
+			}
+		}
+	}
+}
+
+func TestBestFirstFindsTheBestScore(t *testing.T) {
+	scenario := parseTestScenario(t, twoEventsScenario)
+	serial, err := Solve(scenario, Options{Engine: "serial", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bestFirst, err := Solve(scenario, Options{Engine: "best-first", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serial) == 0 || len(bestFirst) == 0 {
+		t.Fatalf("found %d solutions serially and %d best-first", len(serial), len(bestFirst))
+	}
+	if serial[0].Score != bestFirst[0].Score {
+		t.Errorf("best-first found %s (score %d), not %s (score %d)", bestFirst[0].Sequence.CommandSequence(), bestFirst[0].Score, serial[0].Sequence.CommandSequence(), serial[0].Score)
+	}
+}
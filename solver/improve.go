@@ -0,0 +1,44 @@
+package solver
+
+import (
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+)
+
+// Improve keeps searching for plans better than best (see Rank) until the deadline, calling onImprove
+// with each one found.  Unlike the main search this considers plans of every length, so a longer plan
+// can win if the ranking favors it.  Returns true if the whole search space was explored before the
+// deadline, in which case the last plan reported is the best there is.
+func Improve(start *Sequence, best *Sequence, deadline time.Time, onImprove func(*Sequence)) bool {
+	visited := map[SearchState]bool{} // Plans reaching the same state have the same score & future
+	expanded := 0
+	expired := false
+	var visit func(seq *Sequence)
+	visit = func(seq *Sequence) {
+		if expired {
+			return
+		}
+		if expanded++; expanded%1024 == 0 && time.Now().After(deadline) {
+			expired = true
+			return
+		}
+		key := seq.State()
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		if seq.IsFound() {
+			if seq.isBetterThan(best) {
+				best = seq
+				onImprove(seq)
+			}
+			return
+		}
+		seq.Search(func(s parallelsearch.Searchable) {
+			visit(s.(*Sequence))
+		})
+	}
+	visit(start)
+	return !expired
+}
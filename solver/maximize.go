@@ -0,0 +1,64 @@
+package solver
+
+// MaximizeResource finds the plans meeting the goal (if any) which finish with the most of the
+// scenario's Maximize resource, rather than stopping as soon as the goal is met.  The depth-first
+// search is pruned whenever even the most productive remaining actions could not beat the best plan
+// found so far (branch and bound).  Commands which served earlier solutions well are tried first (see
+// componentCache), since a good plan found early prunes more.  Up to limit plans tied for the best
+// value are returned.
+func MaximizeResource(start *Sequence, limit int) []*Sequence {
+	scenario := start.scenario
+	resource, _ := ResourceNamed(scenario.Maximize)
+
+	// The most any one action (or the start of a turn) can add to the resource
+	gainPerAction, gainPerTurn := scenario.raise.Get(resource), 0
+	for _, cost := range scenario.turnCosts() {
+		if gain := cost.Get(resource) + scenario.turnEffectOn(resource); gain > gainPerTurn {
+			gainPerTurn = gain
+		}
+	}
+	gainPerAction -= gainPerTurn // Included in raise, but counted separately below
+	bound := func(seq *Sequence) int {
+		value := seq.Resources.Get(resource)
+		remaining := scenario.TotalActions() - seq.Size
+		turnsRemaining := scenario.Turns - seq.Turn()
+		if resource == Crew && turnsRemaining > 0 && value < scenario.Start.Get(Crew) {
+			value = scenario.Start.Get(Crew) // Crew is replenished each turn
+		}
+		return value + int(remaining)*gainPerAction + int(turnsRemaining)*gainPerTurn
+	}
+
+	commands := searchCache.commandOrder(scenario)
+	found := []*Sequence{}
+	best := 0
+	visited := map[SearchState]bool{}
+	var visit func(seq *Sequence)
+	visit = func(seq *Sequence) {
+		key := seq.State()
+		if visited[key] || (len(found) > 0 && bound(seq) < best) {
+			return
+		}
+		visited[key] = true
+		if seq.IsSuccess() {
+			value := seq.Resources.Get(resource)
+			if len(found) == 0 || value > best {
+				found, best = []*Sequence{seq}, value
+			} else if value == best && len(found) < limit {
+				found = append(found, seq)
+			}
+		}
+		if seq.hasMoreActionsAvailable() {
+			for _, command := range commands {
+				for _, member := range seq.freeCrew() {
+					if next, violated := seq.StepAs(command, member); violated == nil {
+						visit(next)
+					}
+				}
+			}
+		}
+	}
+	visit(start)
+	searchCache.addSolutions(found)
+	Rank(found)
+	return found
+}
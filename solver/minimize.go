@@ -0,0 +1,35 @@
+package solver
+
+// replay takes the given commands in order starting from this sequence, returning nil if any of them
+// is illegal
+func (self *Sequence) replay(commands []*Command) *Sequence {
+	seq := self
+	for _, command := range commands {
+		if !seq.hasMoreActionsAvailable() {
+			return nil
+		}
+		if seq = seq.AttemptAction(command); seq == nil {
+			return nil
+		}
+	}
+	return seq
+}
+
+// Minimize removes redundant actions from a solution: any action whose removal still leaves a legal
+// plan which meets the goal is dropped, repeatedly, until every remaining action is needed.  Engines
+// which do not search shortest-first can otherwise return padded plans.
+func Minimize(solution *Sequence) *Sequence {
+	origin := solution.Origin()
+	commands := solution.Commands()[origin.Size:]
+	for i := 0; i < len(commands); {
+		without := append(append([]*Command{}, commands[:i]...), commands[i+1:]...)
+		if seq := origin.replay(without); seq != nil && seq.IsSuccess() {
+			commands = without
+			solution = seq
+			i = 0
+		} else {
+			i++
+		}
+	}
+	return solution
+}
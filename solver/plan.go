@@ -0,0 +1,147 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// planSeparator splits a plan into command names.  Plans may be typed one command per line or pasted
+// from the solver's own output (e.g. "[ 1 ] MR -> GCC -> SRT").  In a crewed mission each name may be
+// followed by the crew member performing it (e.g. "MR@Aldrin").
+var planSeparator = regexp.MustCompile(`(\[[^\]]*\]|->|,|\s)+`)
+
+func ParsePlan(text string) []string {
+	names := []string{}
+	for _, name := range planSeparator.Split(text, -1) {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ReplayPlan takes each named action in turn, calling onStep after each legal one.  The returned
+// error explains exactly why the plan is illegal or falls short of the goal.
+func ReplayPlan(scenario *Scenario, names []string, onStep func(*Sequence)) (*Sequence, error) {
+	seq := StartSequence(scenario)
+	for _, name := range names {
+		command, member, err := seq.findAction(name)
+		if err != nil {
+			return seq, err
+		}
+		if !seq.hasMoreActionsAvailable() {
+			return seq, fmt.Errorf("plan exceeds %d actions", scenario.TotalActions())
+		}
+		next, violated := seq.StepAs(command, member)
+		if violated != nil {
+			return seq, fmt.Errorf("at turn %d, action %d (%s): %s", next.Turn(), next.Action(), next.CommandName(), violated.Describe(next))
+		}
+		seq = next
+		if onStep != nil {
+			onStep(seq)
+		}
+	}
+	if !seq.IsSuccess() {
+		return seq, errors.New("plan ends without meeting the goal: " + seq.GoalShortfall())
+	}
+	return seq, nil
+}
+
+// ResumePlan rebuilds a game in progress from the actions already taken (named as in ReplayPlan), so
+// that a search from it covers only the actions remaining, with the history the rules depend on
+// (bonuses, usage limits, and crew) intact.  The resources actually seen in-game (if known) replace
+// those the actions should have left, and since the game may have diverged from the plan (e.g. an
+// action failed) actions are then taken even if the rules say they were impossible.
+func ResumePlan(scenario *Scenario, names []string, observed *Resources) (*Sequence, error) {
+	seq := StartSequence(scenario)
+	for _, name := range names {
+		command, member, err := seq.findAction(name)
+		if err != nil {
+			return seq, err
+		}
+		if !seq.hasMoreActionsAvailable() {
+			return seq, fmt.Errorf("%d actions exceeds the %d available", len(names), scenario.TotalActions())
+		}
+		next, violated := seq.StepAs(command, member)
+		if violated != nil && observed == nil {
+			return seq, fmt.Errorf("at turn %d, action %d (%s): %s", next.Turn(), next.Action(), next.CommandName(), violated.Describe(next))
+		}
+		seq = next
+	}
+	if observed != nil {
+		seq = seq.Observe(*observed)
+	}
+	return seq, nil
+}
+
+// PlanStep is the outcome of one action of a plan checked by AuditPlan
+type PlanStep struct {
+	Name     string    // As given in the plan
+	Sequence *Sequence // After the action (nil if it could not be taken at all)
+	Change   Resources // How the action changed the resources
+	Problems []string  // Every rule the action broke, or why it could not be taken at all
+}
+
+// AuditPlan takes each named action (as ReplayPlan) from start, describing every rule each one
+// breaks: which resource went negative (spending the input, or after the output was added), which
+// turn bound failed, and so on.  Ordinarily the audit stops at the first illegal action; forced, it takes
+// every action regardless (skipping only those which can't be taken at all, such as unknown commands)
+// so that a whole plan written by hand can be checked at once.
+func AuditPlan(start *Sequence, names []string, force bool) []*PlanStep {
+	steps := []*PlanStep{}
+	seq := start
+	for _, name := range names {
+		step := &PlanStep{Name: name}
+		steps = append(steps, step)
+		command, member, err := seq.findAction(name)
+		if err == nil && !seq.hasMoreActionsAvailable() {
+			err = fmt.Errorf("the plan exceeds %d actions", seq.scenario.TotalActions())
+		}
+		if err != nil {
+			step.Problems = append(step.Problems, err.Error())
+		} else {
+			next := &Sequence{}
+			seq.spend(next, command, member)
+			broken := map[Constraint]bool{}
+			for _, violated := range next.Violations() {
+				broken[violated] = true
+				step.Problems = append(step.Problems, violated.Describe(next))
+			}
+			next.produce()
+			for _, violated := range next.Violations() {
+				if !broken[violated] {
+					step.Problems = append(step.Problems, violated.Describe(next))
+				}
+			}
+			step.Change = next.Resources
+			step.Change.subtract(&seq.Resources)
+			step.Sequence, seq = next, next
+		}
+		if len(step.Problems) > 0 && !force {
+			break
+		}
+	}
+	return steps
+}
+
+// findAction looks up a named action (e.g. "gcc" or "GCC@Aldrin") to take next, along with the crew
+// member performing it
+func (self *Sequence) findAction(name string) (*Command, *CrewMember, error) {
+	name, performer, crewed := strings.Cut(name, "@")
+	command := self.scenario.FindCommand(name)
+	if command == nil {
+		command = self.scenario.FindCommand(strings.ToLower(name))
+	}
+	if command == nil {
+		return nil, nil, errors.New("unknown command: " + name)
+	}
+	member := self.freeCrew()[0]
+	if crewed {
+		if member = self.scenario.FindCrewMember(performer); member == nil {
+			return nil, nil, errors.New("unknown crew member: " + performer)
+		}
+	}
+	return command, member, nil
+}
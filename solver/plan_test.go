@@ -0,0 +1,42 @@
+package solver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResumePlan(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 2
+actions_per_turn: 3
+start: 6w
+goal: 3r
+commands:
+  srt: {input: w, output: r, max_uses_per_turn: 2}
+  pl: w p
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	observed := Resources{}
+	observed.Set(Power, 2) // Less than planned
+	observed.Set(Comm, 2)
+	start, err := ResumePlan(scenario, ParsePlan("SRT SRT"), &observed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start.Size != 2 || start.Turn() != 1 || start.Resources != observed {
+		t.Fatalf("resumed at action %d of turn %d with %v", start.Action(), start.Turn(), start.Resources)
+	}
+
+	found, err := Solve(scenario, Options{Engine: "serial", Start: start, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) == 0 || strings.Join(found[0].Commands, " ") != "srt srt pl srt" {
+		t.Errorf("solved as %v, want the rest to be pl srt (srt having been taken twice this turn already)", found)
+	}
+
+	if _, err := ResumePlan(scenario, ParsePlan("SRT SRT SRT"), nil); err == nil {
+		t.Error("resumed an illegal plan without observing the resources")
+	}
+}
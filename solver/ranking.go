@@ -0,0 +1,58 @@
+package solver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// objective is one criterion of a lexicographic ranking of solutions.  It maps a sequence to a value
+// where lower is better.
+type objective func(seq *Sequence) int
+
+// parseObjectives compiles objectives such as "shortest", "max:power", or "min:radiation" (as well as
+// "score", meaning the scenario's Scorer, and "reliable", preferring the plans most likely to succeed
+// despite failed actions, see SuccessProbability)
+func parseObjectives(specs []string) ([]objective, error) {
+	objectives := []objective{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		direction, name, _ := strings.Cut(spec, ":")
+		resource, isResource := ResourceNamed(name)
+		switch {
+		case spec == "shortest":
+			objectives = append(objectives, func(seq *Sequence) int { return int(seq.Size) })
+		case spec == "score":
+			objectives = append(objectives, func(seq *Sequence) int { return seq.Score() })
+		case spec == "bonuses":
+			objectives = append(objectives, func(seq *Sequence) int { return -seq.secondaryReward() })
+		case spec == "reliable":
+			objectives = append(objectives, func(seq *Sequence) int { return -int(seq.SuccessProbability() * 1e6) })
+		case direction == "max" && isResource:
+			objectives = append(objectives, func(seq *Sequence) int { return -seq.Resources.Get(resource) })
+		case direction == "min" && isResource:
+			objectives = append(objectives, func(seq *Sequence) int { return seq.Resources.Get(resource) })
+		default:
+			return nil, fmt.Errorf("invalid objective %q (expected shortest, score, bonuses, reliable, max:RESOURCE, or min:RESOURCE)", spec)
+		}
+	}
+	return objectives, nil
+}
+
+// Rank sorts sequences best first.  Scenarios which declare objectives are ranked by each objective
+// in turn (later objectives only breaking ties in earlier ones), others by score.
+func Rank(sequences []*Sequence) {
+	sort.SliceStable(sequences, func(i, j int) bool {
+		return sequences[i].isBetterThan(sequences[j])
+	})
+}
+
+// isBetterThan compares two sequences by the order used in rank
+func (self *Sequence) isBetterThan(other *Sequence) bool {
+	for _, objective := range self.scenario.objectives {
+		if x, y := objective(self), objective(other); x != y {
+			return x < y
+		}
+	}
+	return len(self.scenario.objectives) == 0 && self.Score() < other.Score()
+}
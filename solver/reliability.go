@@ -0,0 +1,58 @@
+package solver
+
+// HasFailureRates is true if any command may fail (see Command.FailureRate)
+func (self *Scenario) HasFailureRates() bool {
+	for _, command := range self.Commands {
+		if command.FailureRate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SuccessProbability is the chance that this plan, followed to the letter, still meets the goal when
+// each action may fail (wasting its input, see Command.Failed) at its command's FailureRate.  An outcome
+// in which a failure leaves a later action impossible counts as the plan failing.  Plans with slack
+// (spare resources or actions beyond the goal) are the most likely to succeed.
+//
+// Every combination of failures is considered, but outcomes in the same state are merged as they go,
+// so this is quick for plans of any realistic length.
+func (self *Sequence) SuccessProbability() float64 {
+	origin := self.Origin()
+	outcomes := map[SearchState]*outcome{origin.State(): {origin, 1}}
+	commands, crew := self.Commands(), self.Crew()
+	for i := origin.Size; i < self.Size; i++ {
+		next := map[SearchState]*outcome{}
+		add := func(seq *Sequence, violated Constraint, probability float64) {
+			if violated != nil || probability == 0 {
+				return
+			}
+			if existing, ok := next[seq.State()]; ok {
+				existing.probability += probability
+			} else {
+				next[seq.State()] = &outcome{seq, probability}
+			}
+		}
+		command, member := commands[i], crew[i]
+		for _, o := range outcomes {
+			seq, violated := o.seq.StepAs(command, member)
+			add(seq, violated, o.probability*(1-command.FailureRate))
+			seq, violated = o.seq.StepAs(command.Failed(), member)
+			add(seq, violated, o.probability*command.FailureRate)
+		}
+		outcomes = next
+	}
+	success := 0.0
+	for _, o := range outcomes {
+		if o.seq.IsSuccess() {
+			success += o.probability
+		}
+	}
+	return success
+}
+
+// outcome is one way a plan may turn out, and how likely it is to
+type outcome struct {
+	seq         *Sequence
+	probability float64
+}
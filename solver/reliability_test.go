@@ -0,0 +1,49 @@
+package solver
+
+import (
+	"math"
+	"testing"
+)
+
+const unreliableScenario = `
+turns: 1
+actions_per_turn: 3
+start: 3w
+goal: 2r
+commands:
+  srt: {input: w, output: r, failure_rate: 0.5}
+turn_must_end_above: ""
+turn_must_end_below: ""
+`
+
+func TestSuccessProbability(t *testing.T) {
+	scenario := parseTestScenario(t, unreliableScenario)
+	for plan, want := range map[string]float64{
+		"SRT SRT":     0.25,
+		"SRT SRT SRT": 0.5, // Any two of the three succeeding
+	} {
+		seq, err := ReplayPlan(scenario, ParsePlan(plan), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := seq.SuccessProbability(); math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s succeeds with probability %g, want %g", plan, got, want)
+		}
+	}
+}
+
+func TestReliableObjectiveRanksSlackFirst(t *testing.T) {
+	scenario := parseTestScenario(t, unreliableScenario+"objectives: [reliable, shortest]\n")
+	plans := []*Sequence{}
+	for _, plan := range []string{"SRT SRT", "SRT SRT SRT"} {
+		seq, err := ReplayPlan(scenario, ParsePlan(plan), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plans = append(plans, seq)
+	}
+	Rank(plans)
+	if plans[0].Size != 3 {
+		t.Errorf("ranked %s first, want the plan with a spare action", plans[0].CommandSequence())
+	}
+}
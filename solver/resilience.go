@@ -0,0 +1,198 @@
+package solver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// resilientPlan follows a plan as intended (nominal) alongside every outcome in which exactly one of
+// its actions so far has failed.  Outcomes which arrive at the same resources have identical futures,
+// so each is only kept once.
+type resilientPlan struct {
+	nominal *Sequence
+	failed  []*Sequence
+}
+
+// Failed is what taking the command amounts to when it fails: its input is spent but it produces no
+// output
+func (self *Command) Failed() *Command {
+	failed := *self
+	failed.Output = Resources{}
+	failed.Bonus = nil
+	return &failed
+}
+
+// key identifies plans whose nominal and failed outcomes all have the same states
+func (self *resilientPlan) key() string {
+	outcomes := make([]string, len(self.failed))
+	for i, failed := range self.failed {
+		outcomes[i] = fmt.Sprintf("%d", failed.Resources) // Every resource (not just those shown by String)
+	}
+	sort.Strings(outcomes)
+	return fmt.Sprint(self.nominal.State(), outcomes)
+}
+
+// IsSuccess is true if the plan meets the goal whether or not any single action fails
+func (self *resilientPlan) isSuccess() bool {
+	if !self.nominal.IsSuccess() {
+		return false
+	}
+	for _, failed := range self.failed {
+		if !failed.IsSuccess() {
+			return false
+		}
+	}
+	return true
+}
+
+// extend takes the command next, returning nil if doing so is illegal in any outcome (including the
+// command itself failing)
+func (self *resilientPlan) extend(command *Command) *resilientPlan {
+	nominal := self.nominal.AttemptAction(command)
+	if nominal == nil {
+		return nil
+	}
+	failedNow := self.nominal.AttemptAction(command.Failed())
+	if failedNow == nil {
+		return nil
+	}
+	next := resilientPlan{nominal, []*Sequence{failedNow}}
+	seen := map[Resources]bool{failedNow.Resources: true}
+	for _, failed := range self.failed {
+		failed = failed.AttemptAction(command)
+		if failed == nil {
+			return nil
+		}
+		if !seen[failed.Resources] {
+			seen[failed.Resources] = true
+			next.failed = append(next.failed, failed)
+		}
+	}
+	return &next
+}
+
+// SolveResiliently is a breadth-first search for the shortest plans which still meet the goal if any
+// single one of their actions fails.  Unlike the ordinary search this does not stop at the goal, since
+// a resilient plan usually needs spare actions beyond it.  Up to limit plans are returned, best first.
+func SolveResiliently(start *Sequence, limit int) []*Sequence {
+	found := []*Sequence{}
+	frontier := []*resilientPlan{{nominal: start}}
+	for len(frontier) > 0 && len(found) == 0 {
+		next := []*resilientPlan{}
+		seen := map[string]bool{}
+		for _, plan := range frontier {
+			if !plan.nominal.hasMoreActionsAvailable() {
+				continue
+			}
+			for i := range start.scenario.Commands {
+				command := start.scenario.Commands[i]
+				extended := plan.extend(&command)
+				if extended == nil || seen[extended.key()] {
+					continue
+				}
+				seen[extended.key()] = true
+				if extended.isSuccess() {
+					found = append(found, extended.nominal)
+				} else {
+					next = append(next, extended)
+				}
+			}
+		}
+		frontier = next
+	}
+	Rank(found)
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// fragileActions replays a plan with each of its actions failing in turn, returning the names of
+// those whose failure leaves the plan illegal or short of the goal
+func fragileActions(solution *Sequence) []string {
+	fragile := []string{}
+	origin := solution.Origin()
+	commands := solution.Commands()[origin.Size:]
+	for i, command := range commands {
+		failed := solution.Ancestor(origin.Size + uint32(i)).AttemptAction(command.Failed())
+		if failed != nil {
+			failed = failed.replay(commands[i+1:])
+		}
+		if failed == nil || !failed.IsSuccess() {
+			fragile = append(fragile, fmt.Sprint(origin.Size+uint32(i)+1, ".", strings.ToUpper(command.Name)))
+		}
+	}
+	return fragile
+}
+
+// DescribeResilience summarizes how a plan fares against single failed actions
+func DescribeResilience(solution *Sequence) string {
+	if fragile := fragileActions(solution); len(fragile) > 0 {
+		return Colorize("red", "Fragile:") + " the goal is missed if any of these actions fail: " + strings.Join(fragile, " ")
+	}
+	return Colorize("green", "Resilient:") + " the goal is met even if any single action fails"
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Robustness is how many of a plan's actions could fail (see Command.Failed) and the plan still be
+// repaired: some plan reaches the goal from the moment of failure, in no more actions than the
+// original had left
+type Robustness struct {
+	Repairable int
+	Actions    int
+}
+
+// Robustness re-plans from each of this plan's actions failing in turn (see Robustness).  Unlike
+// fragileActions, the rest of the plan may change to make up for the failure.
+func (self *Sequence) Robustness() Robustness {
+	robustness := Robustness{}
+	origin := self.Origin()
+	commands, crew := self.Commands(), self.Crew()
+	for i := origin.Size; i < self.Size; i++ {
+		robustness.Actions++
+		failed, violated := self.Ancestor(i).StepAs(commands[i].Failed(), crew[i])
+		if violated == nil && len(solveSeriallyWithin(failed, 1, int(self.Size-failed.Size))) > 0 {
+			robustness.Repairable++
+		}
+	}
+	return robustness
+}
+
+// isMoreRobustThan compares the fractions of actions which may fail, a plan of no actions having
+// none which can
+func (self Robustness) isMoreRobustThan(other Robustness) bool {
+	if self.Actions == 0 || other.Actions == 0 {
+		return self.Actions == 0 && other.Actions > 0
+	}
+	return self.Repairable*other.Actions > other.Repairable*self.Actions
+}
+
+func (self Robustness) String() string {
+	color := "green"
+	if self.Repairable < self.Actions {
+		color = "yellow"
+	}
+	if self.Repairable == 0 && self.Actions > 0 {
+		color = "red"
+	}
+	return Colorize(color, "Robustness: ", self.Repairable, " of ", self.Actions) + " actions could fail and the plan still be repaired in time"
+}
+
+// RankByRobustness sorts sequences by their robustness, most robust first (keeping the existing
+// order of those equally robust), and returns the robustness of each in their new order
+func RankByRobustness(sequences []*Sequence) []Robustness {
+	robustness := map[*Sequence]Robustness{}
+	for _, seq := range sequences {
+		robustness[seq] = seq.Robustness()
+	}
+	sort.SliceStable(sequences, func(i, j int) bool {
+		return robustness[sequences[i]].isMoreRobustThan(robustness[sequences[j]])
+	})
+	ranked := make([]Robustness, len(sequences))
+	for i, seq := range sequences {
+		ranked[i] = robustness[seq]
+	}
+	return ranked
+}
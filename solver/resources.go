@@ -0,0 +1,227 @@
+package solver
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Resource identifies one of the resources in the Mars Horizons mini-game, as an index into Resources
+type Resource int
+
+const (
+	Comm Resource = iota
+	Data
+	Nav
+	Power
+	Drift
+	Heat
+	Thrust
+	Crew
+	Radiation
+	resourceCount
+)
+
+// Resources represents a state or goal in the Mars Horizons mini-game.  Each resource is held as an
+// int16 (saturating rather than overflowing, see Set), which keeps Sequences small enough that deep
+// searches allocate far less.
+type Resources [resourceCount]int16
+
+var (
+	NoLowerBound = uniformResources(math.MinInt16)
+	NoUpperBound = uniformResources(math.MaxInt16)
+)
+
+// IsBounded distinguishes real limits from the "infinite" placeholders used for an unspecified bound
+// (see NoLowerBound and NoUpperBound), allowing for a little arithmetic on the placeholders
+func IsBounded(bound int) bool {
+	return bound > -1<<14 && bound < 1<<14
+}
+
+func uniformResources(value int16) Resources {
+	return Resources{value, value, value, value, value, value, value, value, value}
+}
+
+var (
+	ResourceNames = []string{"comm", "data", "nav", "power", "drift", "heat", "thrust", "crew", "radiation"}
+	AllResources  = []Resource{Comm, Data, Nav, Power, Drift, Heat, Thrust, Crew, Radiation}
+)
+
+// ResourceNamed returns the resource with the given name (as spelled in scenario files), if there is
+// one
+func ResourceNamed(name string) (Resource, bool) {
+	for i, resourceName := range ResourceNames {
+		if name == resourceName {
+			return Resource(i), true
+		}
+	}
+	return 0, false
+}
+
+func (self Resource) String() string {
+	return ResourceNames[self]
+}
+
+// Get returns the given resource
+func (self *Resources) Get(resource Resource) int {
+	return int(self[resource])
+}
+
+// Set changes the given resource, saturating at the limits of an int16 (so that a bound of ±2^62,
+// as the shorthand package writes for an unspecified one, remains unbounded)
+func (self *Resources) Set(resource Resource, value int) {
+	self[resource] = int16(max(min(value, math.MaxInt16), math.MinInt16))
+}
+
+// MarshalJSON implements json.Marshaler, writing the resources as an object keyed by name.  The
+// placeholders for an unspecified bound are written as the shorthand package writes them (as they
+// were read before resources were held as int16s), so that scenario hashes are unchanged.
+func (self Resources) MarshalJSON() ([]byte, error) {
+	raw := []byte{'{'}
+	for i, value := range self {
+		if i > 0 {
+			raw = append(raw, ',')
+		}
+		raw = append(raw, '"')
+		raw = append(raw, strings.ToUpper(ResourceNames[i][:1])...)
+		raw = append(raw, ResourceNames[i][1:]...)
+		raw = append(raw, '"', ':')
+		switch value {
+		case math.MinInt16:
+			raw = strconv.AppendInt(raw, math.MinInt, 10)
+		case math.MaxInt16:
+			raw = strconv.AppendInt(raw, 1<<62, 10)
+		default:
+			raw = strconv.AppendInt(raw, int64(value), 10)
+		}
+	}
+	return append(raw, '}'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading an object keyed by (case-insensitive) name.
+// Any resource omitted is left unchanged.
+func (self *Resources) UnmarshalJSON(data []byte) error {
+	raw := map[string]int{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for name, value := range raw {
+		resource, ok := ResourceNamed(strings.ToLower(name))
+		if !ok {
+			return fmt.Errorf("json: unknown field %q", name)
+		}
+		self.Set(resource, value)
+	}
+	return nil
+}
+
+// ParseResourceAssignments applies assignments such as "power=2 data=5 drift=-1" (separated by spaces
+// or commas) to a copy of the given resources
+func ParseResourceAssignments(base Resources, assignments string) (Resources, error) {
+	for _, assignment := range strings.FieldsFunc(assignments, func(r rune) bool { return r == ' ' || r == ',' }) {
+		name, value, ok := strings.Cut(assignment, "=")
+		resource, known := ResourceNamed(strings.ToLower(name))
+		if !ok || !known {
+			return base, fmt.Errorf("invalid resource assignment %q (expected e.g. power=2)", assignment)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return base, fmt.Errorf("invalid resource assignment %q (expected e.g. power=2)", assignment)
+		}
+		base.Set(resource, n)
+	}
+	return base, nil
+}
+
+// add adds the other resources to these (saturating, as Set)
+func (self *Resources) add(other *Resources) {
+	for i := range self {
+		self[i] = saturate(int32(self[i]) + int32(other[i]))
+	}
+}
+
+// subtract subtracts the other resources from these (saturating, as Set)
+func (self *Resources) subtract(other *Resources) {
+	for i := range self {
+		self[i] = saturate(int32(self[i]) - int32(other[i]))
+	}
+}
+
+func saturate(value int32) int16 {
+	return int16(max(min(value, math.MaxInt16), math.MinInt16))
+}
+
+func (self *Resources) within(lowerBound *Resources, upperBound *Resources) bool {
+	for i, value := range self {
+		if value < lowerBound[i] || value > upperBound[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// clamp lowers any resource above its upper bound to that bound
+func (self *Resources) clamp(upperBound *Resources) {
+	for i, bound := range upperBound {
+		if self[i] > bound {
+			self[i] = bound
+		}
+	}
+}
+
+func (self *Resources) atMost(upperBound *Resources) bool {
+	for i, value := range self {
+		if value > upperBound[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Change describes the resources as a change in each, e.g. "+2 comm, -1 power" (or "none")
+func (self *Resources) Change() string {
+	changes := []string{}
+	for _, resource := range AllResources {
+		if value := self.Get(resource); value != 0 {
+			changes = append(changes, fmt.Sprintf("%+d %s", value, resource))
+		}
+	}
+	if len(changes) == 0 {
+		return "none"
+	}
+	return strings.Join(changes, ", ")
+}
+
+func (self Resources) String() string {
+	e := []string{}
+	if self[Comm] > 0 {
+		e = append(e, "comm: "+Colorize("red", self[Comm]))
+	}
+	if self[Data] > 0 {
+		e = append(e, "data: "+Colorize("cyan", self[Data]))
+	}
+	if self[Nav] > 0 {
+		e = append(e, "nav: "+Colorize("magenta", self[Nav]))
+	}
+	if self[Power] > 0 {
+		e = append(e, "power: "+Colorize("yellow", self[Power]))
+	}
+	if self[Drift] != 0 {
+		e = append(e, "drift: "+Colorize("green", self[Drift]))
+	}
+	if self[Heat] > 0 {
+		e = append(e, "heat: "+Colorize("red", self[Heat]))
+	}
+	if self[Thrust] > 0 {
+		e = append(e, "thrust: "+Colorize("white", self[Thrust]))
+	}
+	if self[Crew] > 0 {
+		e = append(e, "crew: "+Colorize("white", self[Crew]))
+	}
+	if self[Radiation] > 0 {
+		e = append(e, "radiation: "+Colorize("green", self[Radiation]))
+	}
+	return strings.Join(e[:], " | ")
+}
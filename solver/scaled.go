@@ -0,0 +1,53 @@
+package solver
+
+import (
+	"fmt"
+)
+
+// Scale is the range of multiples a command may be taken at, its input and output both multiplied
+// (e.g. "spend up to 3 power, gaining that much thrust" is an input of 1 power and an output of 1
+// thrust, from 1 to 3 times over).  The multiple is chosen as the plan is searched.
+type Scale struct {
+	Min uint32
+	Max uint32
+}
+
+// expandScaled replaces each command which may be scaled with a command of its own for each multiple,
+// named e.g. "convert*2" ("convert" alone at a multiple of 1), so that the search need know nothing
+// of scaling.  Each remembers the command it was scaled from (see baseName), whose limits on uses
+// (see UsageConstraint) they share, and whose name stages and bonuses refer to.  Preparing the
+// scenario again leaves the expanded commands as they are.
+func (self *Scenario) expandScaled() error {
+	commands := []Command{}
+	for _, command := range self.Commands {
+		if command.Scale == nil {
+			commands = append(commands, command)
+			continue
+		}
+		if command.Scale.Min < 1 || command.Scale.Max < command.Scale.Min {
+			return fmt.Errorf("command %s: scale must be from at least 1, to no less than that (not %d to %d)", command.Name, command.Scale.Min, command.Scale.Max)
+		}
+		for multiple := command.Scale.Min; multiple <= command.Scale.Max; multiple++ {
+			scaled := command
+			scaled.Scale, scaled.ScaledFrom = nil, command.Name
+			if multiple > 1 {
+				scaled.Name = fmt.Sprint(command.Name, "*", multiple)
+			}
+			for _, resource := range AllResources {
+				scaled.Input.Set(resource, scaled.Input.Get(resource)*int(multiple))
+				scaled.Output.Set(resource, scaled.Output.Get(resource)*int(multiple))
+			}
+			commands = append(commands, scaled)
+		}
+	}
+	self.Commands = commands
+	return nil
+}
+
+// baseName is the name of the command this is a multiple of (see Scale), or else its own
+func (self *Command) baseName() string {
+	if self.ScaledFrom != "" {
+		return self.ScaledFrom
+	}
+	return self.Name
+}
@@ -0,0 +1,637 @@
+package solver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/shorthand"
+)
+
+// Command is an action that can be taken that requires certain input and produces certain output
+type Command struct {
+	Name        string
+	Input       Resources
+	Output      Resources
+	Category    string // Optional, e.g. "science" or "crew"
+	Description string // Optional
+	Icon        string // Optional
+	Risky       bool   // Optional, for actions with bad odds in-game (see AvoidRisky)
+	Bonus       *Bonus // Optional
+
+	// Optional chance (from 0 to 1) that the action fails in-game, see SuccessProbability
+	FailureRate float64 `json:"failure_rate,omitempty"`
+
+	// Optional limits on how often the command may be taken in a turn and in the whole mission (0 for
+	// no limit), see UsageConstraint
+	MaxUsesPerTurn int `json:"max_uses_per_turn"`
+	MaxUsesTotal   int `json:"max_uses_total"`
+
+	// Optional first and last turns in which the command may be taken (0 for no limit), e.g. a launch
+	// burn only on turn 1, see AvailabilityConstraint
+	AvailableFromTurn  uint32 `json:"available_from_turn,omitempty"`
+	AvailableUntilTurn uint32 `json:"available_until_turn,omitempty"`
+
+	// Optional number of turns after this one for which the crew the command spends stays exhausted,
+	// rather than being replenished at the start of the next turn (negative for the rest of the mission)
+	CrewLock int `json:"crew_lock,omitempty"`
+
+	// Optional range of multiples the command may be taken at (see Scale), and the name of the command
+	// this one is a multiple of, once the scenario is prepared (see expandScaled)
+	Scale      *Scale `json:",omitempty"`
+	ScaledFrom string `json:"scaled_from,omitempty"`
+}
+
+// Bonus is extra output a command earns in-game when taken immediately after another command (e.g.
+// "+1 data if run after Transmit")
+type Bonus struct {
+	After  string // Name of the command which must be taken just before
+	Output Resources
+}
+
+// CrewMember is an astronaut aboard a crewed mission, whose skill adds to the output of every action
+// they perform.  Each crew member may act only once per turn (see CrewConstraint).
+type CrewMember struct {
+	Name  string
+	Bonus Resources
+}
+
+// isLimited is true if the command may only be taken a limited number of times
+func (self *Command) isLimited() bool {
+	return self.MaxUsesPerTurn > 0 || self.MaxUsesTotal > 0
+}
+
+// isAvailableIn is true if the command may be taken in the given (1-based) turn
+func (self *Command) isAvailableIn(turn uint32) bool {
+	return turn >= self.AvailableFromTurn && (self.AvailableUntilTurn == 0 || turn <= self.AvailableUntilTurn)
+}
+
+// earnsBonusAfter is true if taking this command right after the previous one (nil at the start)
+// earns its bonus
+func (self *Command) earnsBonusAfter(previous *Command) bool {
+	return self.Bonus != nil && previous != nil && previous.baseName() == self.Bonus.After
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Event is a change to the resources which happens in-game at the start of a turn regardless of the
+// actions taken (e.g. "solar flare: +2 radiation at turn 3")
+type Event struct {
+	Name  string // Optional
+	Turn  uint32
+	Delta Resources
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TurnEffect is a change to one resource which happens in-game at the start of every turn (after the
+// first, once any turn cost is paid) regardless of the actions taken, e.g. heat dissipating by 1 or
+// the vehicle drifting by 2.  A Floor (e.g. 0 for heat) stops the change taking the resource below it,
+// though a resource already below it is left where it is.
+type TurnEffect struct {
+	Resource string // As named in scenario files
+	Change   int
+	Floor    *int     `json:",omitempty"`
+	resource Resource // The resource named (see Prepare)
+}
+
+// apply is the value of the resource once the effect has happened
+func (self *TurnEffect) apply(value int) int {
+	changed := value + self.Change
+	if self.Floor != nil && changed < *self.Floor && changed < value {
+		return min(value, *self.Floor)
+	}
+	return changed
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Scenario is a specific Mars Horizons mini-game scenario with a starting set of resources, a set of
+// commands, and a desired goal
+type Scenario struct {
+	Turns            uint32
+	ActionsPerTurn   uint32   `json:"actions_per_turn"`
+	ActionsSchedule  []uint32 `json:"actions_schedule"` // Optional actions for each turn (from the first), overriding ActionsPerTurn
+	Start            Resources
+	Goal             Resources
+	GoalMin          *Resources      `json:"goal_min,omitempty"`        // Optional lowest each resource may finish at, in addition to Goal
+	GoalMax          *Resources      `json:"goal_max,omitempty"`        // Optional highest each resource may finish at
+	GoalConditions   []string        `json:"goal_conditions,omitempty"` // Optional comparisons replacing what Goal asks of a resource, e.g. ["drift <= -2"]
+	GoalAnyOf        []Resources     `json:"goal_any_of,omitempty"`     // Optional alternatives in place of Goal, reaching any one of which is success (see Goals)
+	SecondaryGoals   []SecondaryGoal `json:"secondary_goals,omitempty"` // Optional bonus objectives, preferred but not required
+	Commands         []Command
+	TurnCost         Resources         `json:"turn_cost"`
+	TurnEffects      []TurnEffect      `json:"turn_effects,omitempty"` // Optional, applied at the start of every turn after the turn cost
+	TurnMustEndAbove Resources         `json:"turn_must_end_above"`
+	TurnMustEndBelow Resources         `json:"turn_must_end_below"`
+	TurnEndMin       *Resources        `json:"turn_end_min,omitempty"` // Optional lowest each resource may end a turn at (unlike TurnMustEndAbove, inclusive)
+	TurnEndMax       *Resources        `json:"turn_end_max,omitempty"` // Optional highest each resource may end a turn at (inclusive)
+	FailAbove        *Resources        `json:"fail_above,omitempty"`   // Optional most of each resource (e.g. radiation) before the mission fails, checked after every action
+	Events           []Event           // Optional, applied before the first action of their turn
+	Stages           []Stage           `json:",omitempty"` // Optional phases of the mission, in place of turns and actions_schedule
+	Crew             []CrewMember      `json:",omitempty"` // Optional, in which case each action is performed by one of them
+	Caps             *Resources        `json:",omitempty"` // Optional most of each resource which can be held (see Overflow)
+	Overflow         map[string]string `json:",omitempty"` // For each capped resource: "clamp" (the default) to lose any excess, or "invalid" if it may not be exceeded
+	Constraints      []string          // Custom rules, see ExpressionConstraint
+	ScoreWeights     *ScoreWeights     `json:"score_weights"`
+	Scorer           Scorer            `json:"-"` // Defaults to ScoreWeights
+	Objectives       []string          // Optional lexicographic ranking, e.g. ["shortest", "max:power"]
+	Maximize         string            // Optional resource to finish with as much of as possible (see MaximizeResource)
+	constraints      []Constraint
+	objectives       []objective
+	registry         *CommandRegistry
+	rulesKey         string // See componentKeys
+	goalKey          string
+	raise            Resources // See actionLimits
+	lower            Resources
+	goalLowers       []Resources // The bounds of each goal (see goalBounds)
+	goalUppers       []Resources
+	secondaryLower   []Resources // The bounds of each secondary goal (see prepareSecondaryGoals)
+	secondaryUpper   []Resources
+	clampAt          Resources // See Caps
+	hasClamps        bool
+	hasBonuses       bool        // See SearchState
+	hasCrewLocks     bool        // See Command.CrewLock
+	longestCrewLock  uint32      // The most turns any command exhausts crew for (but not for the rest of the mission)
+	hasWindows       bool        // If any command is only available in some turns (or stages)
+	stageOf          []int       // The stage (by index) of each turn (from 0, i.e. none), see Stages
+	stageLower       []Resources // The goal bounds of each stage
+	stageUpper       []Resources
+	earliestSuccess  uint32   // The fewest actions a successful sequence may have taken (to reach the last stage)
+	limited          []string // Names of the commands which may only be taken a limited number of times
+	turnEnds         []uint32 // Actions taken by the end of each turn (from turn 0)
+	turnOf           []uint32 // The turn in which each action (from 0, i.e. none) is taken
+}
+
+// Prepare builds the constraints every sequence in this scenario must obey (as well as other derived
+// state).  It must be called once after the scenario is loaded and before any searching.
+func (self *Scenario) Prepare() error {
+	if err := self.expandScaled(); err != nil {
+		return err
+	}
+	if err := self.prepareStages(); err != nil {
+		return err
+	}
+	if len(self.ActionsSchedule) > int(self.Turns) {
+		return fmt.Errorf("actions_schedule lists %d turns, but there are only %d", len(self.ActionsSchedule), self.Turns)
+	}
+	self.turnEnds, self.turnOf = []uint32{0}, []uint32{0}
+	for turn := uint32(1); turn <= self.Turns; turn++ {
+		actions := self.ActionsIn(turn)
+		if actions == 0 && int(turn) <= len(self.ActionsSchedule) {
+			return fmt.Errorf("actions_schedule must allow at least 1 action in turn %d", turn)
+		} else if actions == 0 {
+			return errors.New("actions_per_turn must be at least 1")
+		}
+		self.turnEnds = append(self.turnEnds, self.turnEnds[turn-1]+actions)
+		for i := uint32(0); i < actions; i++ {
+			self.turnOf = append(self.turnOf, turn)
+		}
+	}
+	self.earliestSuccess = 0
+	if len(self.Stages) > 0 {
+		self.earliestSuccess = self.turnEnds[self.Turns-self.Stages[len(self.Stages)-1].Turns] + 1
+	}
+	for _, event := range self.Events {
+		if event.Turn < 1 || event.Turn > self.Turns {
+			return fmt.Errorf("event %q is at turn %d, outside turns 1 to %d", event.Name, event.Turn, self.Turns)
+		}
+	}
+	for _, source := range self.GoalConditions {
+		if _, err := parseGoalCondition(source); err != nil {
+			return err
+		}
+	}
+	if err := self.prepareSecondaryGoals(); err != nil {
+		return err
+	}
+	for i := range self.TurnEffects {
+		effect := &self.TurnEffects[i]
+		resource, ok := ResourceNamed(effect.Resource)
+		if !ok {
+			return fmt.Errorf("turn_effects: unknown resource %q", effect.Resource)
+		}
+		effect.resource = resource
+	}
+	self.registry = newCommandRegistry(self.Commands)
+	self.constraints = []Constraint{
+		NonNegativeConstraint{},
+		self.turnEndConstraint(),
+	}
+	for _, source := range self.Constraints {
+		constraint, err := ParseExpressionConstraint(source)
+		if err != nil {
+			return err
+		}
+		self.addConstraint(constraint)
+	}
+	specs := self.Objectives
+	if len(specs) == 0 && self.Maximize != "" {
+		specs = []string{"max:" + self.Maximize, "shortest", "score"}
+	}
+	objectives, err := parseObjectives(specs)
+	if err != nil {
+		return err
+	}
+	self.objectives = objectives
+	if self.Scorer == nil {
+		weights := DefaultScoreWeights
+		if self.ScoreWeights != nil {
+			weights = *self.ScoreWeights
+		}
+		self.Scorer = &weights
+	}
+	self.rulesKey, self.goalKey = self.componentKeys()
+	self.raise, self.lower = self.actionLimits()
+	self.prepareGoals()
+	self.hasBonuses, self.hasWindows, self.limited = false, false, nil
+	self.hasCrewLocks, self.longestCrewLock = false, 0
+	for _, command := range self.Commands {
+		self.hasBonuses = self.hasBonuses || command.Bonus != nil
+		self.hasCrewLocks = self.hasCrewLocks || command.CrewLock != 0
+		if command.CrewLock > 0 {
+			self.longestCrewLock = max(self.longestCrewLock, uint32(command.CrewLock))
+		}
+		self.hasWindows = self.hasWindows || command.AvailableFromTurn > 0 || command.AvailableUntilTurn > 0
+		if command.isLimited() && (len(self.limited) == 0 || self.limited[len(self.limited)-1] != command.baseName()) {
+			self.limited = append(self.limited, command.baseName()) // Once for all the multiples of a command
+		}
+	}
+	for _, stage := range self.Stages {
+		self.hasWindows = self.hasWindows || len(stage.Commands) > 0
+	}
+	if len(self.limited) > 0 {
+		self.addConstraint(UsageConstraint{})
+	}
+	if self.hasWindows {
+		self.addConstraint(AvailabilityConstraint{})
+	}
+	if len(self.Stages) > 1 {
+		self.addConstraint(StageConstraint{})
+	}
+	if len(self.Crew) > 0 {
+		self.addConstraint(CrewConstraint{})
+	}
+	if self.FailAbove != nil {
+		self.addConstraint(&StepCapConstraint{*self.FailAbove})
+	}
+	return self.prepareCaps()
+}
+
+// turnEndConstraint combines the strict bounds of TurnMustEndAbove and TurnMustEndBelow with the
+// inclusive TurnEndMin and TurnEndMax into the lowest and highest each resource may end a turn at
+func (self *Scenario) turnEndConstraint() *TurnEndConstraint {
+	constraint := &TurnEndConstraint{NoLowerBound, NoUpperBound}
+	for _, resource := range AllResources {
+		least, most := &constraint.Min[resource], &constraint.Max[resource]
+		if above := self.TurnMustEndAbove.Get(resource); IsBounded(above) {
+			*least = int16(above + 1)
+		}
+		if below := self.TurnMustEndBelow.Get(resource); IsBounded(below) {
+			*most = int16(below - 1)
+		}
+		if self.TurnEndMin != nil && self.TurnEndMin[resource] > *least {
+			*least = self.TurnEndMin[resource]
+		}
+		if self.TurnEndMax != nil && self.TurnEndMax[resource] < *most {
+			*most = self.TurnEndMax[resource]
+		}
+	}
+	return constraint
+}
+
+// prepareCaps clamps the resources which Caps and Overflow say are to be clamped, and forbids
+// exceeding the caps of the others
+func (self *Scenario) prepareCaps() error {
+	for name := range self.Overflow {
+		if _, ok := ResourceNamed(name); !ok {
+			return fmt.Errorf("overflow: unknown resource %q", name)
+		}
+	}
+	self.clampAt, self.hasClamps = NoUpperBound, false
+	if self.Caps == nil {
+		return nil
+	}
+	invalidAbove, hasInvalid := NoUpperBound, false
+	for _, resource := range AllResources {
+		if !IsBounded(self.Caps.Get(resource)) {
+			continue
+		}
+		switch self.Overflow[resource.String()] {
+		case "", "clamp":
+			self.clampAt[resource], self.hasClamps = self.Caps[resource], true
+		case "invalid":
+			invalidAbove[resource], hasInvalid = self.Caps[resource], true
+		default:
+			return fmt.Errorf("overflow of %s must be clamp or invalid, not %q", resource, self.Overflow[resource.String()])
+		}
+	}
+	if hasInvalid {
+		self.addConstraint(&StepCapConstraint{invalidAbove})
+	}
+	return nil
+}
+
+// addConstraint attaches an additional rule to the scenario, allowing new mission mechanics to be
+// enforced without changes to Sequence
+func (self *Scenario) addConstraint(constraint Constraint) {
+	self.constraints = append(self.constraints, constraint)
+}
+
+// eventsAt lists the events which happen at the start of the given turn
+func (self *Scenario) eventsAt(turn uint32) []*Event {
+	events := []*Event{}
+	for i := range self.Events {
+		if self.Events[i].Turn == turn {
+			events = append(events, &self.Events[i])
+		}
+	}
+	return events
+}
+
+// applyTurnEffects makes the changes which happen at the start of every turn (see TurnEffect)
+func (self *Scenario) applyTurnEffects(resources *Resources) {
+	for i := range self.TurnEffects {
+		effect := &self.TurnEffects[i]
+		resources.Set(effect.resource, effect.apply(resources.Get(effect.resource)))
+	}
+}
+
+// turnEffectOn is the most a turn's effects can change the resource by (ignoring any floors)
+func (self *Scenario) turnEffectOn(resource Resource) int {
+	change := 0
+	for _, effect := range self.TurnEffects {
+		if effect.resource == resource {
+			change += effect.Change
+		}
+	}
+	return change
+}
+
+// ActionsIn is how many actions may be taken in the given (1-based) turn
+func (self *Scenario) ActionsIn(turn uint32) uint32 {
+	if stage := self.StageIn(turn); stage != nil && stage.ActionsPerTurn > 0 {
+		return stage.ActionsPerTurn
+	} else if turn >= 1 && int(turn) <= len(self.ActionsSchedule) {
+		return self.ActionsSchedule[turn-1]
+	}
+	return self.ActionsPerTurn
+}
+
+// TurnEnd is how many actions may have been taken by the end of the given turn (0 for none)
+func (self *Scenario) TurnEnd(turn uint32) uint32 {
+	if turn > self.Turns {
+		turn = self.Turns
+	}
+	return self.turnEnds[turn]
+}
+
+// position finds the (1-based) turn in which the given number of actions ends, and how many of them
+// were taken in that turn (or turn 0 if no actions have been taken)
+func (self *Scenario) position(size uint32) (turn uint32, action uint32) {
+	if size == 0 {
+		return 0, 0
+	} else if self.Turns == 0 {
+		return 0, size // A scenario without turns, in which no action can be taken
+	} else if int(size) >= len(self.turnOf) {
+		turn = self.Turns // Only a finished game can have taken every action
+	} else {
+		turn = self.turnOf[size]
+	}
+	return turn, size - self.turnEnds[turn-1]
+}
+
+func (self *Scenario) TotalActions() uint32 {
+	return self.turnEnds[self.Turns]
+}
+
+// Registry indexes the scenario's commands
+func (self *Scenario) Registry() *CommandRegistry {
+	return self.registry
+}
+
+func (self *Scenario) FindCommand(name string) *Command {
+	return self.registry.lookup(name)
+}
+
+// FindCrewMember looks up a crew member by name, ignoring case (nil if there is none)
+func (self *Scenario) FindCrewMember(name string) *CrewMember {
+	for i := range self.Crew {
+		if strings.EqualFold(self.Crew[i].Name, name) {
+			return &self.Crew[i]
+		}
+	}
+	return nil
+}
+
+func (self *Scenario) crewIndex(member *CrewMember) int {
+	for i := range self.Crew {
+		if self.Crew[i].Name == member.Name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (self *Scenario) commandIndex(name string) int {
+	for i, c := range self.Commands {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Hash is a digest of the scenario's canonical JSON form, used to tie persisted sequences back to
+// the scenario they were computed against
+func (self *Scenario) Hash() string {
+	raw, err := json.Marshal(self)
+	if err != nil {
+		panic(err) // Scenarios always marshal
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// ReadScenario loads a scenario written in YAML shorthand (see example-scenario.yml, and
+// shorthand.Extends for scenarios based on another), or if the path ends in .json, one already
+// expanded
+func ReadScenario(path string) (*Scenario, error) {
+	var raw, rawJSON []byte
+	var err error
+	if filepath.Ext(path) == ".json" {
+		rawJSON, err = os.ReadFile(path)
+	} else if raw, err = readExtended(path, nil); err == nil {
+		if rawJSON, err = shorthand.ToJSON(raw); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	scenario, err := ParseScenario(rawJSON)
+	if err != nil {
+		if raw == nil {
+			raw = rawJSON
+		}
+		var unknown *UnknownFieldError
+		if errors.As(err, &unknown) && shorthand.Lines(raw)[unknown.Field] > 0 { // A misspelled top-level key
+			return nil, fmt.Errorf("%s:%d: %v", path, shorthand.Lines(raw)[unknown.Field], err)
+		}
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return scenario, nil
+}
+
+// readExtended reads a scenario in YAML shorthand merged with any it extends (and so on), whose paths
+// are relative to the scenario extending them
+func readExtended(path string, extending []string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	base, err := shorthand.Extends(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	} else if base == "" {
+		return raw, nil
+	}
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(filepath.Dir(path), base)
+	}
+	extending = append(extending, path)
+	for _, extended := range extending {
+		if filepath.Clean(extended) == filepath.Clean(base) {
+			return nil, fmt.Errorf("%s: extends %s, which extends it in turn", path, base)
+		}
+	}
+	rawBase, err := readExtended(base, extending)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err = shorthand.Merge(rawBase, raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return raw, nil
+}
+
+// ParseScenario reads (and prepares) a scenario from its JSON form
+func ParseScenario(rawJSON []byte) (*Scenario, error) {
+	scenario, err := decodeScenario(rawJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := scenario.Prepare(); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
+
+// Limits bound the size of the scenarios ParseScenarioWithin accepts (zero for no limit), since
+// preparing a scenario takes memory for every turn, action, and command (including each multiple of a
+// scaled command)
+type Limits struct {
+	Turns    int
+	Actions  int
+	Commands int
+}
+
+// ParseScenarioWithin is ParseScenario, except that a scenario larger than the limits is rejected
+// before it is prepared (e.g. when the scenario comes from someone else)
+func ParseScenarioWithin(rawJSON []byte, limits Limits) (*Scenario, error) {
+	scenario, err := decodeScenario(rawJSON)
+	if err != nil {
+		return nil, err
+	}
+	turns, actions, commands := scenario.size()
+	if limits.Turns > 0 && turns > uint64(limits.Turns) {
+		return nil, fmt.Errorf("%d turns is more than the %d allowed", turns, limits.Turns)
+	} else if limits.Actions > 0 && actions > uint64(limits.Actions) {
+		return nil, fmt.Errorf("%d actions is more than the %d allowed", actions, limits.Actions)
+	} else if limits.Commands > 0 && commands > uint64(limits.Commands) {
+		return nil, fmt.Errorf("%d commands (counting each multiple of a scaled command) is more than the %d allowed", commands, limits.Commands)
+	}
+	if err := scenario.Prepare(); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
+
+// size works out how many turns, actions and commands an unprepared scenario will have once prepared
+func (self *Scenario) size() (turns uint64, actions uint64, commands uint64) {
+	if len(self.Stages) > 0 {
+		for _, stage := range self.Stages {
+			perTurn := stage.ActionsPerTurn
+			if perTurn == 0 {
+				perTurn = self.ActionsPerTurn
+			}
+			turns, actions = turns+uint64(stage.Turns), actions+uint64(stage.Turns)*uint64(perTurn)
+		}
+	} else {
+		turns = uint64(self.Turns)
+		scheduled := min(uint64(len(self.ActionsSchedule)), turns)
+		for _, perTurn := range self.ActionsSchedule[:scheduled] {
+			actions += uint64(perTurn)
+		}
+		actions += (turns - scheduled) * uint64(self.ActionsPerTurn)
+	}
+	for _, command := range self.Commands {
+		if command.Scale != nil && command.Scale.Max >= command.Scale.Min {
+			commands += uint64(command.Scale.Max-command.Scale.Min) + 1
+		} else {
+			commands++
+		}
+	}
+	return turns, actions, commands
+}
+
+// decodeScenario reads a scenario from JSON, without preparing it
+func decodeScenario(rawJSON []byte) (*Scenario, error) {
+	weights := DefaultScoreWeights                 // Any weights omitted from the scenario keep their default
+	goalMin, goalMax := NoLowerBound, NoUpperBound // Likewise any resources omitted from goal_min and goal_max are unbounded
+	caps, failAbove := NoUpperBound, NoUpperBound  // And from caps and fail_above, unbounded
+	turnEndMin, turnEndMax := NoLowerBound, NoUpperBound
+	scenario := Scenario{ScoreWeights: &weights, GoalMin: &goalMin, GoalMax: &goalMax, TurnEndMin: &turnEndMin, TurnEndMax: &turnEndMax, Caps: &caps, FailAbove: &failAbove}
+	decoder := json.NewDecoder(bytes.NewReader(rawJSON))
+	decoder.DisallowUnknownFields() // Most likely misspelled (see UnknownFieldError)
+	if err := decoder.Decode(&scenario); err != nil {
+		return nil, strictDecodingError(err)
+	}
+	if scenario.GoalMin != nil && *scenario.GoalMin == NoLowerBound {
+		scenario.GoalMin = nil
+	}
+	if scenario.GoalMax != nil && *scenario.GoalMax == NoUpperBound {
+		scenario.GoalMax = nil
+	}
+	if scenario.TurnEndMin != nil && *scenario.TurnEndMin == NoLowerBound {
+		scenario.TurnEndMin = nil
+	}
+	if scenario.TurnEndMax != nil && *scenario.TurnEndMax == NoUpperBound {
+		scenario.TurnEndMax = nil
+	}
+	if scenario.Caps != nil && *scenario.Caps == NoUpperBound {
+		scenario.Caps = nil
+	}
+	if scenario.FailAbove != nil && *scenario.FailAbove == NoUpperBound {
+		scenario.FailAbove = nil
+	}
+	return &scenario, nil
+}
+
+// Variant copies the scenario, applies a modification, and prepares the copy for searching.  An error
+// is returned if the modification leaves the scenario invalid.
+func (self *Scenario) Variant(modify func(*Scenario)) (*Scenario, error) {
+	variant := *self
+	variant.Commands = append([]Command{}, self.Commands...)
+	modify(&variant)
+	if err := variant.Prepare(); err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
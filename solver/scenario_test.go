@@ -0,0 +1,445 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEventsChangeResourcesAtTheStartOfTheirTurn(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 2
+actions_per_turn: 2
+start: 2w
+goal: 3r
+commands:
+  srt: w r
+events:
+  - {name: resupply, turn: 2, delta: 2w}
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	found := SolveSerially(StartSequence(scenario), 1)
+	if len(found) == 0 {
+		t.Fatal("found no solutions, though the event makes the goal reachable")
+	}
+	steps := found[0].Steps()
+	if len(steps) != 3 || steps[1].Resources.Get(Power) != 0 || steps[2].Resources.Get(Power) != 1 {
+		t.Errorf("solved as %s, want power to be resupplied before the third action", found[0].CommandSequence())
+	}
+	if err := Simulate(scenario, found[0].Commands()[found[0].Origin().Size:], nil); err != nil {
+		t.Errorf("simulating %s: %v", found[0].CommandSequence(), err)
+	}
+
+	scenario.Events[0].Turn = 3
+	if err := scenario.Prepare(); err == nil {
+		t.Error("prepared an event after the last turn")
+	}
+}
+
+func TestActionsScheduleVariesActionsPerTurn(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 3
+actions_per_turn: 2
+actions_schedule: [3, 1]
+start: 6w
+goal: 6r
+commands:
+  srt: w r
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	if total := scenario.TotalActions(); total != 6 {
+		t.Fatalf("%d actions in total, want 3+1+2", total)
+	}
+	seq := StartSequence(scenario)
+	positions := [][2]uint32{}
+	for seq.hasMoreActionsAvailable() {
+		seq = seq.AttemptAction(&scenario.Commands[0])
+		if seq == nil {
+			t.Fatal("could not take every action")
+		}
+		positions = append(positions, [2]uint32{seq.Turn(), seq.Action()})
+	}
+	want := [][2]uint32{{1, 1}, {1, 2}, {1, 3}, {2, 1}, {3, 1}, {3, 2}}
+	if fmt.Sprint(positions) != fmt.Sprint(want) {
+		t.Errorf("actions taken at (turn, action) %v, want %v", positions, want)
+	}
+	if !seq.IsSuccess() {
+		t.Errorf("%s did not meet the goal", seq.CommandSequence())
+	}
+
+	scenario.ActionsSchedule = []uint32{3, 1, 2, 2}
+	if err := scenario.Prepare(); err == nil {
+		t.Error("prepared a schedule longer than the mission")
+	}
+}
+
+func TestUsageLimits(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 2
+actions_per_turn: 3
+start: 6w
+goal: 3r
+commands:
+  srt: {input: w, output: r, max_uses_per_turn: 2, max_uses_total: 3}
+  pl: w p
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	for plan, want := range map[string]string{
+		"SRT SRT PL SRT":        "",
+		"SRT PL PL SRT SRT":     "",
+		"SRT SRT SRT":           "srt may only be taken 2 times per turn",
+		"PL PL PL SRT SRT SRT":  "srt may only be taken 2 times per turn",
+		"SRT SRT PL SRT PL SRT": "srt may only be taken 3 times in all",
+	} {
+		_, err := ReplayPlan(scenario, ParsePlan(plan), nil)
+		if want == "" && err != nil {
+			t.Errorf("%s: %v", plan, err)
+		} else if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("%s: got %v, want %q", plan, err, want)
+		}
+	}
+	if found := SolveSerially(StartSequence(scenario), 1); len(found) == 0 || found[0].Size != 4 {
+		t.Errorf("solved as %v, want 4 actions (srt being limited to 2 in the first turn)", found)
+	}
+}
+
+func TestGoalMinAndMax(t *testing.T) {
+	scenario := `
+turns: 1
+actions_per_turn: 3
+start: 3w
+goal: 2r
+commands:
+  hot: w 2r2h
+  cool: 2w 2r
+turn_must_end_above: ""
+turn_must_end_below: ""
+`
+	for _, test := range []struct {
+		bounds string
+		want   string
+	}{
+		{"", "HOT"},
+		{"goal_max: 1h", "COOL"},
+		{"goal_min: 3h", "HOT -> HOT"},
+		{"goal_min: 1h\ngoal_max: 1h", ""},
+	} {
+		found := SolveSerially(StartSequence(parseTestScenario(t, scenario+test.bounds)), 1)
+		plan := "" // None
+		if len(found) > 0 {
+			plan = found[0].CommandSequence()
+		}
+		if plan != test.want {
+			t.Errorf("with %q solved as %q, want %q", test.bounds, plan, test.want)
+		}
+	}
+}
+
+func TestCrewActOncePerTurnAddingTheirBonus(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 2
+actions_per_turn: 2
+start: 2w
+goal: 3r
+commands:
+  srt: w r
+crew:
+  aldrin: r
+  collins: w
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	seq, err := ReplayPlan(scenario, ParsePlan("SRT@Aldrin SRT@Collins"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq.Resources.Get(Comm) != 3 || seq.Resources.Get(Power) != 1 {
+		t.Errorf("crew left %v, want each one's bonus added", seq.Resources)
+	}
+	if _, err := ReplayPlan(scenario, ParsePlan("SRT@Aldrin SRT@Aldrin"), nil); err == nil {
+		t.Error("aldrin acted twice in a turn")
+	}
+	if _, err := ReplayPlan(scenario, ParsePlan("SRT@Aldrin SRT@Collins SRT@Aldrin"), nil); err != nil {
+		t.Errorf("aldrin could not act again in the next turn: %v", err)
+	}
+
+	found := SolveSerially(StartSequence(scenario), 1)
+	if len(found) == 0 || found[0].Size != 2 {
+		t.Fatalf("solved as %v, want 2 actions", found)
+	}
+	for _, step := range found[0].Steps() {
+		if step.Member == nil {
+			t.Errorf("no one took %s", step.CommandName())
+		}
+	}
+}
+
+func TestCapsClampOrForbidExcess(t *testing.T) {
+	scenario := `
+turns: 1
+actions_per_turn: 2
+start: 2w
+goal: 3b
+caps: 3b
+commands:
+  mdp: w 2b
+turn_must_end_above: ""
+turn_must_end_below: ""
+`
+	seq, err := ReplayPlan(parseTestScenario(t, scenario), ParsePlan("MDP MDP"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq.Resources.Get(Data) != 3 {
+		t.Errorf("clamped data to %d, want the cap of 3", seq.Resources.Get(Data))
+	}
+	if _, err := ReplayPlan(parseTestScenario(t, scenario+"overflow: {data: invalid}\n"), ParsePlan("MDP MDP"), nil); err == nil {
+		t.Error("exceeded a cap which may not be exceeded")
+	}
+}
+
+func TestCommandsAvailableInSomeTurns(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 3
+actions_per_turn: 1
+start: 3w
+goal: 3r
+commands:
+  launch: {input: w, output: 2r, available_until_turn: 1}
+  srt: {input: w, output: r, available_from_turn: 2}
+  pl: {input: w, output: p, available_from_turn: 2, available_until_turn: 2}
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	for plan, want := range map[string]string{
+		"LAUNCH SRT":    "",
+		"LAUNCH PL SRT": "",
+		"SRT":           "srt is not available until turn 2",
+		"LAUNCH LAUNCH": "launch is only available until turn 1",
+		"LAUNCH SRT PL": "pl is only available in turns 2 to 2",
+	} {
+		_, err := ReplayPlan(scenario, ParsePlan(plan), nil)
+		if want == "" && err != nil {
+			t.Errorf("%s: %v", plan, err)
+		} else if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("%s: got %v, want %q", plan, err, want)
+		}
+	}
+	if found := SolveSerially(StartSequence(scenario), 1); len(found) == 0 || found[0].CommandSequence() != "LAUNCH -> SRT" {
+		t.Errorf("solved as %v, want LAUNCH -> SRT", found)
+	}
+
+	scenario.Commands[1].AvailableFromTurn = 4
+	if errs := scenario.Validate(); len(errs) == 0 {
+		t.Error("validated a command not available until after the last turn")
+	}
+}
+
+func TestStages(t *testing.T) {
+	scenario := parseTestScenario(t, `
+actions_per_turn: 2
+start: 6w
+goal: 2p
+commands:
+  srt: w r
+  vent: w h
+  pl: w p
+stages:
+  - {name: launch, turns: 1, actions_per_turn: 3, goal: 2r, commands: [srt, vent]}
+  - {name: cruise, turns: 1, commands: [pl], turn_cost: r}
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	if scenario.Turns != 2 {
+		t.Fatalf("%d turns, want those of both stages", scenario.Turns)
+	}
+	for plan, want := range map[string]string{
+		"SRT SRT VENT PL PL": "",
+		"PL":                 "pl is not available in stage launch",
+		"SRT SRT SRT SRT":    "srt is not available in stage cruise",
+		"VENT VENT SRT":      "stage launch needs comm 2",
+	} {
+		_, err := ReplayPlan(scenario, ParsePlan(plan), nil)
+		if want == "" && err != nil {
+			t.Errorf("%s: %v", plan, err)
+		} else if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("%s: got %v, want %q", plan, err, want)
+		}
+	}
+	found := SolveSerially(StartSequence(scenario), 1)
+	if len(found) == 0 || found[0].Size != 5 {
+		t.Fatalf("solved as %v, want 3 actions in launch then 2 in cruise", found)
+	}
+	if stage := found[0].Stage(); stage == nil || stage.Name != "cruise" {
+		t.Errorf("ended in stage %v, want cruise", stage)
+	} else if comm := found[0].Resources.Get(Comm); comm < 3 {
+		t.Errorf("ended with %d comm, want the cruise turn cost added", comm)
+	}
+}
+
+func TestTurnEffectsApplyAtTheStartOfEveryTurn(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 3
+actions_per_turn: 1
+start: 3w3h
+goal: 3r2d
+commands:
+  srt: w r
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	floor := 0
+	scenario.TurnEffects = []TurnEffect{{Resource: "heat", Change: -2, Floor: &floor}, {Resource: "drift", Change: 1}}
+	if err := scenario.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	heat, drift := []int{}, []int{}
+	seq, err := ReplayPlan(scenario, ParsePlan("SRT SRT SRT"), func(seq *Sequence) {
+		heat, drift = append(heat, seq.Resources.Get(Heat)), append(drift, seq.Resources.Get(Drift))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(heat, drift); got != "[3 1 0] [0 1 2]" {
+		t.Errorf("heat and drift %s, want heat to dissipate down to 0 and drift to grow", got)
+	}
+	if err := Simulate(scenario, seq.Commands(), nil); err != nil {
+		t.Errorf("simulating: %v", err)
+	}
+
+	scenario.TurnEffects[0].Resource = "warmth"
+	if err := scenario.Prepare(); err == nil {
+		t.Error("prepared a turn effect on an unknown resource")
+	}
+}
+
+func TestScaledCommands(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 1
+actions_per_turn: 3
+start: 4w
+goal: 3t
+commands:
+  burn: {input: w, output: t, scale: {min: 1, max: 3}, max_uses_per_turn: 2}
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	names := []string{}
+	for _, command := range scenario.Commands {
+		names = append(names, fmt.Sprintf("%s:%dw%dt", command.Name, command.Input.Get(Power), command.Output.Get(Thrust)))
+	}
+	if got := strings.Join(names, " "); got != "burn:1w1t burn*2:2w2t burn*3:3w3t" {
+		t.Errorf("commands %s, want burn at each multiple from 1 to 3", got)
+	}
+	if found := SolveSerially(StartSequence(scenario), 1); len(found) == 0 || found[0].CommandSequence() != "BURN*3" {
+		t.Errorf("solved as %v, want a single BURN*3", found)
+	}
+	if _, err := ReplayPlan(scenario, ParsePlan("BURN BURN*2 BURN"), nil); err == nil || !strings.Contains(err.Error(), "burn may only be taken 2 times per turn") {
+		t.Errorf("got %v, want the multiples of burn to share its limit", err)
+	}
+
+	scenario.Commands = scenario.Commands[:1]
+	scenario.Commands[0].Scale, scenario.Commands[0].ScaledFrom = &Scale{Min: 2, Max: 1}, ""
+	if err := scenario.Prepare(); err == nil {
+		t.Error("prepared a command scaled from 2 to 1")
+	}
+}
+
+func TestCrewLockExhaustsCrewForLaterTurns(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 3
+actions_per_turn: 1
+start: 2c
+goal: 3r
+commands:
+  eva: {input: c, output: r, crew_lock: 1}
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	crew := []int{}
+	seq, err := ReplayPlan(scenario, ParsePlan("EVA EVA EVA"), func(seq *Sequence) {
+		crew = append(crew, seq.Resources.Get(Crew))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(crew); got != "[1 0 0]" {
+		t.Errorf("crew left %s, want one exhausted through the turn after each eva", got)
+	}
+	if err := Simulate(scenario, seq.Commands(), nil); err != nil {
+		t.Errorf("simulating: %v", err)
+	}
+
+	scenario.Commands[0].CrewLock = -1 // For the rest of the mission
+	if err := scenario.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReplayPlan(scenario, ParsePlan("EVA EVA EVA"), nil); err == nil || !strings.Contains(err.Error(), "crew went negative") {
+		t.Errorf("got %v, want the third eva to have no crew left", err)
+	}
+}
+
+func TestFailAboveIsCheckedAfterEveryAction(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 1
+actions_per_turn: 3
+start: 3w
+goal: 2r
+fail_above: 1x
+commands:
+  fast: w 2r2x
+  slow: w r
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	if found := SolveSerially(StartSequence(scenario), 1); len(found) == 0 || found[0].CommandSequence() != "SLOW -> SLOW" {
+		t.Errorf("solved as %v, want SLOW -> SLOW (FAST exceeding the radiation allowed)", found)
+	}
+	_, err := ReplayPlan(scenario, ParsePlan("FAST SLOW"), nil)
+	if err == nil || !strings.Contains(err.Error(), "radiation") {
+		t.Errorf("got %v, want radiation to fail the mission before the turn ends", err)
+	}
+	fast := []*Command{&scenario.Commands[0], &scenario.Commands[1]}
+	if err := Simulate(scenario, fast, nil); err == nil || !strings.Contains(err.Error(), "failing the mission") {
+		t.Errorf("simulated %v, want radiation to fail the mission", err)
+	}
+}
+
+func TestTurnEndMinAndMaxAreInclusive(t *testing.T) {
+	scenario := `
+turns: 2
+actions_per_turn: 1
+start: 2w
+goal: 2r
+commands:
+  hot: w 2r4h
+  cool: w r
+`
+	for _, test := range []struct {
+		bounds string
+		want   string
+	}{
+		{"turn_must_end_above: \"\"\nturn_must_end_below: \"\"", "HOT"},
+		{"turn_must_end_above: \"\"\nturn_must_end_below: 4h", "COOL -> COOL"},
+		{"turn_must_end_above: \"\"\nturn_must_end_below: \"\"\nturn_end_max: 4h", "HOT"},
+		{"turn_must_end_above: \"\"\nturn_must_end_below: \"\"\nturn_end_max: 3h", "COOL -> COOL"},
+		{"turn_must_end_above: 4h\nturn_must_end_below: \"\"", ""},
+	} {
+		found := SolveSerially(StartSequence(parseTestScenario(t, scenario+test.bounds)), 1)
+		plan := "" // None
+		if len(found) > 0 {
+			plan = found[0].CommandSequence()
+		}
+		if plan != test.want {
+			t.Errorf("with %q solved as %q, want %q", test.bounds, plan, test.want)
+		}
+	}
+	inclusive := parseTestScenario(t, scenario+"turn_must_end_above: \"\"\nturn_must_end_below: \"\"\nturn_end_min: 1w").turnEndConstraint()
+	exclusive := parseTestScenario(t, scenario+"turn_must_end_above: 1w\nturn_must_end_below: \"\"").turnEndConstraint()
+	if inclusive.Min.Get(Power) != 1 || exclusive.Min.Get(Power) != 2 {
+		t.Errorf("power must end at least %d and %d, want 1 and 2", inclusive.Min.Get(Power), exclusive.Min.Get(Power))
+	}
+}
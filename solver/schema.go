@@ -0,0 +1,127 @@
+package solver
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/shorthand"
+)
+
+// Schema describes the JSON form of a scenario (as ParseScenario reads it, and shorthand.ToJSON writes
+// it) as a JSON Schema, worked out from Scenario itself so that it can never fall out of date.  Field
+// names are those of scenario files (e.g. "turns", "turn_cost"), though ParseScenario ignores their
+// case.
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Scenario{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Mars Horizon mission scenario"
+	schema["required"] = []string{"start", "commands"}
+	return schema
+}
+
+var resourcesType = reflect.TypeOf(Resources{})
+
+// typeSchema describes how a value of the given type is written in JSON, objects admitting only the
+// fields they have
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == resourcesType {
+		properties := map[string]interface{}{}
+		for _, name := range ResourceNames {
+			properties[name] = map[string]interface{}{"type": "integer"}
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			if name, ok := jsonFieldName(t.Field(i)); ok {
+				properties[name] = typeSchema(t.Field(i).Type)
+			}
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	default:
+		return map[string]interface{}{"type": "integer"}
+	}
+}
+
+// jsonFieldName is the name of a struct field in scenario files: as its json tag gives it, or else its
+// own name lowercased (as encoding/json matches names regardless of case).  Fields which are unexported
+// or left out of JSON have none.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if field.PkgPath != "" || name == "-" {
+		return "", false
+	} else if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, true
+}
+
+// FieldNames lists every field name a scenario file may use, at any depth (including the names of the
+// resources), in alphabetical order
+func FieldNames() []string {
+	seen := map[string]bool{}
+	var collect func(schema map[string]interface{})
+	collect = func(schema map[string]interface{}) {
+		if properties, ok := schema["properties"].(map[string]interface{}); ok {
+			for name, property := range properties {
+				seen[name] = true
+				collect(property.(map[string]interface{}))
+			}
+		}
+		for _, key := range []string{"items", "additionalProperties"} {
+			if nested, ok := schema[key].(map[string]interface{}); ok {
+				collect(nested)
+			}
+		}
+	}
+	collect(Schema())
+	names := []string{}
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UnknownFieldError is a field of a scenario file which no part of a scenario has, most likely
+// misspelled
+type UnknownFieldError struct {
+	Field string
+}
+
+func (self *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q%s", self.Field, shorthand.DidYouMean(self.Field, FieldNames()))
+}
+
+// strictDecodingError recognizes the error encoding/json gives for an unknown field (with
+// DisallowUnknownFields, or from Resources.UnmarshalJSON), returning an UnknownFieldError in its place
+func strictDecodingError(err error) error {
+	quoted, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return err
+	}
+	field, unquoteErr := strconv.Unquote(quoted)
+	if unquoteErr != nil {
+		return err
+	}
+	return &UnknownFieldError{field}
+}
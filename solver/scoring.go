@@ -0,0 +1,141 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+)
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Scorer ranks sequences which meet the goal.  Lower scores are considered better (the best solution
+// is printed last).
+type Scorer interface {
+	Score(seq *Sequence) int
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ScoreWeights is the default Scorer.  Each action taken costs Length, while leftover resources (by
+// default only Power and Radiation), as well as any surplus of goal resources and the reward of any
+// secondary goals met, are weighed against that cost.
+type ScoreWeights struct {
+	Length    int
+	Power     int
+	Radiation int
+	Surplus   int
+
+	// Rewards for each unit of the other resources left over (negative to penalize them), e.g. to
+	// prefer leaving data for the next phase of the mission.  Omitted when zero, so as not to change the
+	// Hash of scenarios which don't use them.
+	Comm   int `json:",omitempty"`
+	Data   int `json:",omitempty"`
+	Nav    int `json:",omitempty"`
+	Drift  int `json:",omitempty"`
+	Heat   int `json:",omitempty"`
+	Thrust int `json:",omitempty"`
+	Crew   int `json:",omitempty"`
+}
+
+var DefaultScoreWeights = ScoreWeights{
+	Length:    1000,
+	Power:     10,
+	Radiation: -100,
+	Surplus:   1,
+}
+
+// Score implements Scorer
+func (self *ScoreWeights) Score(seq *Sequence) int {
+	return self.Length*int(seq.Size) - self.risk(&seq.Resources, seq.goal()) - seq.secondaryReward()
+}
+
+func (self *ScoreWeights) risk(resources *Resources, goal *Resources) int {
+	risk := self.Power*resources.Get(Power) + self.Radiation*resources.Get(Radiation) +
+		self.Comm*resources.Get(Comm) + self.Data*resources.Get(Data) + self.Nav*resources.Get(Nav) +
+		self.Drift*resources.Get(Drift) + self.Heat*resources.Get(Heat) + self.Thrust*resources.Get(Thrust) +
+		self.Crew*resources.Get(Crew)
+	surplus := 0
+	for _, resource := range []Resource{Comm, Data, Nav, Thrust} {
+		if goal[resource] > 0 {
+			surplus += resources.Get(resource) - goal.Get(resource)
+		}
+	}
+	// Ignore Drift, Heat, & Crew
+	return risk + self.Surplus*surplus
+}
+
+func (self *ScoreWeights) Field(name string) *int {
+	switch name {
+	case "length":
+		return &self.Length
+	case "surplus":
+		return &self.Surplus
+	case "comm":
+		return &self.Comm
+	case "data":
+		return &self.Data
+	case "nav":
+		return &self.Nav
+	case "power":
+		return &self.Power
+	case "drift":
+		return &self.Drift
+	case "heat":
+		return &self.Heat
+	case "thrust":
+		return &self.Thrust
+	case "crew":
+		return &self.Crew
+	case "radiation":
+		return &self.Radiation
+	}
+	return nil
+}
+
+// ScoreTerm is one part of a score (see ScoreBreakdown)
+type ScoreTerm struct {
+	Reason string // e.g. "4 actions", "2 power left", "3 nav over the goal"
+	Points int    // What it adds to the score (lower scores being better)
+}
+
+// Breakdown lists the terms which add up to Score, leaving out those which add nothing
+func (self *ScoreWeights) Breakdown(seq *Sequence) []ScoreTerm {
+	terms := []ScoreTerm{{fmt.Sprint(seq.Size, " actions"), self.Length * int(seq.Size)}}
+	for _, resource := range AllResources {
+		if points := -*self.Field(resource.String()) * seq.Resources.Get(resource); points != 0 {
+			terms = append(terms, ScoreTerm{fmt.Sprint(seq.Resources.Get(resource), " ", resource, " left"), points})
+		}
+	}
+	met := seq.goal()
+	for _, resource := range []Resource{Comm, Data, Nav, Thrust} { // As risk
+		goal := met.Get(resource)
+		if surplus := seq.Resources.Get(resource) - goal; goal > 0 && surplus*self.Surplus != 0 {
+			terms = append(terms, ScoreTerm{fmt.Sprint(surplus, " ", resource, " over the goal"), -self.Surplus * surplus})
+		}
+	}
+	for _, goal := range seq.SecondaryGoalsMet() {
+		terms = append(terms, ScoreTerm{"meeting " + goal.Name, -goal.reward()})
+	}
+	return terms
+}
+
+// ScoreBreakdown explains the sequence's Score term by term, or returns nil if the scenario's Scorer
+// is not ScoreWeights
+func (self *Sequence) ScoreBreakdown() []ScoreTerm {
+	if weights, ok := self.scenario.Scorer.(*ScoreWeights); ok {
+		return weights.Breakdown(self)
+	}
+	return nil
+}
+
+// DescribeScore summarizes why the sequence scored as it did, e.g. "score 3980 (lower is better) =
+// +4000 for 4 actions, -20 for 2 power left"
+func DescribeScore(seq *Sequence) string {
+	terms := []string{}
+	for _, term := range seq.ScoreBreakdown() {
+		terms = append(terms, fmt.Sprintf("%+d for %s", term.Points, term.Reason))
+	}
+	if len(terms) == 0 {
+		return Colorize("gray", "score ", seq.Score(), " (lower is better)")
+	}
+	return Colorize("gray", "score ", seq.Score(), " (lower is better) = ", strings.Join(terms, ", "))
+}
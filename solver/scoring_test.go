@@ -0,0 +1,55 @@
+package solver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescribeScore(t *testing.T) {
+	scenario := readExample(t)
+	found, err := Solve(scenario, Options{Engine: "serial", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) == 0 {
+		t.Fatal("found no solutions")
+	}
+	seq := found[0].Sequence
+	total := 0
+	for _, term := range seq.ScoreBreakdown() {
+		total += term.Points
+	}
+	if total != seq.Score() {
+		t.Errorf("terms add up to %d, not the score of %d", total, seq.Score())
+	}
+	if description := DescribeScore(seq); !strings.Contains(description, "lower is better") {
+		t.Errorf("%q does not say which scores are better", description)
+	}
+}
+
+func TestScoreWeightsForOtherResources(t *testing.T) {
+	scenario := `
+turns: 1
+actions_per_turn: 1
+start: wb
+goal: 2r
+commands:
+  srt: w 2r
+  tx: b 2r
+turn_must_end_above: ""
+turn_must_end_below: ""
+`
+	for _, test := range []struct {
+		weights string
+		want    string
+	}{
+		{"", "TX"}, // Leaving power, by default
+		{"score_weights: {length: 1000, power: 10, data: 20, surplus: 1}", "SRT"},
+		{"score_weights: {length: 1000, power: 30, data: 20}", "TX"},
+	} {
+		found := SolveSerially(StartSequence(parseTestScenario(t, scenario+test.weights)), 1)
+		if len(found) == 0 || found[0].CommandSequence() != test.want {
+			t.Errorf("with %q solved as %v, want %s", test.weights, found, test.want)
+		}
+	}
+}
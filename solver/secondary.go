@@ -0,0 +1,81 @@
+package solver
+
+import (
+	"fmt"
+)
+
+// DefaultSecondaryReward is taken off the score of a solution for each secondary goal it meets, unless
+// the goal gives its own Reward.  It outweighs a few resources left over, but not an extra action.
+const DefaultSecondaryReward = 100
+
+// SecondaryGoal is an optional bonus objective of a mission (e.g. "also finish with 2 data to spare"),
+// which a solution need not meet but is preferred for meeting (see ScoreWeights)
+type SecondaryGoal struct {
+	Name       string
+	Goal       Resources // As the scenario's Goal
+	Conditions []string  `json:",omitempty"` // As the scenario's GoalConditions, e.g. ["heat <= 2"]
+	Reward     int       `json:",omitempty"` // Taken off the score of a solution meeting it (DefaultSecondaryReward if omitted)
+}
+
+// reward is what meeting the secondary goal takes off a score
+func (self *SecondaryGoal) reward() int {
+	if self.Reward != 0 {
+		return self.Reward
+	}
+	return DefaultSecondaryReward
+}
+
+// prepareSecondaryGoals checks the conditions of each secondary goal, and works out its bounds (see
+// goalBand and applyGoalConditions)
+func (self *Scenario) prepareSecondaryGoals() error {
+	self.secondaryLower, self.secondaryUpper = nil, nil
+	for i := range self.SecondaryGoals {
+		goal := &self.SecondaryGoals[i]
+		for _, source := range goal.Conditions {
+			if _, err := parseGoalCondition(source); err != nil {
+				return fmt.Errorf("secondary goal %d (%s): %v", i+1, goal.Name, err)
+			}
+		}
+		lower, upper := goalBand(&goal.Goal)
+		applyGoalConditions(goal.Conditions, &lower, &upper)
+		self.secondaryLower, self.secondaryUpper = append(self.secondaryLower, lower), append(self.secondaryUpper, upper)
+	}
+	return nil
+}
+
+// SecondaryGoalsMet lists the scenario's secondary goals which this sequence's resources meet
+func (self *Sequence) SecondaryGoalsMet() []*SecondaryGoal {
+	met := []*SecondaryGoal{}
+	for i := range self.scenario.SecondaryGoals {
+		if self.Resources.within(&self.scenario.secondaryLower[i], &self.scenario.secondaryUpper[i]) {
+			met = append(met, &self.scenario.SecondaryGoals[i])
+		}
+	}
+	return met
+}
+
+// secondaryReward totals the rewards of the secondary goals this sequence meets
+func (self *Sequence) secondaryReward() int {
+	reward := 0
+	for _, goal := range self.SecondaryGoalsMet() {
+		reward += goal.reward()
+	}
+	return reward
+}
+
+// DescribeSecondaryGoals describes which of the scenario's secondary goals this sequence meets, e.g.
+// "bonus objectives met (1 of 2): spare data"
+func (self *Sequence) DescribeSecondaryGoals() string {
+	met := self.SecondaryGoalsMet()
+	if len(met) == 0 {
+		return fmt.Sprintf("no bonus objectives met (of %d)", len(self.scenario.SecondaryGoals))
+	}
+	names := ""
+	for i, goal := range met {
+		if i > 0 {
+			names += ", "
+		}
+		names += goal.Name
+	}
+	return fmt.Sprintf("bonus objectives met (%d of %d): %s", len(met), len(self.scenario.SecondaryGoals), names)
+}
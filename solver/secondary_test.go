@@ -0,0 +1,40 @@
+package solver
+
+import (
+	"testing"
+)
+
+func TestSecondaryGoalsArePreferred(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 1
+actions_per_turn: 1
+start: 2w
+goal: 2r
+secondary_goals:
+  - {name: keep cool, goal: "", conditions: [heat <= 0]}
+commands:
+  hot: w 2r2h
+  cool: 2w 2r
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	found := SolveSerially(StartSequence(scenario), 2)
+	Rank(found)
+	if len(found) != 2 || found[0].CommandSequence() != "COOL" {
+		t.Fatalf("solved as %v, want COOL ranked first", found)
+	}
+	if got := found[0].DescribeSecondaryGoals(); got != "bonus objectives met (1 of 1): keep cool" {
+		t.Errorf("COOL: %s", got)
+	}
+	if got := found[1].DescribeSecondaryGoals(); got != "no bonus objectives met (of 1)" {
+		t.Errorf("HOT: %s", got)
+	}
+	if found[1].Score()-found[0].Score() < DefaultSecondaryReward-10 {
+		t.Errorf("scored COOL %d and HOT %d, want the bonus to outweigh the power left", found[0].Score(), found[1].Score())
+	}
+
+	scenario.SecondaryGoals[0].Conditions = []string{"heat < 1"}
+	if err := scenario.Prepare(); err == nil {
+		t.Error("prepared a secondary goal with an invalid condition")
+	}
+}
@@ -0,0 +1,519 @@
+package solver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+)
+
+// Sequence is a list of commands that have been run with the state of resources arrived at by these
+// commands
+type Sequence struct {
+	scenario  *Scenario
+	Resources Resources
+	Command   *Command
+	Prev      *Sequence
+	Size      uint32
+	Member    *CrewMember // Who performed the most recent action (if the scenario has a crew)
+	observed  bool        // The resources were seen in-game rather than worked out (see Observe)
+	search    *search     // The search which reached this sequence (see Solve), or nil
+}
+
+// Scenario is the scenario in which this sequence's actions were taken
+func (self *Sequence) Scenario() *Scenario {
+	return self.scenario
+}
+
+func (self *Sequence) CommandName() string {
+	if self.Size == 0 {
+		return "[START]"
+	}
+	if self.Command == nil {
+		return "[RESUME]"
+	}
+	if self.Member != nil {
+		return strings.ToUpper(self.Command.Name) + "@" + self.Member.Name
+	}
+	return strings.ToUpper(self.Command.Name)
+}
+
+// Origin is the sequence this one was built from: either the scenario's start or a mid-game state
+// (see ResumeSequence)
+func (self *Sequence) Origin() *Sequence {
+	seq := self
+	for seq.Command != nil {
+		seq = seq.Prev
+	}
+	return seq
+}
+
+// Ancestor is the sequence of the given size (at most this one's) which this sequence extends
+func (self *Sequence) Ancestor(size uint32) *Sequence {
+	seq := self
+	for seq.Size > size {
+		seq = seq.Prev
+	}
+	return seq
+}
+
+// Commands lists the commands taken to reach this sequence, in order.  For a resumed sequence the
+// commands before its origin are unknown (nil).
+func (self *Sequence) Commands() []*Command {
+	commands := make([]*Command, self.Size)
+	for prev := self; prev.Command != nil; prev = prev.Prev {
+		commands[prev.Size-1] = prev.Command
+	}
+	return commands
+}
+
+// Steps lists the sequence after each action taken to reach this one, in order (from the origin of a
+// resumed sequence)
+func (self *Sequence) Steps() []*Sequence {
+	steps := []*Sequence{}
+	for prev := self; prev.Command != nil; prev = prev.Prev {
+		steps = append(steps, prev)
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
+
+// Observe corrects the resources after the most recent action to those actually seen in-game, which
+// may differ from those worked out (e.g. if the action failed, or the scenario is slightly off)
+func (self *Sequence) Observe(resources Resources) *Sequence {
+	observed := *self
+	observed.Resources, observed.observed = resources, true
+	return &observed
+}
+
+// Crew lists the crew member who performed each action taken to reach this sequence, in order (nil
+// for those not known, or if the scenario has no crew)
+func (self *Sequence) Crew() []*CrewMember {
+	crew := make([]*CrewMember, self.Size)
+	for prev := self; prev.Command != nil; prev = prev.Prev {
+		crew[prev.Size-1] = prev.Member
+	}
+	return crew
+}
+
+func (self *Sequence) CommandSequence() string {
+	if self.Command == nil {
+		return self.CommandName()
+	}
+	stack := []string{}
+	for prev := self; prev.Command != nil; prev = prev.Prev {
+		stack = append([]string{prev.CommandName()}, stack...)
+	}
+	return strings.Join(stack[:], " -> ")
+}
+
+// Turn is the (1-based) turn in which the most recent action was taken
+func (self *Sequence) Turn() uint32 {
+	turn, _ := self.scenario.position(self.Size)
+	return turn
+}
+
+// Action is the (1-based) position of the most recent action within its turn
+func (self *Sequence) Action() uint32 {
+	_, action := self.scenario.position(self.Size)
+	return action
+}
+
+func (self *Sequence) isNewTurn() bool {
+	return self.Action() == 1
+}
+
+func (self *Sequence) IsTurnEnd() bool {
+	return self.Size == self.scenario.TurnEnd(self.Turn())
+}
+
+func (self *Sequence) hasMoreActionsAvailable() bool {
+	return self.Size < self.scenario.TotalActions()
+}
+
+// Violation returns the first scenario constraint this sequence breaks (if any)
+func (self *Sequence) Violation() Constraint {
+	for _, constraint := range self.scenario.constraints {
+		if !constraint.Allows(self) {
+			return constraint
+		}
+	}
+	return nil
+}
+
+// Violations returns every scenario constraint this sequence breaks
+func (self *Sequence) Violations() []Constraint {
+	violated := []Constraint{}
+	for _, constraint := range self.scenario.constraints {
+		if !constraint.Allows(self) {
+			violated = append(violated, constraint)
+		}
+	}
+	return violated
+}
+
+func (self *Sequence) isInvalid() bool {
+	return self.Violation() != nil
+}
+
+// IsSuccess is true if every resource is within the bounds of a goal (see goalBounds and GoalMet), in
+// the last stage of the mission (if it has stages)
+func (self *Sequence) IsSuccess() bool {
+	return self.Size >= self.scenario.earliestSuccess && self.GoalMet() >= 0
+}
+
+func (self *Sequence) AttemptAction(command *Command) *Sequence {
+	next, violated := self.Step(command)
+	if violated != nil {
+		return nil
+	}
+	return next
+}
+
+// Step takes an action, returning the resulting sequence along with the first constraint that the
+// action violates (if any).  In a crewed mission the action is performed by the first crew member who
+// has yet to act this turn (see StepAs).
+func (self *Sequence) Step(command *Command) (*Sequence, Constraint) {
+	return self.StepAs(command, self.freeCrew()[0])
+}
+
+// StepAs takes an action performed by the given crew member (nil if the scenario has no crew), as Step
+func (self *Sequence) StepAs(command *Command, member *CrewMember) (*Sequence, Constraint) {
+	next := &Sequence{}
+	self.spend(next, command, member)
+	return next, next.act()
+}
+
+// opening works out the resources at the beginning of the next action, before any input is spent: any
+// new turn starts (the crew returning, turn costs and effects applied) along with its events.  This is
+// the same whichever action is taken next, so Search works it out only once.
+func (self *Sequence) opening() Resources {
+	resources := self.Resources
+	turn, action := self.scenario.position(self.Size + 1)
+
+	// Apply any logic at the beginning of a new turn (not including the first turn)
+	if self.Size > 0 && action == 1 {
+		if crew := self.scenario.Start.Get(Crew); crew > 0 && self.scenario.hasCrewLocks {
+			resources.Set(Crew, max(crew-self.lockedCrew(turn), 0))
+		} else if crew > 0 {
+			resources.Set(Crew, crew)
+		}
+		resources.add(self.scenario.turnCostIn(turn))
+		self.scenario.applyTurnEffects(&resources)
+	}
+	if action == 1 {
+		for _, event := range self.scenario.eventsAt(turn) {
+			resources.add(&event.Delta)
+		}
+	}
+	if self.scenario.hasClamps {
+		resources.clamp(&self.scenario.clampAt)
+	}
+	return resources
+}
+
+// spend begins (in the given sequence) an action performed by the given crew member: any new turn
+// starts (see opening), and the input of the command is spent
+func (self *Sequence) spend(next *Sequence, command *Command, member *CrewMember) {
+	*next = Sequence{self.scenario, self.opening(), command, self, self.Size + 1, member, false, self.search}
+	next.Resources.subtract(&command.Input)
+}
+
+// act finishes the action begun by spend, returning the first constraint violated either once the
+// input of the command is spent or once its output is produced (if any)
+func (self *Sequence) act() Constraint {
+	if violated := self.Violation(); violated != nil {
+		return violated
+	}
+	self.produce()
+	return self.Violation()
+}
+
+// produce finishes the action begun by spend, adding the output of the command (with any bonus, and
+// the skill of the crew member performing it)
+func (self *Sequence) produce() {
+	self.Resources.add(&self.Command.Output)
+	if self.Command.earnsBonusAfter(self.Prev.Command) {
+		self.Resources.add(&self.Command.Bonus.Output)
+	}
+	if self.Member != nil {
+		self.Resources.add(&self.Member.Bonus)
+	}
+	if self.scenario.hasClamps {
+		self.Resources.clamp(&self.scenario.clampAt)
+	}
+}
+
+// Search implements Searchable interface for continuing the search from this sequence into a
+// subsequence sequence by taking an available (and legal) action
+func (self *Sequence) Search(onNext func(parallelsearch.Searchable)) {
+	if self.hasMoreActionsAvailable() {
+		self.search.visit(self)
+		crew := self.freeCrew()
+		turn, action := self.scenario.position(self.Size + 1)
+		latest := -1 // The index of the most recent command, if it was taken in the same turn (and is known)
+		if self.search.isCanonical() && action > 1 && self.Command != nil {
+			latest = self.scenario.registry.index[self.Command.Name]
+		}
+		// Each candidate action is tried out on the scratch sequence, undone by restoring the opening
+		// resources, and only copied out (see sequenceArena) once found to be legal
+		opening := self.opening()
+		scratch := &Sequence{scenario: self.scenario, Prev: self, Size: self.Size + 1, search: self.search}
+		for j := range self.scenario.Commands {
+			i := self.search.commandAt(j)
+			command := self.scenario.Commands[i] // WARNING: Be careful about reusing a variable from range that gets passed by value
+			if !self.scenario.offers(&command, turn) {
+				continue // Skipped here rather than left to AvailabilityConstraint, to save stepping
+			}
+			for _, member := range crew {
+				scratch.Resources, scratch.Command, scratch.Member = opening, &command, member
+				scratch.Resources.subtract(&command.Input)
+				if violated := scratch.act(); violated == nil {
+					if i < latest && self.commutesWith(&command, member) {
+						continue // Reached in the other order instead
+					} else if self.search.isDeadEnd(scratch) {
+						continue
+					}
+					next := self.search.newSequence(scratch.Size)
+					*next = *scratch
+					onNext(next)
+				} else {
+					self.search.prune(scratch.Size)
+				}
+			}
+		}
+	}
+}
+
+// commutesWith is true if the command (performed by the given crew member), legally taken after the
+// most recent action, could have been taken just before it instead, reaching the same state (see
+// Options.Canonical).  Within a turn only bonuses and caps make the order of actions matter, other
+// than which orders are legal (constraints being checked once the input of each is spent, too).
+func (self *Sequence) commutesWith(command *Command, member *CrewMember) bool {
+	if self.scenario.hasBonuses || self.scenario.hasClamps {
+		return false
+	}
+	swapped, violated := self.Prev.StepAs(command, member)
+	if violated == nil {
+		_, violated = swapped.StepAs(self.Command, self.Member)
+	}
+	return violated == nil
+}
+
+// IsFound implements Searchable interface to determine if the current sequence meets the goal
+// we are looking for
+func (self *Sequence) IsFound() bool {
+	return self.IsSuccess()
+}
+
+// uses counts how many times the named command (at any multiple, see Scale) was taken in this
+// sequence's current turn, and in all
+func (self *Sequence) uses(name string) (inTurn int, total int) {
+	turn := self.Turn()
+	for prev := self; prev.Command != nil; prev = prev.Prev {
+		if prev.Command.baseName() == name {
+			total++
+			if prev.Turn() == turn {
+				inTurn++
+			}
+		}
+	}
+	return inTurn, total
+}
+
+// hasActed is true if the crew member performed an action in the given turn, up to this sequence
+func (self *Sequence) hasActed(member *CrewMember, turn uint32) bool {
+	for prev := self; prev.Command != nil && prev.Turn() == turn; prev = prev.Prev {
+		if prev.Member != nil && prev.Member.Name == member.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// freeCrew lists the crew members who may perform the next action: those yet to act in its turn (or
+// just nil if the scenario has no crew, or none are free)
+func (self *Sequence) freeCrew() []*CrewMember {
+	free := []*CrewMember{}
+	turn, _ := self.scenario.position(self.Size + 1)
+	for i := range self.scenario.Crew {
+		if member := &self.scenario.Crew[i]; !self.hasActed(member, turn) {
+			free = append(free, member)
+		}
+	}
+	if len(free) == 0 {
+		return []*CrewMember{nil}
+	}
+	return free
+}
+
+// SearchState identifies sequences with identical futures (and scores): those of the same length
+// which arrive at the same resources and, if any command can earn a bonus (or, while searching with
+// Options.Canonical, mid-turn), end with the same command and, if any command is limited, have the
+// same uses of those commands left and, if the mission is crewed, have the same crew members left to
+// act this turn and, if any command exhausts crew, the same crew to come in later turns
+type SearchState struct {
+	size      uint32
+	resources Resources
+	last      string
+	uses      string
+	acted     string
+	locked    string
+}
+
+func (self *Sequence) State() SearchState {
+	state := SearchState{size: self.Size, resources: self.Resources}
+	if (self.scenario.hasBonuses || (self.search.isCanonical() && !self.IsTurnEnd())) && self.Command != nil {
+		state.last = self.Command.Name
+	}
+	if len(self.scenario.limited) > 0 {
+		uses := []int{}
+		for _, name := range self.scenario.limited {
+			inTurn, total := self.uses(name)
+			if self.IsTurnEnd() {
+				inTurn = 0 // Reset in the next turn
+			}
+			uses = append(uses, inTurn, total)
+		}
+		state.uses = fmt.Sprint(uses)
+	}
+	if self.scenario.hasCrewLocks {
+		state.locked = self.crewLocks()
+	}
+	if len(self.scenario.Crew) > 0 && !self.IsTurnEnd() {
+		for _, member := range self.freeCrew() {
+			if member != nil {
+				state.acted += member.Name + ","
+			}
+		}
+	}
+	return state
+}
+
+// Key implements parallelsearch.Keyed (see SearchState)
+func (self *Sequence) Key() interface{} {
+	return self.State()
+}
+
+// ResultKey implements parallelsearch.ResultKeyed: solutions are the same result if they finish with
+// the same resources, however (and in however many actions) they got there
+func (self *Sequence) ResultKey() interface{} {
+	return self.Resources
+}
+
+// Score implements Searchable interface and provides the ability to sort the discovered solutions
+// to try and present the "best" solution last.  By default (see ScoreWeights) we consider sequences
+// that are shorter to be the least "risky" (since we have more wiggle room to fix things if actions
+// fail).  If two sequences have the same size, we prefer the ones that leave us with the most
+// resources (especially power).
+func (self *Sequence) Score() int {
+	return self.scenario.Scorer.Score(self)
+}
+
+// Priority implements parallelsearch.Prioritized, so that (see Options.Prioritize) the sequences
+// which would score best were they to stop here are expanded first
+func (self *Sequence) Priority() int {
+	return -self.Score()
+}
+
+// sequenceJSON is the persisted form of a Sequence.  Commands are stored by their index into the
+// scenario's command list, so a sequence can only be restored against the same scenario.  Resumed
+// sequences also record the mid-game state they started from, and any resources observed in-game
+// along the way (by the number of commands taken).
+type sequenceJSON struct {
+	Scenario  string               `json:"scenario"`
+	Origin    *Resources           `json:"origin,omitempty"`
+	Offset    uint32               `json:"offset,omitempty"`
+	Commands  []int                `json:"commands"`
+	Crew      []int                `json:"crew,omitempty"` // Who performed each command, in a crewed mission
+	Observed  map[uint32]Resources `json:"observed,omitempty"`
+	Resources Resources            `json:"resources"`
+}
+
+// MarshalJSON implements json.Marshaler so that partial plans and results can be persisted
+func (self *Sequence) MarshalJSON() ([]byte, error) {
+	origin := self.Origin()
+	raw := sequenceJSON{Scenario: self.scenario.Hash(), Commands: make([]int, self.Size-origin.Size), Resources: self.Resources}
+	for prev := self; prev.Command != nil; prev = prev.Prev {
+		raw.Commands[prev.Size-origin.Size-1] = self.scenario.commandIndex(prev.Command.Name)
+		if prev.Member != nil {
+			if raw.Crew == nil {
+				raw.Crew = make([]int, len(raw.Commands))
+			}
+			raw.Crew[prev.Size-origin.Size-1] = self.scenario.crewIndex(prev.Member)
+		}
+		if prev.observed {
+			if raw.Observed == nil {
+				raw.Observed = map[uint32]Resources{}
+			}
+			raw.Observed[prev.Size-origin.Size] = prev.Resources
+		}
+	}
+	if origin.Size > 0 {
+		raw.Origin, raw.Offset = &origin.Resources, origin.Size
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON implements json.Unmarshaler by replaying the persisted commands.  The receiver must
+// already be bound to the scenario (e.g. via StartSequence) the sequence was computed against.
+func (self *Sequence) UnmarshalJSON(data []byte) error {
+	if self.scenario == nil {
+		return errors.New("sequence must be bound to a scenario before unmarshaling")
+	}
+	raw := sequenceJSON{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if hash := self.scenario.Hash(); raw.Scenario != hash {
+		return fmt.Errorf("sequence was computed for scenario %s, not %s", raw.Scenario, hash)
+	}
+	seq := StartSequence(self.scenario)
+	if raw.Origin != nil {
+		seq = ResumeSequence(self.scenario, *raw.Origin, raw.Offset)
+	}
+	if raw.Crew != nil && len(raw.Crew) != len(raw.Commands) {
+		return errors.New("crew does not match commands")
+	}
+	for j, i := range raw.Commands {
+		if i < 0 || i >= len(self.scenario.Commands) {
+			return fmt.Errorf("invalid command index: %d", i)
+		}
+		member := seq.freeCrew()[0]
+		if raw.Crew != nil {
+			if raw.Crew[j] < 0 || raw.Crew[j] >= len(self.scenario.Crew) {
+				return fmt.Errorf("invalid crew index: %d", raw.Crew[j])
+			}
+			member = &self.scenario.Crew[raw.Crew[j]]
+		}
+		next, violated := seq.StepAs(&self.scenario.Commands[i], member)
+		if observed, ok := raw.Observed[uint32(j+1)]; ok {
+			next, violated = next.Observe(observed), nil // The game allowed it, whatever the rules say
+		}
+		if violated != nil {
+			return errors.New("can not take action: " + self.scenario.Commands[i].Name)
+		}
+		seq = next
+	}
+	if seq.Resources != raw.Resources {
+		return fmt.Errorf("replayed resources (%v) do not match persisted resources (%v)", &seq.Resources, &raw.Resources)
+	}
+	*self = *seq
+	return nil
+}
+
+func StartSequence(scenario *Scenario) *Sequence {
+	start := Sequence{scenario, scenario.Start, nil, nil, 0, nil, false, nil}
+	return &start
+}
+
+// ResumeSequence describes a game already in progress, in which the given number of actions have
+// been taken (their history unknown) leaving the given resources
+func ResumeSequence(scenario *Scenario, resources Resources, actionsTaken uint32) *Sequence {
+	resume := Sequence{scenario, resources, nil, nil, actionsTaken, nil, false, nil}
+	return &resume
+}
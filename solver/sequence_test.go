@@ -0,0 +1,30 @@
+package solver
+
+import "testing"
+
+// readExample reads (and prepares) the example scenario
+func readExample(t testing.TB) *Scenario {
+	t.Helper()
+	scenario, err := ReadScenario("../example-scenario.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scenario.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	return scenario
+}
+
+func TestCanonicalResumedMidTurn(t *testing.T) {
+	scenario := readExample(t)
+	resources := Resources{}
+	resources.Set(Power, 2)
+	resources.Set(Crew, 1)
+	resources.Set(Heat, 3)
+	start := ResumeSequence(scenario, resources, 1) // Action 2 of turn 1, with no history
+	for _, engine := range []string{"serial", "parallel"} {
+		if _, err := Solve(scenario, Options{Start: start, Engine: engine, Canonical: true}); err != nil {
+			t.Errorf("%s: %v", engine, err)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package solver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Simulate independently replays a plan using nothing but the raw scenario data (including any events),
+// returning the first invariant it breaks: a command taken too often, a crew member acting twice in a
+// turn, a command taken outside its turns or stage, a validated resource going negative or over its
+// cap (or FailAbove), a turn ending outside its bounds, a stage ending short of its goal, too many
+// actions, or the goal (any one of them, if there are alternatives) not being met (in the last stage).  In a crewed mission, crew gives who
+// performed each action (see Sequence.Crew); otherwise it may be nil.  It deliberately shares no logic with Sequence so that it
+// can catch engine bugs such as off-by-one errors in the turn-end bounds.  (Custom expression
+// constraints are not re-checked.)
+func Simulate(scenario *Scenario, plan []*Command, crew []*CrewMember) error {
+	schedule := []int{}  // Actions in each turn
+	stages := []*Stage{} // Stage of each turn (if any)
+	lastStageFrom := 0   // Actions taken before the last stage
+	for i := range scenario.Stages {
+		stage := &scenario.Stages[i]
+		for turn := uint32(0); turn < stage.Turns; turn++ {
+			actions := stage.ActionsPerTurn
+			if actions == 0 {
+				actions = scenario.ActionsPerTurn
+			}
+			if i < len(scenario.Stages)-1 {
+				lastStageFrom += int(actions)
+			}
+			schedule, stages = append(schedule, int(actions)), append(stages, stage)
+		}
+	}
+	for turn := 0; len(scenario.Stages) == 0 && turn < int(scenario.Turns); turn++ {
+		if turn < len(scenario.ActionsSchedule) {
+			schedule = append(schedule, int(scenario.ActionsSchedule[turn]))
+		} else {
+			schedule = append(schedule, int(scenario.ActionsPerTurn))
+		}
+	}
+	state := [resourceCount]int{} // Not Resources, so as to share none of its arithmetic
+	for _, resource := range AllResources {
+		state[resource] = scenario.Start.Get(resource)
+	}
+	turn, action := 1, 0
+	usesInTurn, uses := map[string]int{}, map[string]int{}
+	acted := map[string]bool{}
+	// Crew exhausted through each turn (see Command.CrewLock), or at -1 for the rest of the mission
+	exhausted := map[int]int{}
+	overflow := func(where string, clamp bool) error { // Clamps resources over their caps, failing if they may not be
+		var exceeded error
+		for _, resource := range AllResources {
+			if scenario.Caps == nil || !IsBounded(scenario.Caps.Get(resource)) || state[resource] <= scenario.Caps.Get(resource) {
+				continue
+			} else if scenario.Overflow[resource.String()] != "invalid" && clamp {
+				state[resource] = scenario.Caps.Get(resource)
+			} else if scenario.Overflow[resource.String()] == "invalid" && exceeded == nil {
+				exceeded = fmt.Errorf("%s: %s exceeds its cap (%d)", where, resource, state[resource])
+			}
+		}
+		return exceeded
+	}
+	for i, command := range plan {
+		if action++; turn > len(schedule) || action > schedule[turn-1] {
+			turn, action = turn+1, 1
+		}
+		if turn > len(schedule) {
+			return fmt.Errorf("%d actions exceeds the %d turns", len(plan), scenario.Turns)
+		}
+		where := fmt.Sprintf("turn %d, action %d (%s)", turn, action, command.Name)
+		if action == 1 {
+			usesInTurn, acted = map[string]int{}, map[string]bool{}
+		}
+		var member *CrewMember
+		if i < len(crew) {
+			member = crew[i]
+		}
+		if len(scenario.Crew) > 0 && member == nil {
+			return fmt.Errorf("%s: no crew member performed it", where)
+		} else if member != nil && acted[member.Name] {
+			return fmt.Errorf("%s: %s already acted in the turn", where, member.Name)
+		} else if member != nil {
+			acted[member.Name] = true
+		}
+		base := command.ScaledFrom // Every multiple of a command (see Scale) counts as the command
+		if base == "" {
+			base = command.Name
+		}
+		usesInTurn[base]++
+		uses[base]++
+		if command.MaxUsesPerTurn > 0 && usesInTurn[base] > command.MaxUsesPerTurn {
+			return fmt.Errorf("%s: taken more than %d times in the turn", where, command.MaxUsesPerTurn)
+		}
+		if command.MaxUsesTotal > 0 && uses[base] > command.MaxUsesTotal {
+			return fmt.Errorf("%s: taken more than %d times in all", where, command.MaxUsesTotal)
+		}
+		if turn < int(command.AvailableFromTurn) || command.AvailableUntilTurn > 0 && turn > int(command.AvailableUntilTurn) {
+			return fmt.Errorf("%s: not available in turn %d", where, turn)
+		}
+		if len(stages) > 0 && len(stages[turn-1].Commands) > 0 {
+			offered := false
+			for _, name := range stages[turn-1].Commands {
+				offered = offered || name == base
+			}
+			if !offered {
+				return fmt.Errorf("%s: not available in stage %s", where, stages[turn-1].Name)
+			}
+		}
+		if i > 0 && action == 1 {
+			if scenario.Start.Get(Crew) > 0 {
+				state[Crew] = scenario.Start.Get(Crew)
+				for through, crew := range exhausted {
+					if through < 0 || through >= turn {
+						state[Crew] -= crew
+					}
+				}
+				if state[Crew] < 0 {
+					state[Crew] = 0
+				}
+			}
+			cost := &scenario.TurnCost
+			if len(stages) > 0 && stages[turn-1].TurnCost != nil {
+				cost = stages[turn-1].TurnCost
+			}
+			for _, resource := range AllResources {
+				state[resource] += cost.Get(resource)
+			}
+			for _, effect := range scenario.TurnEffects {
+				resource, _ := ResourceNamed(effect.Resource)
+				value := &state[resource]
+				if changed := *value + effect.Change; effect.Floor == nil || changed >= *effect.Floor || effect.Change >= 0 {
+					*value = changed
+				} else if *value > *effect.Floor {
+					*value = *effect.Floor // Decays no further than its floor (and not at all if already below it)
+				}
+			}
+		}
+		for _, event := range scenario.Events {
+			if action != 1 || event.Turn != uint32(turn) {
+				continue
+			}
+			for _, resource := range AllResources {
+				state[resource] += event.Delta.Get(resource)
+			}
+		}
+		overflow(where, true) // Resources may not exceed their caps once the input is spent
+		for _, resource := range AllResources {
+			state[resource] -= command.Input.Get(resource)
+		}
+		if command.CrewLock < 0 && command.Input.Get(Crew) > 0 {
+			exhausted[-1] += command.Input.Get(Crew)
+		} else if command.CrewLock > 0 && command.Input.Get(Crew) > 0 {
+			exhausted[turn+command.CrewLock] += command.Input.Get(Crew)
+		}
+		for _, resource := range []Resource{Comm, Data, Nav, Power, Heat, Crew} {
+			if state[resource] < 0 {
+				return fmt.Errorf("%s: %s went negative (%d)", where, resource, state[resource])
+			}
+		}
+		if err := overflow(where, false); err != nil {
+			return err
+		}
+		for _, resource := range AllResources {
+			if scenario.FailAbove != nil && state[resource] > scenario.FailAbove.Get(resource) {
+				return fmt.Errorf("%s: %s exceeds %d once spent, failing the mission", where, resource, scenario.FailAbove.Get(resource))
+			}
+		}
+		for _, resource := range AllResources {
+			state[resource] += command.Output.Get(resource)
+			if i > 0 && command.Bonus != nil && (plan[i-1].Name == command.Bonus.After || plan[i-1].ScaledFrom == command.Bonus.After) {
+				state[resource] += command.Bonus.Output.Get(resource)
+			}
+			if member != nil {
+				state[resource] += member.Bonus.Get(resource)
+			}
+		}
+		if err := overflow(where, true); err != nil {
+			return err
+		}
+		for _, resource := range AllResources {
+			if scenario.FailAbove != nil && state[resource] > scenario.FailAbove.Get(resource) {
+				return fmt.Errorf("%s: %s exceeds %d, failing the mission", where, resource, scenario.FailAbove.Get(resource))
+			}
+		}
+		for _, resource := range []Resource{Comm, Data, Nav, Power, Heat, Crew} {
+			if state[resource] < 0 {
+				return fmt.Errorf("%s: %s went negative (%d)", where, resource, state[resource])
+			}
+		}
+		if action == schedule[turn-1] {
+			for _, resource := range AllResources {
+				value := state[resource]
+				if value <= scenario.TurnMustEndAbove.Get(resource) || value >= scenario.TurnMustEndBelow.Get(resource) {
+					return fmt.Errorf("%s: turn ends with %s out of bounds (%d)", where, resource, value)
+				}
+				if scenario.TurnEndMin != nil && value < scenario.TurnEndMin.Get(resource) || scenario.TurnEndMax != nil && value > scenario.TurnEndMax.Get(resource) {
+					return fmt.Errorf("%s: turn ends with %s out of bounds (%d)", where, resource, value)
+				}
+			}
+			if turn < len(stages) && stages[turn] != stages[turn-1] {
+				if short := simulatedShortfall(nil, &stages[turn-1].Goal, &state); short != "" {
+					return fmt.Errorf("%s: stage %s ends short of its goal (%s)", where, stages[turn-1].Name, short)
+				}
+			}
+		}
+	}
+	if len(plan) <= lastStageFrom && len(scenario.Stages) > 0 {
+		return fmt.Errorf("goal not met: the plan ends before the last stage")
+	}
+	goals := scenario.GoalAnyOf
+	if len(goals) == 0 {
+		goals = []Resources{scenario.Goal}
+	}
+	unmet := []string{}
+	for i := range goals {
+		short := simulatedShortfall(scenario, &goals[i], &state)
+		if short == "" {
+			return nil // Any goal will do
+		}
+		unmet = append(unmet, short)
+	}
+	if len(goals) > 1 {
+		return fmt.Errorf("goal not met: none of the %d goals (%s)", len(goals), strings.Join(unmet, "; "))
+	}
+	return fmt.Errorf("goal not met: %s", unmet[0])
+}
+
+// simulatedShortfall describes the first resource of the state which falls short of (or beyond) what
+// the goal asks (e.g. "nav is 3"), or returns "" if the goal is met.  A goal asks for at least its
+// comm, data, nav and power (and thrust, if given), and a band of ±Drift around zero.  Unless the
+// scenario is nil (as for the goal of a stage), its goal conditions replace what the goal asks of
+// each resource they compare, its goal_min and goal_max must be met as well, and either bounding drift
+// replaces whatever else is asked of drift.
+func simulatedShortfall(scenario *Scenario, goal *Resources, state *[resourceCount]int) string {
+	conditions := map[Resource][]func(value int) bool{}
+	driftBounded := false
+	if scenario != nil {
+		for _, source := range scenario.GoalConditions {
+			if resource, holds, ok := simulatedCondition(source); ok {
+				conditions[resource] = append(conditions[resource], holds)
+			}
+		}
+		driftBounded = scenario.GoalMin != nil && IsBounded(scenario.GoalMin.Get(Drift)) || scenario.GoalMax != nil && IsBounded(scenario.GoalMax.Get(Drift))
+	}
+	for _, resource := range AllResources {
+		value, met := state[resource], true
+		switch {
+		case resource == Drift && driftBounded:
+		case len(conditions[resource]) > 0:
+			for _, holds := range conditions[resource] {
+				met = met && holds(value)
+			}
+		case resource == Comm || resource == Data || resource == Nav || resource == Power:
+			met = value >= goal.Get(resource)
+		case resource == Thrust:
+			met = goal.Get(Thrust) == 0 || value >= goal.Get(Thrust)
+		case resource == Drift:
+			met = value >= -goal.Get(Drift) && value <= goal.Get(Drift)
+		}
+		if scenario != nil && scenario.GoalMin != nil && IsBounded(scenario.GoalMin.Get(resource)) && value < scenario.GoalMin.Get(resource) {
+			met = false
+		}
+		if scenario != nil && scenario.GoalMax != nil && IsBounded(scenario.GoalMax.Get(resource)) && value > scenario.GoalMax.Get(resource) {
+			met = false
+		}
+		if !met {
+			return fmt.Sprintf("%s is %d", resource, value)
+		}
+	}
+	return ""
+}
+
+// simulatedCondition reads a goal condition such as "drift <= -2", "heat == 0" or "drift within 1",
+// returning the resource it compares and whether a value holds to it (and false if it can't be read)
+func simulatedCondition(source string) (Resource, func(value int) bool, bool) {
+	for _, comparator := range []string{"<=", ">=", "==", " within "} {
+		name, number, found := strings.Cut(source, comparator)
+		if !found {
+			continue
+		}
+		resource, known := ResourceNamed(strings.ToLower(strings.TrimSpace(name)))
+		n, err := strconv.Atoi(strings.TrimSpace(number))
+		if !known || err != nil {
+			return resource, nil, false
+		}
+		switch comparator {
+		case "<=":
+			return resource, func(value int) bool { return value <= n }, true
+		case ">=":
+			return resource, func(value int) bool { return value >= n }, true
+		case "==":
+			return resource, func(value int) bool { return value == n }, true
+		default:
+			return resource, func(value int) bool { return value >= -n && value <= n }, n >= 0
+		}
+	}
+	return 0, nil, false
+}
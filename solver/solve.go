@@ -0,0 +1,350 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+)
+
+// DefaultLimit is how many solutions Solve finds unless told otherwise
+const DefaultLimit = 4
+
+// queuedSequenceSize is roughly the memory held by each sequence waiting to be searched by the parallel
+// engine (including its share of the sequences it extends), see Options.MemoryBudget
+const queuedSequenceSize = 512
+
+// Options adjust how Solve searches.  The zero value chooses everything automatically.  They marshal
+// (as JSON) to just those which decide the solutions found, e.g. for caching them.
+type Options struct {
+	Start    *Sequence `json:"-"` // Where to search from (e.g. a mid-game state, see ResumeSequence), or nil for the scenario's start
+	Engine   string    // "serial", "parallel", "best-first", "breadth-first", "depth-first", or "beam", or "" to choose automatically (see AutoTune)
+	PoolSize int       `json:"-"` // Workers for every engine but the serial one, or 0 to choose automatically
+	Beam     int       // Nodes searched per depth by the parallel and breadth-first engines (or kept by the beam engine), 0 to choose automatically, or negative for no limit
+	Limit    int       // Most solutions to find, or 0 for DefaultLimit
+	Depth    int       // Most actions to search ahead, or 0 for as many as the scenario allows (ignored when maximizing)
+
+	// Shallowest stops the parallel engine going deeper once any solution is found, returning the best
+	// of the shortest solutions (see ParallelSearch.StopAtShallowest)
+	Shallowest bool
+
+	// Unique returns only the best solution arriving at each final state (see Sequence.ResultKey).  The
+	// parallel engine keeps them from taking up the Limit as it goes; for the others, more solutions are
+	// searched for to make up for those dropped.
+	Unique bool
+
+	// Deterministic makes the solutions the same on every run, with ties broken by their commands.  The
+	// parallel, best-first, breadth-first, and depth-first engines (whose workers race) are then replaced
+	// by the beam engine with no beam, which searches each depth in full before moving on.  The other
+	// engines are deterministic already.
+	Deterministic bool
+
+	// Canonical searches only one order of the actions in a turn which could be taken in either order to
+	// the same effect (the one taking commands earlier in the scenario's list first).  The solutions
+	// found are the same, save for the order of their actions within a turn.  NOTE: Sequences arriving
+	// at the same state are only searched once anyway (see SearchState), which spares the search most
+	// reorderings already, so checking for them (and telling apart mid-turn states by their last
+	// command) usually costs more time than it saves.  It chiefly gives each turn a consistent order.
+	Canonical bool
+
+	// Distinct (if set) drops solutions which merely reorder the commands of a better one, by "multiset"
+	// or "turns" (see Distinct).  More solutions are searched for to make up for those dropped.
+	Distinct string
+
+	// MemoryBudget is roughly how many bytes the sequences waiting to be searched by the parallel engine
+	// may take before any more are spilled to disk (see ParallelSearch.SetMemoryBudget), or 0 for no limit
+	MemoryBudget int `json:"-"`
+
+	// Context (if set) may be cancelled to stop any engine but the serial one early, in which case
+	// Solve returns the solutions found so far.  The serial engine always runs to completion.
+	Context context.Context `json:"-"`
+
+	// Timeout (if set) stops any engine but the serial one once it has passed, in which case
+	// Solve returns the solutions found so far (and Stats tell how close the search got)
+	Timeout time.Duration `json:"-"`
+
+	// OnParallelSearch (if set) is called just before a parallel search starts, e.g. to observe or steer
+	// it.  The function it returns is called once the search is over.
+	OnParallelSearch func(ps *parallelsearch.ParallelSearch) (done func()) `json:"-"`
+
+	// Prioritize has the parallel engine expand the most promising sequences at each depth first (see
+	// Sequence.Priority), rather than those found first
+	Prioritize bool
+
+	// OnFound (if set) is called with each solution as soon as the search finds it (never concurrently),
+	// before the search is over and the solutions are ranked, so better ones may yet be found.  The
+	// serial engine, and searches maximizing a resource, call it with none.
+	OnFound func(seq *Sequence) `json:"-"`
+
+	// Tuned (if set) are the engine settings to search with, as settled by Tune, in place of Engine,
+	// PoolSize, Beam, and Deterministic.  Solve reports the settings it used in Stats.Tuning.
+	Tuned *Tuning `json:"-"`
+
+	// Stats (if set) is filled in once the search is over
+	Stats *Stats `json:"-"`
+
+	// Profile (if set along with Stats) also accounts for the search in detail (see Profile), at some
+	// cost in speed
+	Profile bool `json:"-"`
+}
+
+// Stats describe a finished search
+type Stats struct {
+	Tuning   Tuning
+	Searched uint64 // Nodes searched (not counted by the serial engine)
+	Elapsed  time.Duration
+	TimedOut bool      // See Options.Timeout
+	Closest  *Sequence // The closest the search came to the goal (if it timed out, and the engine wasn't serial or beam)
+	Profile  *Profile  // See Options.Profile
+}
+
+// Profile is a detailed account of a search, for tuning it
+type Profile struct {
+	Pruned   []uint64                   // Actions ruled out by a constraint, by the depth (from the start of the search) they would have reached
+	Parallel *parallelsearch.Statistics // From the parallel engine (nil for the others)
+}
+
+// Solution is a plan which meets the scenario's goal
+type Solution struct {
+	Commands  []string  // Names of the commands to take, in order
+	Resources Resources // Those left once every command has been taken
+	Score     int
+	Sequence  *Sequence // The plan itself, with the resources after each action
+}
+
+// Tune settles the engine settings a search from start would use, making any automatic choices
+// (unless they were settled already, see Options.Tuned)
+func (self *Options) Tune(start *Sequence) Tuning {
+	if self.Tuned != nil {
+		return *self.Tuned
+	}
+	settings := Tuning{Engine: self.Engine, Dedupe: true}
+	if self.Engine == "" {
+		settings = AutoTune(start)
+	}
+	if self.PoolSize > 0 {
+		settings.PoolSize = self.PoolSize
+	} else if settings.PoolSize == 0 {
+		settings.PoolSize = PoolSizeFor(runtime.GOMAXPROCS(0))
+	}
+	if self.Deterministic && settings.Engine != "serial" && settings.Engine != "beam" {
+		settings.Engine, settings.Beam = "beam", 0
+		return settings
+	}
+	if self.Beam > 0 {
+		settings.Beam = self.Beam
+	} else if self.Beam < 0 {
+		settings.Beam = 0
+	} else if settings.Engine == "beam" && settings.Beam == 0 {
+		settings.Beam = DefaultBeamWidth
+	}
+	return settings
+}
+
+// Solve searches the (prepared) scenario for the best plans meeting its goal, best first.  Scenarios
+// which maximize a resource are solved by MaximizeResource whatever the engine.  No solutions (and no
+// error) are returned if the goal can't be met.
+func Solve(scenario *Scenario, opts Options) ([]Solution, error) {
+	if scenario.registry == nil {
+		return nil, errors.New("scenario has not been prepared")
+	}
+	start := opts.Start
+	if start == nil {
+		start = StartSequence(scenario)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	wanted := limit
+	if opts.Distinct != "" {
+		if _, err := Distinct(nil, opts.Distinct); err != nil {
+			return nil, err
+		}
+		limit *= distinctOversample
+	}
+	settings := opts.Tune(start)
+	if opts.Unique && (settings.Engine != "parallel" || scenario.Maximize != "") {
+		limit *= distinctOversample
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.Timeout > 0 && settings.Engine != "parallel" {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	depth := int(scenario.TotalActions() - start.Size)
+	if opts.Depth > 0 && opts.Depth < depth {
+		depth = opts.Depth
+	}
+	search := &search{canonical: opts.Canonical}
+	if opts.MemoryBudget == 0 { // Spilled sequences must not be kept alive by the rest of their block
+		search.arenas = make([]sequenceArena, scenario.TotalActions()+1)
+	}
+	if opts.Profile && opts.Stats != nil {
+		search.pruned = make([]uint64, scenario.TotalActions()+1)
+	}
+	// The serial engine and MaximizeResource consult the cache of what earlier searches learned (see
+	// componentCache) themselves, and the beam engine never explores enough to prove dead ends
+	cached := scenario.Maximize == "" && settings.Engine != "serial"
+	if cached {
+		search.pruneDead = searchCache.hasDeadStates(scenario)
+		if !opts.Deterministic {
+			for _, command := range searchCache.commandOrder(scenario) {
+				search.order = append(search.order, scenario.registry.index[command.Name])
+			}
+		}
+		search.visiting = settings.Engine != "beam" && settings.Beam == 0 && depth == int(scenario.TotalActions()-start.Size)
+	}
+	start = start.within(search)
+	defer search.finish() // For sequences stepped from the solutions
+	started := time.Now()
+	searched := uint64(0)
+	var closest *Sequence
+	var parallel *parallelsearch.Statistics
+
+	var onFound func(parallelsearch.Searchable)
+	if opts.OnFound != nil {
+		onFound = func(s parallelsearch.Searchable) { opts.OnFound(s.(*Sequence)) }
+	}
+
+	found := []*Sequence{}
+	if scenario.Maximize != "" {
+		found = MaximizeResource(start, limit)
+	} else if settings.Engine == "serial" {
+		found = solveSeriallyWithin(start, limit, depth)
+	} else if strategy := settings.strategy(); strategy != nil {
+		ss := parallelsearch.NewStrategySearch(settings.PoolSize, depth, limit, strategy)
+		ss.OnFound(onFound)
+		if !settings.Dedupe {
+			ss.KeepDuplicates()
+		}
+		ss.Start(ctx, start)
+		for _, s := range ss.WaitForFound() {
+			found = append(found, s.(*Sequence))
+		}
+		searched = ss.Searched()
+		if nearest := ss.Closest(); nearest != nil {
+			closest = nearest.(*Sequence)
+		}
+	} else if settings.Engine == "beam" {
+		beam := parallelsearch.NewBeam(settings.PoolSize, depth, limit, settings.Beam)
+		beam.OnFound(onFound)
+		beam.Start(ctx, start)
+		for _, s := range beam.WaitForFound() {
+			found = append(found, s.(*Sequence))
+		}
+		searched = beam.Searched()
+	} else if settings.Engine == "parallel" {
+		ps := parallelsearch.New(
+			settings.PoolSize, // poolSize
+			depth,             // searchDepth
+			limit,             // searchLimit
+		)
+		ps.SetWidthLimit(settings.Beam)
+		ps.OnFound(onFound)
+		if opts.Prioritize {
+			ps.Prioritize()
+		}
+		if opts.Shallowest {
+			ps.StopAtShallowest()
+		}
+		if opts.Unique {
+			ps.UniqueResults()
+		}
+		if !settings.Dedupe {
+			ps.KeepDuplicates()
+		}
+		if opts.MemoryBudget > 0 {
+			spiller := newSpiller(start)
+			if err := ps.SetMemoryBudget(opts.MemoryBudget/queuedSequenceSize+1, spiller.encode, spiller.restore); err != nil {
+				return nil, err
+			}
+		}
+		done := func() {}
+		if opts.OnParallelSearch != nil {
+			done = opts.OnParallelSearch(ps)
+		}
+		if opts.Profile {
+			ps.CollectStatistics()
+		}
+		ps.Start(ctx, start)
+		var results []parallelsearch.Searchable
+		var err error
+		if opts.Timeout > 0 {
+			var nearest parallelsearch.Searchable
+			if results, nearest, err = ps.WaitForFoundWithTimeout(opts.Timeout); nearest != nil {
+				closest = nearest.(*Sequence)
+			}
+		} else {
+			results, err = ps.WaitForFound()
+		}
+		done()
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range results {
+			found = append(found, s.(*Sequence))
+		}
+		progress := ps.Progress()
+		searched = progress.Total()
+		parallel = ps.Statistics()
+	} else {
+		return nil, errors.New("unknown engine: " + settings.Engine)
+	}
+	if opts.Deterministic {
+		sort.SliceStable(found, func(i, j int) bool {
+			return found[i].CommandSequence() < found[j].CommandSequence()
+		})
+	}
+	if cached {
+		stopped := ctx.Err() != nil || (opts.Timeout > 0 && time.Since(started) >= opts.Timeout)
+		if search.visiting && len(found) == 0 && !stopped && !search.overflown {
+			searchCache.addDeadStates(scenario, search.visited)
+		}
+		searchCache.addSolutions(found)
+	}
+	Rank(found)
+	if opts.Unique {
+		found = Unique(found)
+	}
+	if opts.Distinct != "" {
+		found, _ = Distinct(found, opts.Distinct)
+	}
+	if len(found) > wanted {
+		found = found[:wanted]
+	}
+
+	if opts.Stats != nil {
+		elapsed := time.Since(started)
+		stoppable := scenario.Maximize == "" && settings.Engine != "serial"
+		timedOut := stoppable && opts.Timeout > 0 && elapsed >= opts.Timeout // Searches which ran that long were stopped
+		if !timedOut {
+			closest = nil
+		}
+		*opts.Stats = Stats{settings, searched, elapsed, timedOut, closest, nil}
+		if opts.Profile {
+			profile := &Profile{Parallel: parallel}
+			for size := start.Size; int(size) < len(search.pruned); size++ {
+				profile.Pruned = append(profile.Pruned, atomic.LoadUint64(&search.pruned[size]))
+			}
+			opts.Stats.Profile = profile
+		}
+	}
+	solutions := make([]Solution, len(found))
+	for i, seq := range found {
+		names := []string{}
+		for _, command := range seq.Commands()[seq.Origin().Size:] {
+			names = append(names, command.Name)
+		}
+		solutions[i] = Solution{names, seq.Resources, seq.Score(), seq}
+	}
+	return solutions, nil
+}
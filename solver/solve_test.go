@@ -0,0 +1,70 @@
+package solver
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// solveConcurrently solves the scenario with each of the options at once, checking every search finds
+// solutions
+func solveConcurrently(t *testing.T, scenario *Scenario, options ...Options) {
+	t.Helper()
+	var wait sync.WaitGroup
+	errs := make([]error, len(options))
+	counts := make([]int, len(options))
+	for i := range options {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			found, err := Solve(scenario, options[i])
+			errs[i], counts[i] = err, len(found)
+		}(i)
+	}
+	wait.Wait()
+	for i := range options {
+		if errs[i] != nil {
+			t.Errorf("%+v: %v", options[i], errs[i])
+		} else if counts[i] == 0 {
+			t.Errorf("%+v: found no solutions", options[i])
+		}
+	}
+}
+
+func TestConcurrentSolves(t *testing.T) {
+	scenario := readExample(t)
+	solveConcurrently(t, scenario,
+		Options{Engine: "parallel"},
+		Options{Engine: "parallel", Canonical: true},
+		Options{Engine: "parallel", Profile: true, Stats: &Stats{}},
+		Options{Engine: "best-first"},
+		Options{Engine: "serial"},
+		Options{Engine: "serial", Canonical: true},
+	)
+}
+
+func TestDeterministicSolves(t *testing.T) {
+	scenario := readExample(t)
+	var first []string
+	for run := 0; run < 5; run++ {
+		found, err := Solve(scenario, Options{Engine: "parallel", Limit: 8, Deterministic: true, PoolSize: 16})
+		if err != nil {
+			t.Fatal(err)
+		}
+		plans := []string{}
+		for i, solution := range found {
+			plans = append(plans, solution.Sequence.CommandSequence())
+			if i > 0 && solution.Score == found[i-1].Score && plans[i] < plans[i-1] {
+				t.Errorf("%s is listed after %s, which scores the same", plans[i-1], plans[i])
+			}
+		}
+		if run == 0 {
+			first = plans
+		} else if strings.Join(plans, "\n") != strings.Join(first, "\n") {
+			t.Fatalf("run %d found\n%s\nbut the first found\n%s", run, strings.Join(plans, "\n"), strings.Join(first, "\n"))
+		}
+	}
+	if len(first) != 8 {
+		t.Errorf("found %d solutions, want 8", len(first))
+	}
+}
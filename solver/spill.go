@@ -0,0 +1,96 @@
+package solver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+)
+
+// spiller serializes the sequences waiting to be searched by the parallel engine once it is over its
+// memory budget (see ParallelSearch.SetMemoryBudget).  Unlike MarshalJSON, which persists a sequence
+// on its own, they are only ever restored into the same search, so just the index of each command
+// (and crew member) taken since the start of the search is kept.  Sequences are restored in roughly
+// the order they were spilled, and neighbours share most of their actions, so the steps of the last
+// sequence restored are kept and only the actions after they diverge are taken again.
+type spiller struct {
+	start *Sequence
+	mutex sync.Mutex
+	path  []*Sequence // The steps (after start) of the sequence last restored
+}
+
+func newSpiller(start *Sequence) *spiller {
+	return &spiller{start: start}
+}
+
+// encode writes the command (and, if the scenario has a crew, the crew member) of each action taken
+// since the start of the search, oldest first, as varints
+func (self *spiller) encode(searchable parallelsearch.Searchable) ([]byte, error) {
+	seq := searchable.(*Sequence)
+	if seq.Size < self.start.Size {
+		return nil, errors.New("sequence does not extend the start of the search")
+	}
+	steps := make([]*Sequence, seq.Size-self.start.Size)
+	for prev := seq; prev.Size > self.start.Size; prev = prev.Prev {
+		steps[prev.Size-self.start.Size-1] = prev
+	}
+	scenario := self.start.scenario
+	raw := make([]byte, 0, 2*len(steps))
+	for _, step := range steps {
+		raw = binary.AppendUvarint(raw, uint64(scenario.registry.index[step.Command.Name]))
+		if len(scenario.Crew) > 0 {
+			raw = binary.AppendUvarint(raw, uint64(memberIndex(scenario, step.Member)+1))
+		}
+	}
+	return raw, nil
+}
+
+// restore takes again the actions encoded, reusing as many of the steps of the sequence last restored
+// as it shares with this one
+func (self *spiller) restore(raw []byte) (parallelsearch.Searchable, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	scenario := self.start.scenario
+	seq := self.start
+	for i := 0; len(raw) > 0; i++ {
+		command, n := binary.Uvarint(raw)
+		if n <= 0 || command >= uint64(len(scenario.Commands)) {
+			return nil, errors.New("invalid spilled command")
+		}
+		raw = raw[n:]
+		var member *CrewMember
+		if len(scenario.Crew) > 0 {
+			crew, n := binary.Uvarint(raw)
+			if n <= 0 || crew > uint64(len(scenario.Crew)) {
+				return nil, errors.New("invalid spilled crew member")
+			}
+			raw = raw[n:]
+			if crew > 0 {
+				member = &scenario.Crew[crew-1]
+			}
+		}
+		if i < len(self.path) && self.path[i].Command == &scenario.Commands[command] && self.path[i].Member == member {
+			seq = self.path[i]
+			continue
+		}
+		next, violated := seq.StepAs(&scenario.Commands[command], member)
+		if violated != nil {
+			return nil, fmt.Errorf("can not restore spilled action %s: %s", scenario.Commands[command].Name, violated.Describe(next))
+		}
+		self.path = append(self.path[:i], next)
+		seq = next
+	}
+	return seq, nil
+}
+
+// memberIndex is the index of the given crew member in the scenario's crew, or -1 if nil
+func memberIndex(scenario *Scenario, member *CrewMember) int {
+	for i := range scenario.Crew {
+		if &scenario.Crew[i] == member {
+			return i
+		}
+	}
+	return -1
+}
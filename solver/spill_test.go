@@ -0,0 +1,89 @@
+package solver
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSpilledSequencesAreRestored(t *testing.T) {
+	scenario := readExample(t)
+	found, err := Solve(scenario, Options{Engine: "parallel", Limit: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spiller := newSpiller(StartSequence(scenario))
+	for _, solution := range found {
+		seq := solution.Sequence
+		raw, err := spiller.encode(seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := spiller.restore(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := restored.(*Sequence); got.CommandSequence() != seq.CommandSequence() || got.Resources != seq.Resources {
+			t.Errorf("restored %s (%v), want %s (%v)", got.CommandSequence(), &got.Resources, seq.CommandSequence(), &seq.Resources)
+		}
+	}
+}
+
+func TestSolveWithinMemoryBudget(t *testing.T) {
+	scenario := readExample(t)
+	found, err := Solve(scenario, Options{Engine: "parallel", MemoryBudget: 1}) // Spilling all but one sequence
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) == 0 {
+		t.Fatal("found no solutions")
+	}
+	for _, solution := range found {
+		seq := solution.Sequence
+		if !seq.IsSuccess() {
+			t.Errorf("%s is not a solution", seq.CommandSequence())
+		}
+	}
+}
+
+// peakHeap samples the heap in use while solving, returning the most seen
+func peakHeap(b *testing.B, scenario *Scenario, opts Options) uint64 {
+	done, peak := make(chan struct{}), uint64(0)
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		stats := runtime.MemStats{}
+		for {
+			runtime.ReadMemStats(&stats)
+			peak = max(peak, stats.HeapInuse)
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+	if _, err := Solve(scenario, opts); err != nil {
+		b.Fatal(err)
+	}
+	close(done)
+	<-sampled
+	return peak
+}
+
+// BenchmarkSolveWithinMemoryBudget reports the peak heap (in MB) while solving, with and without a
+// memory budget.  Spilled sequences are freed only if no arena block keeps them alive.
+func BenchmarkSolveWithinMemoryBudget(b *testing.B) {
+	scenario := readExample(b)
+	for _, budget := range []int{0, 1 << 20} {
+		b.Run(fmt.Sprint("budget=", budget>>20, "MB"), func(b *testing.B) {
+			peak := uint64(0)
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				peak = max(peak, peakHeap(b, scenario, Options{Engine: "parallel", Limit: 1000, MemoryBudget: budget}))
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-MB")
+		})
+	}
+}
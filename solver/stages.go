@@ -0,0 +1,108 @@
+package solver
+
+import (
+	"fmt"
+)
+
+// Stage is one phase of a multi-stage mission (e.g. launch, then cruise, then landing), lasting a
+// fixed number of turns with its own commands and turn costs.  Its Goal must have been reached by the
+// end of its last turn before the next stage begins; the goal of the last stage is the scenario's Goal
+// (so its own must be left out).  A plan spans every stage, and only succeeds in the last.
+type Stage struct {
+	Name           string
+	Turns          uint32
+	ActionsPerTurn uint32     `json:"actions_per_turn,omitempty"` // Optional, overriding the scenario's
+	Goal           Resources  // As the scenario's Goal
+	Commands       []string   `json:",omitempty"`          // Names of the scenario's commands which may be taken (all of them if none)
+	TurnCost       *Resources `json:"turn_cost,omitempty"` // Optional, overriding the scenario's
+}
+
+// prepareStages works out the turns of the mission from those of its stages (if it has any), as well
+// as the bounds each stage's goal sets (see goalBand)
+func (self *Scenario) prepareStages() error {
+	self.stageOf, self.stageLower, self.stageUpper = nil, nil, nil
+	if len(self.Stages) == 0 {
+		return nil
+	} else if len(self.ActionsSchedule) > 0 {
+		return fmt.Errorf("actions_schedule can't be given with stages (give each stage its actions_per_turn instead)")
+	}
+	turns := uint32(0)
+	self.stageOf = []int{-1} // No stage before the first turn
+	for i, stage := range self.Stages {
+		if stage.Turns == 0 {
+			return fmt.Errorf("stage %d (%s) must last at least 1 turn", i+1, stage.Name)
+		}
+		turns += stage.Turns
+		for turn := uint32(0); turn < stage.Turns; turn++ {
+			self.stageOf = append(self.stageOf, i)
+		}
+		lower, upper := goalBand(&stage.Goal)
+		self.stageLower, self.stageUpper = append(self.stageLower, lower), append(self.stageUpper, upper)
+	}
+	if self.Turns != 0 && self.Turns != turns {
+		return fmt.Errorf("turns is %d, but the stages last %d", self.Turns, turns)
+	}
+	self.Turns = turns
+	return nil
+}
+
+// StageIn finds the stage of the given (1-based) turn, or nil if the mission has no stages
+func (self *Scenario) StageIn(turn uint32) *Stage {
+	if int(turn) >= len(self.stageOf) || self.stageOf[turn] < 0 {
+		return nil
+	}
+	return &self.Stages[self.stageOf[turn]]
+}
+
+// Stage is the stage of the mission in which the most recent action was taken (nil if none)
+func (self *Sequence) Stage() *Stage {
+	return self.scenario.StageIn(self.Turn())
+}
+
+// offers is true if the command may be taken in the given (1-based) turn, both by its own turns (see
+// Command.AvailableFromTurn) and by the commands of the stage
+func (self *Scenario) offers(command *Command, turn uint32) bool {
+	if !command.isAvailableIn(turn) {
+		return false
+	}
+	stage := self.StageIn(turn)
+	if stage == nil || len(stage.Commands) == 0 {
+		return true
+	}
+	for _, name := range stage.Commands {
+		if name == command.baseName() {
+			return true
+		}
+	}
+	return false
+}
+
+// turnCostIn is the cost of starting the given (1-based) turn
+func (self *Scenario) turnCostIn(turn uint32) *Resources {
+	if stage := self.StageIn(turn); stage != nil && stage.TurnCost != nil {
+		return stage.TurnCost
+	}
+	return &self.TurnCost
+}
+
+// turnCosts lists every cost a turn may have (the scenario's, and any stage's own)
+func (self *Scenario) turnCosts() []*Resources {
+	costs := []*Resources{&self.TurnCost}
+	for i := range self.Stages {
+		if self.Stages[i].TurnCost != nil {
+			costs = append(costs, self.Stages[i].TurnCost)
+		}
+	}
+	return costs
+}
+
+// stageEndingAt finds the index of the stage (other than the last) whose final turn is the given one,
+// or -1 if there is none
+func (self *Scenario) stageEndingAt(turn uint32) int {
+	if int(turn) >= len(self.stageOf) || self.stageOf[turn] < 0 || self.stageOf[turn] == len(self.Stages)-1 {
+		return -1
+	} else if int(turn)+1 < len(self.stageOf) && self.stageOf[turn+1] == self.stageOf[turn] {
+		return -1
+	}
+	return self.stageOf[turn]
+}
@@ -0,0 +1,91 @@
+package solver
+
+import (
+	"sort"
+)
+
+// SearchNode is a node of the tree the search explores (see SearchTree): an action tried, and what
+// became of it
+type SearchNode struct {
+	Action    string        `json:"action"` // e.g. "GCC" (or "[START]" at the root)
+	Turn      uint32        `json:"turn,omitempty"`
+	Resources Resources     `json:"resources"`          // Those left after the action
+	Found     bool          `json:"found,omitempty"`    // If the goal is met here (so the search goes no further)
+	Pruned    string        `json:"pruned,omitempty"`   // Why the search goes no further, e.g. the rule the action breaks
+	Children  []*SearchNode `json:"children,omitempty"` // Every action tried next, in scenario order
+	Omitted   int           `json:"omitted,omitempty"`  // Legal actions tried next which are left out (beyond the top branches)
+}
+
+// SearchTree explores the tree the search would from start, level by level (as ExpandLevel) to the
+// given depth, recording every action tried at each node along with why the search goes no further
+// with it, if it doesn't: the rule it breaks, the same state having been reached already, or the
+// state being a dead end (proved by an earlier search).  Commands not offered at a node are left out
+// altogether.  With branches above 0, only that many of the legal actions from each node (those
+// nearest the goal, see Heuristic and GoalDistance) are explored further; the rest are counted in
+// Omitted.
+func SearchTree(start *Sequence, depth int, branches int) *SearchNode {
+	scenario := start.scenario
+	pruning := searchCache.hasDeadStates(scenario)
+	root := &SearchNode{Action: start.CommandName(), Turn: start.Turn(), Resources: start.Resources, Found: start.IsFound()}
+	type branch struct {
+		seq  *Sequence
+		node *SearchNode
+	}
+	frontier := []branch{{start, root}}
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		next := []branch{}
+		seen := map[SearchState]*Sequence{}
+		for _, parent := range frontier {
+			if parent.node.Found || !parent.seq.hasMoreActionsAvailable() {
+				continue
+			}
+			legal := []branch{}
+			turn, _ := scenario.position(parent.seq.Size + 1)
+			for i := range scenario.Commands {
+				if !scenario.offers(&scenario.Commands[i], turn) {
+					continue
+				}
+				for _, member := range parent.seq.freeCrew() {
+					child, violated := parent.seq.StepAs(&scenario.Commands[i], member)
+					node := &SearchNode{Action: child.CommandName(), Turn: child.Turn(), Resources: child.Resources}
+					parent.node.Children = append(parent.node.Children, node)
+					if violated != nil {
+						node.Pruned = violated.Describe(child)
+					} else if first := seen[child.State()]; first != nil {
+						node.Pruned = "the same state as " + first.CommandSequence()
+					} else if pruning && searchCache.isDead(scenario, child.State()) {
+						node.Pruned = "a dead end (proved by an earlier search)"
+					} else {
+						seen[child.State()] = child
+						node.Found = child.IsFound()
+						legal = append(legal, branch{child, node})
+					}
+				}
+			}
+			if branches > 0 && len(legal) > branches {
+				sort.SliceStable(legal, func(i, j int) bool {
+					a, b := legal[i].seq, legal[j].seq
+					if a.Heuristic() != b.Heuristic() {
+						return a.Heuristic() < b.Heuristic()
+					}
+					return a.GoalDistance() < b.GoalDistance()
+				})
+				parent.node.Omitted = len(legal) - branches
+				omitted := map[*SearchNode]bool{}
+				for _, b := range legal[branches:] {
+					omitted[b.node] = true
+				}
+				children := parent.node.Children[:0]
+				for _, child := range parent.node.Children {
+					if !omitted[child] {
+						children = append(children, child)
+					}
+				}
+				parent.node.Children, legal = children, legal[:branches]
+			}
+			next = append(next, legal...)
+		}
+		frontier = next
+	}
+	return root
+}
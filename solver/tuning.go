@@ -0,0 +1,117 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+)
+
+// Tuning holds the engine settings used for a search
+type Tuning struct {
+	Engine   string // "serial" (deterministic), "parallel" (breadth-first), "best-first", "breadth-first", "depth-first", or "beam"
+	PoolSize int    // Workers for every engine but the serial one
+	Beam     int    // Nodes searched per depth by the parallel and breadth-first engines (or kept by the beam engine), or zero for no limit
+	Dedupe   bool   // Search sequences arriving at the same state only once (see SearchState), as the serial and beam engines always do
+}
+
+// DefaultBeamWidth is how many nodes the beam engine keeps at each depth unless told otherwise
+const DefaultBeamWidth = 1000
+
+// autoBeamWidth is how many nodes the parallel engine searches per depth when AutoTune finds the tree
+// too large to search in full
+const autoBeamWidth = 100000
+
+// tuningSample is how many states AutoTune explores (see EstimateDifficulty) to measure how much the
+// search tree branches
+const tuningSample = 1000
+
+// AutoTune picks sensible settings from the size of the search tree, estimated by exploring the first
+// few levels of it (see TuneFor)
+func AutoTune(start *Sequence) Tuning {
+	return TuneFor(start, EstimateDifficulty(start, tuningSample))
+}
+
+// TuneFor picks sensible settings from the size of the search tree: the branching measured (or if
+// nothing was expanded, the number of commands) to the power of the actions remaining.  Small trees are
+// solved fastest by the serial engine, which has no coordination overhead; larger ones are spread over
+// a worker pool sized to the machine, searching at most autoBeamWidth nodes per depth once they are too
+// large to search in full; and trees so large that a breadth-first search is hopeless are searched
+// best-first.  Sequences arriving at the same state are searched only once unless none of the legal
+// actions explored (if there were many) arrived at a state reached already.
+func TuneFor(start *Sequence, difficulty *Difficulty) Tuning {
+	branching := difficulty.Branching
+	if branching == 0 {
+		branching = float64(len(start.scenario.Commands))
+	}
+	depth := float64(start.scenario.TotalActions() - start.Size)
+	magnitude := depth * math.Log10(math.Max(branching, 1))
+	dedupe := difficulty.Repeats > 0 || difficulty.States < tuningSample
+	switch {
+	case magnitude < 6:
+		return Tuning{Engine: "serial", Dedupe: true}
+	case magnitude < 10:
+		return Tuning{Engine: "parallel", PoolSize: PoolSizeFor(runtime.GOMAXPROCS(0)), Dedupe: dedupe}
+	case magnitude < 18:
+		return Tuning{Engine: "parallel", PoolSize: PoolSizeFor(runtime.GOMAXPROCS(0)), Beam: autoBeamWidth, Dedupe: dedupe}
+	default:
+		return Tuning{Engine: "best-first", PoolSize: PoolSizeFor(runtime.GOMAXPROCS(0)), Dedupe: dedupe}
+	}
+}
+
+// PoolSizeFor sizes the worker pool for the given number of CPUs (those the search may use at once,
+// see GOMAXPROCS).  Workers spend much of their time waiting on each other, so there are several to
+// each CPU.  See the bench command for measuring what suits a machine and scenario.
+func PoolSizeFor(cpus int) int {
+	if poolSize := 16 * cpus; poolSize < 128 {
+		return poolSize
+	}
+	return 128
+}
+
+func (self *Tuning) String() string {
+	if self.Engine == "serial" {
+		return "serial engine"
+	}
+	duplicates := ""
+	if !self.Dedupe && self.Engine != "beam" {
+		duplicates = ", keeping duplicates"
+	}
+	if self.Engine == "best-first" || self.Engine == "depth-first" {
+		return fmt.Sprintf("%s engine (%d workers%s)", self.Engine, self.PoolSize, duplicates)
+	} else if self.Engine == "breadth-first" && self.Beam > 0 {
+		return fmt.Sprintf("breadth-first engine (%d workers, beam of %d%s)", self.PoolSize, self.Beam, duplicates)
+	} else if self.Engine == "breadth-first" {
+		return fmt.Sprintf("breadth-first engine (%d workers, no beam%s)", self.PoolSize, duplicates)
+	}
+	if self.Engine == "beam" && self.Beam == 0 {
+		return fmt.Sprintf("beam engine (%d workers, keeping every node)", self.PoolSize)
+	} else if self.Engine == "beam" {
+		return fmt.Sprintf("beam engine (%d workers, keeping %d nodes per depth)", self.PoolSize, self.Beam)
+	}
+	beam := "no beam"
+	if self.Beam > 0 {
+		beam = fmt.Sprint("beam of ", self.Beam)
+	}
+	return fmt.Sprintf("parallel engine (%d workers, %s%s)", self.PoolSize, beam, duplicates)
+}
+
+// strategy is the order in which the best-first, breadth-first, and depth-first engines search (see
+// parallelsearch.StrategySearch), or nil for the other engines.  Unlike the parallel engine, the
+// breadth-first one does not wait for each depth to finish, and its beam keeps the most promising
+// nodes rather than the first found.
+func (self *Tuning) strategy() parallelsearch.Strategy {
+	switch self.Engine {
+	case "best-first":
+		return parallelsearch.NewBestFirstStrategy()
+	case "breadth-first":
+		if self.Beam > 0 {
+			return parallelsearch.NewBeamStrategy(self.Beam)
+		}
+		return parallelsearch.NewBreadthFirstStrategy()
+	case "depth-first":
+		return parallelsearch.NewDepthFirstStrategy()
+	}
+	return nil
+}
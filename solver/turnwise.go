@@ -0,0 +1,46 @@
+package solver
+
+// SolveTurnwise greedily plans one turn at a time.  Every way of playing out the current turn is
+// considered, and the one which leaves the sequence closest to the goal is kept, without any look
+// ahead to later turns.  This is fast but may be worse than the optimum (or get stuck entirely), so
+// the returned sequence does not necessarily meet the goal.
+func SolveTurnwise(start *Sequence) *Sequence {
+	seq := start
+	for !seq.IsSuccess() && seq.hasMoreActionsAvailable() {
+		var best *Sequence
+		frontier := []*Sequence{seq}
+		for len(frontier) > 0 {
+			next, _ := ExpandLevel(frontier)
+			frontier = nil
+			for _, candidate := range next {
+				if candidate.IsFound() || candidate.IsTurnEnd() || !candidate.hasMoreActionsAvailable() {
+					if best == nil || candidate.closerToGoal(best) {
+						best = candidate
+					}
+				} else {
+					frontier = append(frontier, candidate)
+				}
+			}
+		}
+		if best == nil {
+			return seq // No legal way to finish the turn
+		}
+		seq = best
+	}
+	return seq
+}
+
+// closerToGoal prefers sequences which meet the goal (the shorter the better), then those with the
+// smallest shortfall, using score to break ties
+func (self *Sequence) closerToGoal(other *Sequence) bool {
+	if self.IsSuccess() != other.IsSuccess() {
+		return self.IsSuccess()
+	}
+	if self.IsSuccess() && self.Size != other.Size {
+		return self.Size < other.Size
+	}
+	if distance, otherDistance := self.GoalDistance(), other.GoalDistance(); distance != otherDistance {
+		return distance < otherDistance
+	}
+	return self.Score() < other.Score()
+}
@@ -0,0 +1,130 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is a mistake in a scenario found by Validate.  Key locates it in the scenario file,
+// e.g. "turns" or "commands.gcc".
+type ValidationError struct {
+	Key     string
+	Message string
+}
+
+func (self *ValidationError) Error() string {
+	return self.Key + ": " + self.Message
+}
+
+// Validate checks the scenario for mistakes which would otherwise only show up as bizarre search
+// results: no turns, no commands, commands with negative inputs, impossible failure rates, or the same
+// name, crew members with the same name (or one which can't be written in a plan) or too few to take
+// every action of a turn, or a goal which no number of actions could reach (or goal bounds which
+// contradict each other or the caps).
+func (self *Scenario) Validate() []*ValidationError {
+	problems := []*ValidationError{}
+	report := func(key string, format string, args ...interface{}) {
+		problems = append(problems, &ValidationError{key, fmt.Sprintf(format, args...)})
+	}
+
+	if self.Turns == 0 {
+		report("turns", "must be at least 1")
+	}
+	if len(self.Commands) == 0 {
+		report("commands", "there must be at least one command")
+	}
+	seen := map[string]bool{}
+	for _, command := range self.Commands {
+		key := "commands." + command.Name
+		if seen[command.Name] {
+			report(key, "there is more than one command named %s", command.Name)
+		}
+		seen[command.Name] = true
+		if command.FailureRate < 0 || command.FailureRate >= 1 {
+			report(key, "failure_rate must be at least 0 and less than 1 (not %g)", command.FailureRate)
+		}
+		if command.AvailableFromTurn > self.Turns {
+			report(key, "available_from_turn is %d, but there are only %d turns", command.AvailableFromTurn, self.Turns)
+		} else if command.AvailableUntilTurn > 0 && command.AvailableUntilTurn < command.AvailableFromTurn {
+			report(key, "available_until_turn (%d) is before available_from_turn (%d)", command.AvailableUntilTurn, command.AvailableFromTurn)
+		}
+		for _, resource := range AllResources {
+			if value := command.Input.Get(resource); value < 0 {
+				report(key, "input has negative %s (%d); gains belong in the output", resource, value)
+			}
+		}
+	}
+
+	seen = map[string]bool{}
+	for _, member := range self.Crew {
+		key := "crew." + member.Name
+		if seen[strings.ToLower(member.Name)] {
+			report(key, "there is more than one crew member named %s", member.Name)
+		} else if strings.ContainsAny(member.Name, "@ \t") {
+			report(key, "crew member names may not contain spaces or @")
+		}
+		seen[strings.ToLower(member.Name)] = true
+	}
+	for i, stage := range self.Stages {
+		for _, name := range stage.Commands {
+			if self.commandIndex(name) < 0 {
+				report("stages", "stage %d (%s) offers %s, but there is no such command", i+1, stage.Name, name)
+			}
+		}
+		if i == len(self.Stages)-1 && stage.Goal != (Resources{}) {
+			report("stages", "the last stage (%s) may not have a goal of its own; the scenario's goal is its goal", stage.Name)
+		}
+	}
+	for turn := uint32(1); len(self.Crew) > 0 && turn <= self.Turns; turn++ {
+		if actions := self.ActionsIn(turn); int(actions) > len(self.Crew) {
+			report("crew", "%d crew members can't take the %d actions of turn %d", len(self.Crew), actions, turn)
+			break
+		}
+	}
+
+	// The most each action could raise (or lower) a resource is a generous bound on what the whole
+	// mission can
+	raise, lower := self.actionLimits()
+	turnEnd := self.turnEndConstraint()
+	actions := 0
+	for turn := uint32(1); turn <= self.Turns; turn++ {
+		actions += int(self.ActionsIn(turn))
+	}
+	for _, resource := range AllResources {
+		if turnEnd.Min.Get(resource) > turnEnd.Max.Get(resource) {
+			report("turn_end_max", "turns must end with %s at least %d, but at most %d", resource, turnEnd.Min.Get(resource), turnEnd.Max.Get(resource))
+		}
+		if self.Caps != nil && self.Start.Get(resource) > self.Caps.Get(resource) {
+			report("caps", "%s is capped at %d, but starts at %d", resource, self.Caps.Get(resource), self.Start.Get(resource))
+		}
+	}
+	if len(self.GoalAnyOf) > 0 && self.Goal != (Resources{}) {
+		report("goal", "is ignored when goal_any_of is given (make it one of the alternatives instead)")
+	}
+	goals := self.Goals()
+	for i := range goals {
+		goalKey, goalMaxKey, name, which := "goal", "goal_max", "the goal", "" // Which goal, if there are alternatives
+		if len(self.GoalAnyOf) > 0 {
+			goalKey, goalMaxKey, name = "goal_any_of", "goal_any_of", fmt.Sprint("goal ", i+1)
+			which = name + " "
+		}
+		least, most := self.goalBounds(&goals[i])
+		for _, resource := range AllResources {
+			if self.Caps != nil && IsBounded(self.Caps.Get(resource)) && least.Get(resource) > self.Caps.Get(resource) {
+				report("caps", "%s is capped at %d, but %s needs %d", resource, self.Caps.Get(resource), name, least.Get(resource))
+			}
+			if self.FailAbove != nil && least.Get(resource) > self.FailAbove.Get(resource) {
+				report("fail_above", "the mission fails once %s exceeds %d, but %s needs %d", resource, self.FailAbove.Get(resource), name, least.Get(resource))
+			}
+			start := self.Start.Get(resource)
+			if needs := least.Get(resource); needs > most.Get(resource) {
+				report(goalMaxKey, "%sneeds %s of at least %d, but at most %d", which, resource, needs, most.Get(resource))
+			} else if highest := start + actions*raise.Get(resource); IsBounded(needs) && needs > 0 && highest < needs {
+				report(goalKey, "%sneeds %s %d, but at most %d can be had in %d actions", which, resource, needs, highest, actions)
+			} else if lowest := start - actions*lower.Get(resource); IsBounded(most.Get(resource)) && lowest > most.Get(resource) {
+				report(goalMaxKey, "%sneeds %s at most %d, but at least %d remains after %d actions", which, resource, most.Get(resource), lowest, actions)
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,38 @@
+package solver
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	scenario := parseTestScenario(t, `
+turns: 2
+actions_per_turn: 2
+start: 2w
+goal: 9r
+commands:
+  srt: w 2r
+  leak: {input: -h}
+turn_must_end_above: ""
+turn_must_end_below: ""
+`)
+	scenario.Commands = append(scenario.Commands, scenario.Commands[0])
+	want := map[string]string{
+		"commands.srt":  "there is more than one command named srt",
+		"commands.leak": "input has negative heat (-1); gains belong in the output",
+		"goal":          "needs comm 9, but at most 8 can be had in 4 actions",
+	}
+	for _, problem := range scenario.Validate() {
+		if want[problem.Key] != problem.Message {
+			t.Errorf("unexpected problem %v", problem)
+		}
+		delete(want, problem.Key)
+	}
+	for key, message := range want {
+		t.Errorf("missing problem %s: %s", key, message)
+	}
+
+	if problems := readExample(t).Validate(); len(problems) > 0 {
+		t.Errorf("the example has problems: %v", problems)
+	}
+}
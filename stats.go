@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// printStats reports (to stderr, so as not to disturb the solutions) how a search went: for each
+// depth the nodes searched, ruled out by a constraint, skipped as duplicates, and dropped beyond the
+// beam, and when the depth was finished; then the peak queue and how busy the workers were.  Only the
+// parallel engine accounts for more than the nodes ruled out.
+func printStats(stats *solver.Stats) {
+	out := os.Stderr
+	fmt.Fprintf(out, "\n%s (%s engine, %d workers): %d searched in %s\n", solver.Colorize("yellow", "Search statistics"), stats.Tuning.Engine, stats.Tuning.PoolSize, stats.Searched, stats.Elapsed.Round(time.Millisecond))
+	profile := stats.Profile
+	if profile == nil {
+		return
+	}
+	parallel := profile.Parallel
+	if parallel == nil {
+		fmt.Fprintf(out, "%6s %12s\n", "depth", "pruned")
+	} else {
+		fmt.Fprintf(out, "%6s %12s %12s %12s %12s %10s\n", "depth", "searched", "pruned", "duplicates", "dropped", "finished")
+	}
+	for depth := range profile.Pruned {
+		pruned := profile.Pruned[depth]
+		if parallel == nil || depth >= len(parallel.Depths) {
+			if pruned > 0 {
+				fmt.Fprintf(out, "%6d %12d\n", depth, pruned)
+			}
+			continue
+		}
+		d := parallel.Depths[depth]
+		if d.Searched == 0 && d.Duplicates == 0 && d.Dropped == 0 && pruned == 0 {
+			continue
+		}
+		finished := "-"
+		if d.Finished > 0 {
+			finished = d.Finished.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(out, "%6d %12d %12d %12d %12d %10s\n", depth, d.Searched, pruned, d.Duplicates, d.Dropped, finished)
+	}
+	if parallel != nil {
+		fmt.Fprintf(out, "peak queue: %d nodes\n", parallel.PeakQueued)
+		fmt.Fprintf(out, "worker utilization: %.0f%% of %d workers (%s busy in all)\n", 100*parallel.Utilization(), parallel.Workers, parallel.Busy.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// treeCommand implements the "tree" subcommand, which writes the tree the search explores from the
+// start (or from -taken, to see why the search passes over a plan) as Graphviz DOT or JSON, each node
+// annotated with its resources and why the search went no further with it (see solver.SearchTree)
+func treeCommand(args []string) {
+	flags := flag.NewFlagSet("tree", flag.ExitOnError)
+	scenarioFlags := newScenarioFlags(flags)
+	depth := flags.Int("depth", 3, "actions to explore ahead")
+	branches := flags.Int("branches", 5, "legal actions from each node to explore further, those nearest the goal (0 for all of them)")
+	format := flags.String("format", "dot", "how to write the tree: dot (for Graphviz, e.g. \"| dot -Tsvg > tree.svg\") or json")
+	output := flags.String("o", "", "file to write the tree to (stdout if none)")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("Usage: ", os.Args[0], " tree [OPTIONS]")
+	}
+	writers := map[string]func(io.Writer, *solver.SearchNode) error{"dot": writeTreeDOT, "json": writeTreeJSON}
+	write := writers[*format]
+	if write == nil {
+		log.Fatal("Unknown format: ", *format, " (try dot or json)")
+	}
+	scenario := scenarioFlags.load()
+	tree := solver.SearchTree(scenarioFlags.start(scenario), *depth, *branches)
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := write(out, tree); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeTreeJSON(out io.Writer, tree *solver.SearchNode) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tree)
+}
+
+// writeTreeDOT writes the tree as a Graphviz digraph: nodes meeting the goal in green, those pruned
+// dashed in red (labelled with why), and a note of any legal actions left out
+func writeTreeDOT(out io.Writer, tree *solver.SearchNode) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintln(w, "digraph search {")
+	fmt.Fprintln(w, "\tnode [shape=box, fontname=monospace];")
+	count := 0
+	var write func(node *solver.SearchNode) string
+	write = func(node *solver.SearchNode) string {
+		id := fmt.Sprint("n", count)
+		count++
+		label := []string{node.Action, plainResources(node.Resources)}
+		attributes := ""
+		if node.Found {
+			label, attributes = append(label, "goal met"), ", color=green, style=bold"
+		} else if node.Pruned != "" {
+			label, attributes = append(label, node.Pruned), ", color=red, style=dashed"
+		}
+		fmt.Fprintf(w, "\t%s [label=%s%s];\n", id, strconv.Quote(strings.Join(label, "\n")), attributes)
+		for _, child := range node.Children {
+			fmt.Fprintf(w, "\t%s -> %s;\n", id, write(child))
+		}
+		if node.Omitted > 0 {
+			omitted := fmt.Sprint(id, "_omitted")
+			fmt.Fprintf(w, "\t%s [label=\"%d more\", shape=plaintext];\n", omitted, node.Omitted)
+			fmt.Fprintf(w, "\t%s -> %s [style=dotted];\n", id, omitted)
+		}
+		return id
+	}
+	write(tree)
+	fmt.Fprintln(w, "}")
+	return w.Flush()
+}
+
+// plainResources lists the resources held (other than none), without color, e.g. "comm 2, nav 4"
+func plainResources(resources solver.Resources) string {
+	held := []string{}
+	for _, resource := range solver.AllResources {
+		if value := resources.Get(resource); value != 0 {
+			held = append(held, fmt.Sprint(resource, " ", value))
+		}
+	}
+	return strings.Join(held, ", ")
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/parallelsearch"
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+	"golang.org/x/term"
+)
+
+// dashboard is a full-screen view of a running search, redrawn several times a second.  It shows the
+// same state as a SIGUSR1 dump (see dumpOnSignal) along with the search rate and any solutions found,
+// and lets the user steer the search from the keyboard.
+type dashboard struct {
+	ps      *parallelsearch.ParallelSearch
+	miss    *nearMiss
+	mutex   sync.Mutex
+	found   []*solver.Sequence
+	rates   []float64 // Nodes searched per second, most recent last
+	total   uint64
+	started time.Time
+	updated time.Time
+}
+
+var dashboardKeys = "q: stop & print   b/B: tighten/widen beam   -/+: lower/raise depth limit"
+
+func newDashboard(ps *parallelsearch.ParallelSearch, miss *nearMiss) *dashboard {
+	return &dashboard{ps: ps, miss: miss, started: time.Now(), updated: time.Now()}
+}
+
+// observe is called (concurrently) for every node searched
+func (self *dashboard) observe(s parallelsearch.Searchable) {
+	self.miss.observe(s)
+	if s.IsFound() {
+		self.mutex.Lock()
+		self.found = append(self.found, s.(*solver.Sequence))
+		self.mutex.Unlock()
+	}
+}
+
+// start takes over the terminal until the returned function is called (once the search is over)
+func (self *dashboard) start() func() {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return func() {}
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return func() {}
+	}
+	fmt.Print("\033[?1049h\033[?25l") // Switch to the alternate screen & hide the cursor
+
+	keys := make(chan byte)
+	go func() {
+		key := make([]byte, 1)
+		for {
+			if n, err := os.Stdin.Read(key); err != nil || n == 0 {
+				return
+			}
+			keys <- key[0]
+		}
+	}()
+
+	done := make(chan bool)
+	finished := make(chan bool)
+	go func() {
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case key := <-keys:
+				self.handle(key)
+			case <-ticker.C:
+			case <-done:
+				close(finished)
+				return
+			}
+			self.draw()
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		fmt.Print("\033[?25h\033[?1049l")
+		term.Restore(fd, state)
+	}
+}
+
+func (self *dashboard) handle(key byte) {
+	switch key {
+	case 'q', 's', 3: // 3 is Ctrl-C, which raw mode no longer turns into a signal
+		self.ps.Stop()
+	case 'b':
+		width := self.ps.WidthLimit()
+		if width == 0 {
+			progress := self.ps.Progress()
+			width = int(progress.Searched[progress.Depth])
+		}
+		if width /= 2; width < 1 {
+			width = 1
+		}
+		self.ps.SetWidthLimit(width)
+	case 'B':
+		self.ps.SetWidthLimit(self.ps.WidthLimit() * 2)
+	case '-':
+		if depth := self.ps.DepthLimit(); depth > 1 {
+			self.ps.SetDepthLimit(depth - 1)
+		}
+	case '+', '=':
+		self.ps.SetDepthLimit(self.ps.DepthLimit() + 1)
+	}
+}
+
+func (self *dashboard) draw() {
+	progress := self.ps.Progress()
+	now := time.Now()
+	total := progress.Total()
+	if elapsed := now.Sub(self.updated).Seconds(); elapsed > 0 {
+		self.rates = append(self.rates, float64(total-self.total)/elapsed)
+		if len(self.rates) > 60 {
+			self.rates = self.rates[1:]
+		}
+	}
+	self.total, self.updated = total, now
+
+	lines := []string{
+		solver.Colorize("yellow", "MARS HORIZON MISSION SOLVER") + fmt.Sprintf("   %s elapsed", now.Sub(self.started).Round(time.Second)),
+		solver.Colorize("gray", dashboardKeys),
+		"",
+	}
+
+	// Per-depth progress (on a log scale, since each depth is typically much larger than the last)
+	most := uint64(1)
+	for _, searched := range progress.Searched {
+		if searched > most {
+			most = searched
+		}
+	}
+	depthLimit := self.ps.DepthLimit()
+	for depth, searched := range progress.Searched {
+		if depth > depthLimit && searched == 0 {
+			break
+		}
+		width := int(40 * math.Log1p(float64(searched)) / math.Log1p(float64(most)))
+		bar := strings.Repeat("█", width) + strings.Repeat(" ", 40-width)
+		if depth > depthLimit {
+			bar = solver.Colorize("gray", bar)
+		}
+		lines = append(lines, fmt.Sprintf("depth %2d |%s| %d", depth, bar, searched))
+	}
+	lines = append(lines, "")
+
+	rate := 0.0
+	if len(self.rates) > 0 {
+		rate = self.rates[len(self.rates)-1]
+	}
+	lines = append(lines, fmt.Sprintf("nodes/sec %s %.0f/s", sparkline(self.rates), rate))
+
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	beam := "unlimited"
+	if width := self.ps.WidthLimit(); width > 0 {
+		beam = fmt.Sprint(width, " per depth")
+	}
+	lines = append(lines, fmt.Sprintf("frontier %d queued   depth limit %d   beam %s   memory %d MiB", progress.Queued, depthLimit, beam, memory.Alloc>>20))
+	lines = append(lines, "")
+
+	self.mutex.Lock()
+	if len(self.found) > 0 {
+		lines = append(lines, solver.Colorize("green", "found ", len(self.found), " solutions"))
+		for i := len(self.found) - 1; i >= 0 && i >= len(self.found)-5; i-- {
+			lines = append(lines, "  "+self.found[i].CommandSequence())
+		}
+	} else if closest := self.miss.closest(); closest != nil {
+		lines = append(lines, "closest so far: "+closest.CommandSequence(), "                "+closest.GoalShortfall())
+	}
+	self.mutex.Unlock()
+
+	// Raw mode does not translate newlines, so each line must also return the cursor
+	fmt.Print("\033[H\033[2J" + strings.Join(lines, "\r\n"))
+}
+
+// sparkline draws a tiny bar graph of the values
+func sparkline(values []float64) string {
+	ticks := []rune("▁▂▃▄▅▆▇█")
+	most := 0.0
+	for _, value := range values {
+		most = math.Max(most, value)
+	}
+	line := []rune{}
+	for _, value := range values {
+		i := 0
+		if most > 0 {
+			i = int(value / most * float64(len(ticks)-1))
+		}
+		line = append(line, ticks[i])
+	}
+	return string(line)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+)
+
+// verifyCommand implements the "verify" subcommand (see verifyPlan), exiting with an error if the plan fails
+func verifyCommand(args []string) {
+	if len(args) != 2 {
+		log.Fatal("Usage: ", os.Args[0], " verify SCENARIO PLAN")
+	}
+	if !verifyPlan(readScenario(args[0]), args[1]) {
+		os.Exit(1)
+	}
+}
+
+func readPlan(path string) []string {
+	var raw []byte
+	var err error
+	if path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return solver.ParsePlan(string(raw))
+}
+
+// verifyPlan replays a plan produced elsewhere (another player, an older run) against the scenario and
+// reports whether every action is legal and the goal is met.  If not, the exact violation is shown.
+func verifyPlan(scenario *solver.Scenario, path string) bool {
+	fmt.Println("START: ", scenario.Start.String())
+	seq, err := solver.ReplayPlan(scenario, readPlan(path), func(seq *solver.Sequence) {
+		fmt.Println(solver.Colorize("gray", fmt.Sprintf("[%d.%d]", seq.Turn(), seq.Action())), seq.CommandName(), "\t", seq.Resources)
+	})
+	if err != nil {
+		fmt.Println(solver.Colorize("red", "FAIL"), err)
+		return false
+	}
+	fmt.Println(solver.Colorize("green", "PASS"), "in", seq.Size, "actions")
+	return true
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/david-mccullars/mars-horizon-mission-solver/solver"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long to wait after a scenario file changes for any further changes (saving a file
+// often takes several) before solving it again
+const watchSettle = 100 * time.Millisecond
+
+// watchAndSolve implements -watch: it solves the scenario at path, then again each time the file is
+// saved, until interrupted.  Each run is this command run again with the same arguments (less -watch),
+// in a process of its own, so that a scenario left invalid mid-edit only ends that run.
+func watchAndSolve(path string, args []string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer watcher.Close()
+	// Editors often save by writing a new file and renaming it over the old one, so the directory is
+	// watched rather than the file itself
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		log.Fatal(err)
+	}
+	solve := func() {
+		cmd := exec.Command(os.Args[0], append(append([]string{"solve"}, args...), "-watch=false")...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Println(solver.Colorize("red", "Solving failed: ", err))
+		}
+		fmt.Println()
+		fmt.Println(solver.Colorize("gray", "Watching ", path, " for changes (Ctrl-C to stop)"))
+	}
+	solve()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !isSaveOf(event, path) {
+				continue
+			}
+			settled := time.After(watchSettle)
+			for waiting := true; waiting; {
+				select {
+				case <-watcher.Events:
+				case <-settled:
+					waiting = false
+				}
+			}
+			logger.Info("scenario changed, solving again", "scenario", path)
+			solve()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("could not watch the scenario", "scenario", path, "error", err)
+		}
+	}
+}
+
+// isSaveOf is true if the event is the file at path being written (or replaced)
+func isSaveOf(event fsnotify.Event, path string) bool {
+	return filepath.Clean(event.Name) == filepath.Clean(path) && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+}